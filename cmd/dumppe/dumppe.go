@@ -12,11 +12,11 @@ import (
 var dumpHeaders bool
 var dumpSections bool
 var dumpDebugInfo bool
+var dumpAuthenticode bool
 
 /*
 var dumpImports bool
 var dumpExports bool
-var dumpAuthenticode bool
 var dumpWinMD bool
 var dumpResources bool
 */
@@ -26,6 +26,7 @@ func init() {
 	flag.BoolVar(&dumpHeaders, "headers", false, "dump essential headers")
 	flag.BoolVar(&dumpSections, "sections", false, "dump section headers")
 	flag.BoolVar(&dumpDebugInfo, "debuginfo", false, "dump debug info")
+	flag.BoolVar(&dumpAuthenticode, "authenticode", false, "dump authenticode signature summary")
 	flag.Parse()
 }
 
@@ -68,10 +69,16 @@ func main() {
 	if dumpDebugInfo {
 		runDumpDebugInfo(pef)
 	}
+	if dumpAuthenticode {
+		runDumpAuthenticode(pef)
+	}
 }
 
 func runDumpHeaders(peh *pe.PEHeaders) {
 	fmt.Printf("FileHeader:\n\n%#v\n\n", *(peh.FileHeader()))
+	_, machineName := peh.Machine()
+	fmt.Printf("Machine: %s\n", machineName)
+	fmt.Printf("Characteristics: %v\n\n", peh.Characteristics())
 	fmt.Printf("(more to come)\n\n")
 }
 
@@ -79,11 +86,80 @@ func runDumpSections(peh *pe.PEHeaders) {
 	sections := peh.Sections()
 	fmt.Printf("%d sections:\n\n", len(sections))
 	for i, sec := range sections {
-		fmt.Printf("Index %2d: %s\n%#v\n\n", i, sec.NameString(), sec)
+		name, err := peh.SectionName(i)
+		if err != nil {
+			name = sec.NameString()
+		}
+		fmt.Printf("Index %2d: %s\n%#v\n\n", i, name, sec)
 	}
 	fmt.Printf("(more to come)\n\n")
 }
 
 func runDumpDebugInfo(peh *pe.PEHeaders) {
-	fmt.Printf("(more to come)\n\n")
+	dirs, err := peh.DebugEntries()
+	if err == pe.ErrNotPresent {
+		fmt.Printf("no debug info\n\n")
+		return
+	}
+	if err != nil {
+		log.Fatalf("error reading debug directory: %v\n", err)
+	}
+
+	fmt.Printf("%d debug directory entries:\n\n", len(dirs))
+	for i, de := range dirs {
+		fmt.Printf("Index %2d: %s, %d bytes\n", i, de.TypeName, de.SizeOfData)
+		switch de.Type {
+		case pe.IMAGE_DEBUG_TYPE_CODEVIEW:
+			cv, err := peh.ExtractCodeViewInfo(de.IMAGE_DEBUG_DIRECTORY)
+			if err != nil {
+				fmt.Printf("\terror reading CodeView info: %v\n\n", err)
+				continue
+			}
+			fmt.Printf("\tPDB path: %s\n\tSymbol server key: %s\n", cv.PDBPath, cv.String())
+		case pe.IMAGE_DEBUG_TYPE_REPRO:
+			hash, err := peh.ExtractReproHash(de.IMAGE_DEBUG_DIRECTORY)
+			if err != nil {
+				fmt.Printf("\terror reading REPRO hash: %v\n\n", err)
+				continue
+			}
+			fmt.Printf("\tReproducible build hash: %x\n", hash)
+		}
+		fmt.Printf("\n")
+	}
+}
+
+func runDumpAuthenticode(peh *pe.PEHeaders) {
+	certsAny, err := peh.DataDirectoryEntry(pe.IMAGE_DIRECTORY_ENTRY_SECURITY)
+	if err == pe.ErrNotPresent {
+		fmt.Printf("not signed\n\n")
+		return
+	}
+	if err != nil {
+		log.Fatalf("error reading authenticode certificates: %v\n", err)
+	}
+
+	certs := certsAny.([]pe.AuthenticodeCert)
+	fmt.Printf("%d certificate entries:\n\n", len(certs))
+	for i, cert := range certs {
+		fmt.Printf("Index %2d: Revision 0x%04X, Type %d, %d bytes\n", i, cert.Revision(), cert.Type(), len(cert.Data()))
+		if cert.Type() != pe.WIN_CERT_TYPE_PKCS_SIGNED_DATA {
+			fmt.Printf("\n")
+			continue
+		}
+
+		signers, err := cert.Signers()
+		if err != nil {
+			fmt.Printf("\terror parsing PKCS#7 signed data: %v\n\n", err)
+			continue
+		}
+
+		for _, signer := range signers {
+			subject := signer.Subject()
+			if subject == "" {
+				subject = "(signing certificate not embedded)"
+			}
+			fmt.Printf("\tSigner: %s\n\tTimestamped: %v\n", subject, signer.HasTimestamp)
+		}
+		fmt.Printf("\n")
+	}
 }
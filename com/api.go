@@ -7,11 +7,25 @@
 package com
 
 import (
+	"errors"
 	"unsafe"
 
 	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
 )
 
+var (
+	// ErrInvalidProgID is returned by CLSIDFromProgID when the ProgID cannot be
+	// found in the registry.
+	ErrInvalidProgID = errors.New("invalid ProgID")
+	// ErrUnsupportedOSVersion is returned when the caller requests a feature
+	// that is not available on the running version of Windows, and has asked
+	// not to fall back to an alternative implementation.
+	ErrUnsupportedOSVersion = errors.New("feature is not supported by this version of Windows")
+)
+
+const hrCO_E_CLASSSTRING = wingoes.HRESULT(-((0x800401F3 ^ 0xFFFFFFFF) + 1))
+
 // MustGetAppID parses s, a string containing an app ID and returns a pointer to the
 // parsed AppID. s must be specified in the format "{XXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX}".
 // If there is an error parsing s, MustGetAppID panics.
@@ -40,6 +54,40 @@ func MustGetServiceID(s string) *ServiceID {
 	return (*ServiceID)(unsafe.Pointer(wingoes.MustGetGUID(s)))
 }
 
+// CLSIDFromProgID looks up the CLSID that is associated with progID, a string
+// such as "Excel.Application". It returns ErrInvalidProgID when progID is not
+// registered.
+func CLSIDFromProgID(progID string) (CLSID, error) {
+	var clsid CLSID
+
+	progID16, err := windows.UTF16PtrFromString(progID)
+	if err != nil {
+		return clsid, err
+	}
+
+	hr := clsidFromProgID(progID16, &clsid)
+	if hr == hrCO_E_CLASSSTRING {
+		return clsid, ErrInvalidProgID
+	}
+	if err := wingoes.ErrorFromHRESULT(hr); err.Failed() {
+		return clsid, err
+	}
+
+	return clsid, nil
+}
+
+// ProgIDFromCLSID looks up the ProgID that is associated with clsid.
+func ProgIDFromCLSID(clsid CLSID) (string, error) {
+	var progID COMAllocatedString
+	hr := progIDFromCLSID(&clsid, &progID)
+	if err := wingoes.ErrorFromHRESULT(hr); err.Failed() {
+		return "", err
+	}
+	defer progID.Close()
+
+	return progID.String(), nil
+}
+
 func getCurrentApartmentInfo() (aptInfo, error) {
 	var info aptInfo
 	hr := coGetApartmentType(&info.apt, &info.qualifier)
@@ -50,6 +98,14 @@ func getCurrentApartmentInfo() (aptInfo, error) {
 	return info, nil
 }
 
+// CurrentApartment returns information about the COM apartment in which the
+// current OS thread resides. Callers should lock their goroutine to the current
+// OS thread for the result to be meaningful.
+func CurrentApartment() (ApartmentType, ApartmentQualifier, error) {
+	info, err := getCurrentApartmentInfo()
+	return info.apt, info.qualifier, err
+}
+
 // aptChecker is a function that applies an arbitrary predicate to an OS thread's
 // apartment information, returning true if the input satisifes that predicate.
 type aptChecker func(*aptInfo) bool
@@ -79,7 +135,7 @@ func AssertCurrentOSThreadSTA() {
 // single-threaded apartment and returns true if so.
 func IsCurrentOSThreadSTA() bool {
 	chk := func(i *aptInfo) bool {
-		return i.apt == coAPTTYPE_STA || i.apt == coAPTTYPE_MAINSTA
+		return i.apt == ApartmentTypeSTA || i.apt == ApartmentTypeMainSTA
 	}
 
 	return checkCurrentApartment(chk)
@@ -98,7 +154,7 @@ func AssertCurrentOSThreadMTA() {
 // multi-threaded apartment and returns true if so.
 func IsCurrentOSThreadMTA() bool {
 	chk := func(i *aptInfo) bool {
-		return i.apt == coAPTTYPE_MTA
+		return i.apt == ApartmentTypeMTA
 	}
 
 	return checkCurrentApartment(chk)
@@ -138,3 +194,37 @@ func CreateInstance[T Object](clsid *CLSID) (T, error) {
 func CreateOutOfProcessInstance[T Object](clsid *CLSID) (T, error) {
 	return createInstanceWithCLSCTX[T](clsid, coCLSCTX_LOCAL_SERVER)
 }
+
+// CreateInstanceRemote instantiates a new COM object of type T using class
+// clsid, hosted on the remote computer named machine. Activation is performed
+// using the caller's credentials and COM's default authentication settings.
+func CreateInstanceRemote[T Object](clsid *CLSID, machine string) (T, error) {
+	var t T
+
+	machine16, err := windows.UTF16PtrFromString(machine)
+	if err != nil {
+		return t, err
+	}
+
+	serverInfo := coServerInfo{name: machine16}
+	qi := multiQI{iid: t.IID()}
+
+	hr := coCreateInstanceEx(
+		clsid,
+		nil,
+		coCLSCTX_REMOTE_SERVER,
+		&serverInfo,
+		1,
+		&qi,
+	)
+	if err := wingoes.ErrorFromHRESULT(hr); err.Failed() {
+		return t, err
+	}
+	if err := wingoes.ErrorFromHRESULT(qi.hr); err.Failed() {
+		return t, err
+	}
+
+	ppunk := NewABIReceiver()
+	*ppunk = qi.itf
+	return t.Make(ppunk).(T), nil
+}
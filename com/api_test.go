@@ -0,0 +1,75 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestProgID(t *testing.T) {
+	// Shell.Application is registered on every version of Windows.
+	const progID = "Shell.Application"
+	wantCLSID := *MustGetCLSID("{13709620-C279-11CE-A49E-444553540000}")
+
+	clsid, err := CLSIDFromProgID(progID)
+	if err != nil {
+		t.Fatalf("CLSIDFromProgID(%q) error: %v", progID, err)
+	}
+	if clsid != wantCLSID {
+		t.Errorf("CLSIDFromProgID(%q) got %v, want %v", progID, clsid, wantCLSID)
+	}
+
+	// The registered ProgID may be versioned (eg, "Shell.Application.1"), so we
+	// check that it round-trips instead of comparing it against progID.
+	gotProgID, err := ProgIDFromCLSID(clsid)
+	if err != nil {
+		t.Fatalf("ProgIDFromCLSID(%v) error: %v", clsid, err)
+	}
+	if !strings.HasPrefix(gotProgID, progID) {
+		t.Errorf("ProgIDFromCLSID(%v) got %q, want prefix %q", clsid, gotProgID, progID)
+	}
+
+	clsid2, err := CLSIDFromProgID(gotProgID)
+	if err != nil {
+		t.Fatalf("CLSIDFromProgID(%q) error: %v", gotProgID, err)
+	}
+	if clsid2 != clsid {
+		t.Errorf("CLSIDFromProgID(%q) got %v, want %v", gotProgID, clsid2, clsid)
+	}
+
+	const bogusProgID = "Wingoes.Bogus.ProgID"
+	if _, err := CLSIDFromProgID(bogusProgID); err != ErrInvalidProgID {
+		t.Errorf("CLSIDFromProgID(%q) got error %v, want %v", bogusProgID, err, ErrInvalidProgID)
+	}
+}
+
+func TestCreateInstanceRemote(t *testing.T) {
+	// WbemLevel1Login is hosted by the WMI service, which is present on every
+	// version of Windows and is designed to be activated remotely.
+	clsidWbemLevel1Login := MustGetCLSID("{8BC3F05E-D86B-11D0-A075-00C04FB68820}")
+
+	machine, err := windows.ComputerName()
+	if err != nil {
+		t.Fatalf("ComputerName error: %v", err)
+	}
+
+	obj, err := CreateInstanceRemote[ObjectBase](clsidWbemLevel1Login, machine)
+	if err != nil {
+		t.Fatalf("CreateInstanceRemote(%q) error: %v", machine, err)
+	}
+	if obj.UnsafeUnwrap() == nil {
+		t.Errorf("CreateInstanceRemote(%q) returned a nil interface", machine)
+	}
+
+	// The .invalid TLD is reserved and guaranteed never to resolve.
+	const bogusMachine = "wingoes.invalid"
+	if _, err := CreateInstanceRemote[ObjectBase](clsidWbemLevel1Login, bogusMachine); err == nil {
+		t.Errorf("CreateInstanceRemote(%q) got nil error, want non-nil", bogusMachine)
+	}
+}
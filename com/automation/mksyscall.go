@@ -8,7 +8,10 @@ package automation
 //go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go mksyscall.go
 //go:generate go run golang.org/x/tools/cmd/goimports -w zsyscall_windows.go
 
+//sys compareStringOrdinal(str1 *uint16, len1 int32, str2 *uint16, len2 int32, ignoreCase bool) (ret int32) = kernel32.CompareStringOrdinal
 //sys sysAllocString(str *uint16) (ret BSTR) = oleaut32.SysAllocString
+//sys sysAllocStringByteLen(str *byte, byteLen uint32) (ret BSTR) = oleaut32.SysAllocStringByteLen
 //sys sysAllocStringLen(str *uint16, strLen uint32) (ret BSTR) = oleaut32.SysAllocStringLen
 //sys sysFreeString(bstr BSTR) = oleaut32.SysFreeString
+//sys sysStringByteLen(bstr BSTR) (ret uint32) = oleaut32.SysStringByteLen
 //sys sysStringLen(bstr BSTR) (ret uint32) = oleaut32.SysStringLen
@@ -6,11 +6,16 @@
 package automation
 
 import (
+	"unicode"
+	"unicode/utf16"
 	"unsafe"
 
+	"golang.org/x/exp/slices"
 	"golang.org/x/sys/windows"
 )
 
+const cstrEqual = 2 // CSTR_EQUAL
+
 // BSTR is the string format used by COM Automation. They are not garbage
 // collected and must be explicitly closed when no longer needed.
 type BSTR uintptr
@@ -40,11 +45,51 @@ func NewBSTRFromUTF16Ptr(up *uint16) BSTR {
 	return sysAllocString(up)
 }
 
-// Len returns the length of bs in code units.
+// NewBSTRFromBytes creates a new BSTR containing a copy of b. The resulting
+// BSTR is an opaque byte container: its contents are not interpreted as UTF-16,
+// and its length in bytes may be odd. Use Bytes to retrieve its contents.
+func NewBSTRFromBytes(b []byte) BSTR {
+	if len(b) == 0 {
+		return 0
+	}
+	return sysAllocStringByteLen(unsafe.SliceData(b), uint32(len(b)))
+}
+
+// Bytes returns a copy of the raw contents of bs. It is intended for use with
+// BSTRs that contain binary data, such as those created by NewBSTRFromBytes.
+func (bs *BSTR) Bytes() []byte {
+	if *bs == 0 {
+		return nil
+	}
+	return append([]byte{}, unsafe.Slice((*byte)(unsafe.Pointer(bs.toUTF16Ptr())), bs.ByteLen())...)
+}
+
+// Len returns the length of bs in UTF-16 code units.
 func (bs *BSTR) Len() uint32 {
 	return sysStringLen(*bs)
 }
 
+// ByteLen returns the length of bs in bytes. For BSTRs containing UTF-16 text,
+// this is always twice the value of Len.
+func (bs *BSTR) ByteLen() uint32 {
+	return sysStringByteLen(*bs)
+}
+
+// RuneCount returns the number of Unicode code points in bs. Surrogate pairs
+// count as a single code point, while unpaired surrogates count as one each.
+func (bs *BSTR) RuneCount() int {
+	us := bs.toUTF16()
+	var n int
+	for i := 0; i < len(us); i++ {
+		if utf16.IsSurrogate(rune(us[i])) && i+1 < len(us) &&
+			utf16.DecodeRune(rune(us[i]), rune(us[i+1])) != unicode.ReplacementChar {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
 // String returns the contents of bs as a Go string.
 func (bs *BSTR) String() string {
 	return windows.UTF16ToString(bs.toUTF16())
@@ -78,6 +123,31 @@ func (bs *BSTR) Clone() BSTR {
 	return sysAllocStringLen(bs.toUTF16Ptr(), bs.Len())
 }
 
+// Equal returns true if bs and other contain identical sequences of UTF-16
+// code units. Since BSTRs may contain embedded NULs, the comparison considers
+// the entire length of both strings. A nil BSTR is equal to an empty BSTR.
+func (bs *BSTR) Equal(other BSTR) bool {
+	if bs.Len() != other.Len() {
+		return false
+	}
+
+	return slices.Equal(bs.toUTF16(), other.toUTF16())
+}
+
+// EqualFold is like Equal, but performs a case-insensitive comparison
+// using the operating system's ordinal casing rules.
+func (bs *BSTR) EqualFold(other BSTR) bool {
+	lenL, lenR := bs.Len(), other.Len()
+	if lenL != lenR {
+		return false
+	}
+	if lenL == 0 {
+		return true
+	}
+
+	return compareStringOrdinal(bs.toUTF16Ptr(), int32(lenL), other.toUTF16Ptr(), int32(lenR), true) == cstrEqual
+}
+
 // IsNil returns true if bs holds a nil value.
 func (bs *BSTR) IsNil() bool {
 	return *bs == 0
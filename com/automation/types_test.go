@@ -0,0 +1,99 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package automation
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestBSTREqual(t *testing.T) {
+	testCases := []struct {
+		l, r      []uint16
+		equal     bool
+		equalFold bool
+	}{
+		{nil, nil, true, true},
+		{nil, []uint16{}, true, true},
+		{[]uint16{'a', 'b', 'c'}, []uint16{'a', 'b', 'c'}, true, true},
+		{[]uint16{'a', 'b', 'c'}, []uint16{'A', 'B', 'C'}, false, true},
+		{[]uint16{'a', 'b', 'c'}, []uint16{'a', 'b'}, false, false},
+		// Embedded NULs must be significant.
+		{[]uint16{'a', 0, 'b'}, []uint16{'a', 0, 'c'}, false, false},
+		{[]uint16{'a', 0, 'b'}, []uint16{'A', 0, 'B'}, false, true},
+		{[]uint16{'a', 0}, []uint16{'a'}, false, false},
+	}
+
+	for i, tc := range testCases {
+		l := NewBSTRFromUTF16(tc.l)
+		r := NewBSTRFromUTF16(tc.r)
+
+		if got := l.Equal(r); got != tc.equal {
+			t.Errorf("%d: Equal got %v, want %v", i, got, tc.equal)
+		}
+		if got := l.EqualFold(r); got != tc.equalFold {
+			t.Errorf("%d: EqualFold got %v, want %v", i, got, tc.equalFold)
+		}
+
+		l.Close()
+		r.Close()
+	}
+}
+
+func TestBSTRLengths(t *testing.T) {
+	testCases := []struct {
+		us        []uint16
+		runeCount int
+	}{
+		{nil, 0},
+		{[]uint16{'a', 'b', 'c'}, 3},
+		{[]uint16{'a', 0, 'b'}, 3},
+		// U+1F600 encoded as a surrogate pair.
+		{[]uint16{'a', 0xD83D, 0xDE00}, 2},
+		// Unpaired surrogates.
+		{[]uint16{0xD83D, 'a', 0xDE00}, 3},
+		{[]uint16{'a', 0xD83D}, 2},
+	}
+
+	for i, tc := range testCases {
+		bs := NewBSTRFromUTF16(tc.us)
+
+		if got, want := bs.Len(), uint32(len(tc.us)); got != want {
+			t.Errorf("%d: Len got %d, want %d", i, got, want)
+		}
+		if got, want := bs.ByteLen(), uint32(len(tc.us)*2); got != want {
+			t.Errorf("%d: ByteLen got %d, want %d", i, got, want)
+		}
+		if got := bs.RuneCount(); got != tc.runeCount {
+			t.Errorf("%d: RuneCount got %d, want %d", i, got, tc.runeCount)
+		}
+
+		bs.Close()
+	}
+}
+
+func TestBSTRBytes(t *testing.T) {
+	testCases := [][]byte{
+		nil,
+		{0x01},
+		{0x00, 0x01, 0x02},
+		{0xDE, 0xAD, 0xBE, 0xEF},
+	}
+
+	for i, tc := range testCases {
+		bs := NewBSTRFromBytes(tc)
+
+		if got, want := bs.ByteLen(), uint32(len(tc)); got != want {
+			t.Errorf("%d: ByteLen got %d, want %d", i, got, want)
+		}
+		if got := bs.Bytes(); !slices.Equal(got, tc) {
+			t.Errorf("%d: Bytes got %v, want %v", i, got, tc)
+		}
+
+		bs.Close()
+	}
+}
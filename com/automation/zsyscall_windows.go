@@ -38,20 +38,40 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
 	modoleaut32 = windows.NewLazySystemDLL("oleaut32.dll")
 
-	procSysAllocString    = modoleaut32.NewProc("SysAllocString")
-	procSysAllocStringLen = modoleaut32.NewProc("SysAllocStringLen")
-	procSysFreeString     = modoleaut32.NewProc("SysFreeString")
-	procSysStringLen      = modoleaut32.NewProc("SysStringLen")
+	procCompareStringOrdinal  = modkernel32.NewProc("CompareStringOrdinal")
+	procSysAllocString        = modoleaut32.NewProc("SysAllocString")
+	procSysAllocStringByteLen = modoleaut32.NewProc("SysAllocStringByteLen")
+	procSysAllocStringLen     = modoleaut32.NewProc("SysAllocStringLen")
+	procSysFreeString         = modoleaut32.NewProc("SysFreeString")
+	procSysStringByteLen      = modoleaut32.NewProc("SysStringByteLen")
+	procSysStringLen          = modoleaut32.NewProc("SysStringLen")
 )
 
+func compareStringOrdinal(str1 *uint16, len1 int32, str2 *uint16, len2 int32, ignoreCase bool) (ret int32) {
+	var _p0 uint32
+	if ignoreCase {
+		_p0 = 1
+	}
+	r0, _, _ := syscall.Syscall6(procCompareStringOrdinal.Addr(), 5, uintptr(unsafe.Pointer(str1)), uintptr(len1), uintptr(unsafe.Pointer(str2)), uintptr(len2), uintptr(_p0), 0)
+	ret = int32(r0)
+	return
+}
+
 func sysAllocString(str *uint16) (ret BSTR) {
 	r0, _, _ := syscall.Syscall(procSysAllocString.Addr(), 1, uintptr(unsafe.Pointer(str)), 0, 0)
 	ret = BSTR(r0)
 	return
 }
 
+func sysAllocStringByteLen(str *byte, byteLen uint32) (ret BSTR) {
+	r0, _, _ := syscall.Syscall(procSysAllocStringByteLen.Addr(), 2, uintptr(unsafe.Pointer(str)), uintptr(byteLen), 0)
+	ret = BSTR(r0)
+	return
+}
+
 func sysAllocStringLen(str *uint16, strLen uint32) (ret BSTR) {
 	r0, _, _ := syscall.Syscall(procSysAllocStringLen.Addr(), 2, uintptr(unsafe.Pointer(str)), uintptr(strLen), 0)
 	ret = BSTR(r0)
@@ -63,6 +83,12 @@ func sysFreeString(bstr BSTR) {
 	return
 }
 
+func sysStringByteLen(bstr BSTR) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procSysStringByteLen.Addr(), 1, uintptr(bstr), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
 func sysStringLen(bstr BSTR) (ret uint32) {
 	r0, _, _ := syscall.Syscall(procSysStringLen.Addr(), 1, uintptr(bstr), 0, 0)
 	ret = uint32(r0)
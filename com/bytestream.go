@@ -0,0 +1,327 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	hrS_OK               = wingoes.HRESULT(0)
+	hrE_FAIL             = wingoes.HRESULT(-((0x80004005 ^ 0xFFFFFFFF) + 1))
+	hrE_NOINTERFACE      = wingoes.HRESULT(-((0x80004002 ^ 0xFFFFFFFF) + 1))
+	hrE_POINTER          = wingoes.HRESULT(-((0x80004003 ^ 0xFFFFFFFF) + 1))
+	hrE_UNEXPECTED       = wingoes.HRESULT(-((0x8000FFFF ^ 0xFFFFFFFF) + 1))
+	hrSTG_E_ACCESSDENIED = wingoes.HRESULT(-((0x80030005 ^ 0xFFFFFFFF) + 1))
+)
+
+// byteStreamState is the Go-side state of a single COM object created by
+// NewReadOnlyStreamFromBytes.
+type byteStreamState struct {
+	refs atomic.Int32
+	mu   sync.Mutex
+	data []byte
+	pos  int64
+}
+
+var (
+	byteStreamVtblOnce sync.Once
+	// byteStreamVtbl is IStream's vtable, whose entries are callbacks into Go.
+	byteStreamVtbl [14]uintptr
+	// byteStreams maps each live COM object to its Go-side state. The COM
+	// objects themselves reside in COM task memory, since COM must be able to
+	// reference them while they are not otherwise reachable from Go. This map
+	// also keeps each object's data alive for as long as COM holds a reference.
+	byteStreams   = map[*IUnknownABI]*byteStreamState{}
+	byteStreamsMu sync.Mutex
+)
+
+func hrToCallbackResult(hr wingoes.HRESULT) uintptr {
+	return uintptr(uint32(hr))
+}
+
+func initByteStreamVtbl() {
+	byteStreamVtbl = [14]uintptr{
+		syscall.NewCallback(byteStreamQueryInterface),
+		syscall.NewCallback(byteStreamAddRef),
+		syscall.NewCallback(byteStreamRelease),
+		syscall.NewCallback(byteStreamRead),
+		syscall.NewCallback(byteStreamWrite),
+		syscall.NewCallback(byteStreamSeek),
+		syscall.NewCallback(byteStreamSetSize),
+		syscall.NewCallback(byteStreamCopyTo),
+		syscall.NewCallback(byteStreamCommit),
+		syscall.NewCallback(byteStreamRevert),
+		syscall.NewCallback(byteStreamLockRegion),
+		syscall.NewCallback(byteStreamUnlockRegion),
+		syscall.NewCallback(byteStreamStat),
+		syscall.NewCallback(byteStreamClone),
+	}
+}
+
+// NewReadOnlyStreamFromBytes creates a new read-only Stream whose contents are
+// read directly from b, without copying. Writes to the stream fail with
+// STG_E_ACCESSDENIED. The Stream retains b for as long as any references to it
+// are held, however the caller must not modify b's contents during that time.
+func NewReadOnlyStreamFromBytes(b []byte) (result Stream, _ error) {
+	punk, err := newByteStream(b, 0)
+	if err != nil {
+		return result, err
+	}
+
+	ppstream := NewABIReceiver()
+	*ppstream = punk
+	return result.Make(ppstream).(Stream), nil
+}
+
+func newByteStream(data []byte, pos int64) (*IUnknownABI, error) {
+	byteStreamVtblOnce.Do(initByteStreamVtbl)
+
+	obj := (*IUnknownABI)(coTaskMemAlloc(unsafe.Sizeof(IUnknownABI{})))
+	if obj == nil {
+		return nil, wingoes.ErrorFromHRESULT(hrE_OUTOFMEMORY)
+	}
+	obj.Vtbl = &byteStreamVtbl[0]
+
+	st := &byteStreamState{data: data, pos: pos}
+	st.refs.Store(1)
+
+	byteStreamsMu.Lock()
+	defer byteStreamsMu.Unlock()
+	byteStreams[obj] = st
+
+	return obj, nil
+}
+
+// lookupByteStream returns the Go-side state of this, or nil if this is not a
+// live byte stream (eg, because a foreign caller used it after its final
+// Release). Callbacks must fail with E_UNEXPECTED in the latter case.
+func lookupByteStream(this *IUnknownABI) *byteStreamState {
+	byteStreamsMu.Lock()
+	defer byteStreamsMu.Unlock()
+	return byteStreams[this]
+}
+
+func byteStreamQueryInterface(this *IUnknownABI, iid *IID, ppv **IUnknownABI) uintptr {
+	if ppv == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+	*ppv = nil
+	if iid == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	switch *iid {
+	case *IID_IUnknown, *IID_ISequentialStream, *IID_IStream:
+		st.refs.Add(1)
+		*ppv = this
+		return hrToCallbackResult(hrS_OK)
+	default:
+		return hrToCallbackResult(hrE_NOINTERFACE)
+	}
+}
+
+// AddRef and Release return reference counts rather than HRESULTs, so they
+// report a count of zero when this is not a live byte stream.
+
+func byteStreamAddRef(this *IUnknownABI) uintptr {
+	st := lookupByteStream(this)
+	if st == nil {
+		return 0
+	}
+
+	return uintptr(st.refs.Add(1))
+}
+
+func byteStreamRelease(this *IUnknownABI) uintptr {
+	st := lookupByteStream(this)
+	if st == nil {
+		return 0
+	}
+
+	refs := st.refs.Add(-1)
+	if refs == 0 {
+		byteStreamsMu.Lock()
+		delete(byteStreams, this)
+		byteStreamsMu.Unlock()
+		windows.CoTaskMemFree(unsafe.Pointer(this))
+	}
+
+	return uintptr(refs)
+}
+
+func byteStreamRead(this *IUnknownABI, pv *byte, cb uint32, pcbRead *uint32) uintptr {
+	// As per IStream's contract, pcbRead is optional.
+	if pcbRead != nil {
+		*pcbRead = 0
+	}
+	if pv == nil && cb > 0 {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var n int
+	if st.pos < int64(len(st.data)) {
+		n = copy(unsafe.Slice(pv, cb), st.data[st.pos:])
+	}
+	st.pos += int64(n)
+
+	if pcbRead != nil {
+		*pcbRead = uint32(n)
+	}
+	if n < int(cb) {
+		return hrToCallbackResult(wingoes.S_FALSE)
+	}
+
+	return hrToCallbackResult(hrS_OK)
+}
+
+func byteStreamWrite(this *IUnknownABI, pv *byte, cb uint32, pcbWritten *uint32) uintptr {
+	if pcbWritten != nil {
+		*pcbWritten = 0
+	}
+
+	return hrToCallbackResult(hrSTG_E_ACCESSDENIED)
+}
+
+func (st *byteStreamState) seek(offset int64, whence uint32, newPos *uint64) uintptr {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = st.pos
+	case io.SeekEnd:
+		base = int64(len(st.data))
+	default:
+		return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+	}
+
+	pos := base + offset
+	if pos < 0 {
+		return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+	}
+	st.pos = pos
+
+	if newPos != nil {
+		*newPos = uint64(pos)
+	}
+
+	return hrToCallbackResult(hrS_OK)
+}
+
+func (st *byteStreamState) copyTo(dest *IStreamABI, numBytesToCopy uint64, bytesRead, bytesWritten *uint64) uintptr {
+	if dest == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var src []byte
+	if st.pos < int64(len(st.data)) {
+		src = st.data[st.pos:]
+	}
+	if uint64(len(src)) > numBytesToCopy {
+		src = src[:numBytesToCopy]
+	}
+	st.pos += int64(len(src))
+
+	var written int
+	var err error
+	for written < len(src) && err == nil {
+		var n int
+		n, err = dest.Write(src[written:])
+		written += n
+	}
+
+	if bytesRead != nil {
+		*bytesRead = uint64(len(src))
+	}
+	if bytesWritten != nil {
+		*bytesWritten = uint64(written)
+	}
+
+	if err != nil {
+		return errToCallbackResult(err)
+	}
+
+	return hrToCallbackResult(hrS_OK)
+}
+
+func byteStreamCommit(this *IUnknownABI, flags uint32) uintptr {
+	// Nothing to commit, since writing is not permitted.
+	return hrToCallbackResult(hrS_OK)
+}
+
+func byteStreamRevert(this *IUnknownABI) uintptr {
+	return hrToCallbackResult(hrS_OK)
+}
+
+func byteStreamStat(this *IUnknownABI, statstg *STATSTG, flags uint32) uintptr {
+	if statstg == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	*statstg = STATSTG{
+		Type: STGTY_STREAM,
+		Size: uint64(len(st.data)),
+		Mode: uint32(STGM_READ),
+	}
+
+	return hrToCallbackResult(hrS_OK)
+}
+
+func byteStreamClone(this *IUnknownABI, ppstm **IUnknownABI) uintptr {
+	if ppstm == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	*ppstm = nil
+
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	st.mu.Lock()
+	pos := st.pos
+	st.mu.Unlock()
+
+	punk, err := newByteStream(st.data, pos)
+	if err != nil {
+		return hrToCallbackResult(hrE_OUTOFMEMORY)
+	}
+
+	*ppstm = punk
+	return hrToCallbackResult(hrS_OK)
+}
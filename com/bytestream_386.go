@@ -0,0 +1,41 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows && 386
+
+package com
+
+// On 386, each 64-bit argument is passed to the callbacks as two 32-bit halves,
+// low-order half first.
+
+func byteStreamSeek(this *IUnknownABI, offsetLo, offsetHi, whence uint32, newPos *uint64) uintptr {
+	offset := int64(uint64(offsetHi)<<32 | uint64(offsetLo))
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	return st.seek(offset, whence, newPos)
+}
+
+func byteStreamSetSize(this *IUnknownABI, newSizeLo, newSizeHi uint32) uintptr {
+	return hrToCallbackResult(hrSTG_E_ACCESSDENIED)
+}
+
+func byteStreamCopyTo(this *IUnknownABI, dest *IStreamABI, numBytesToCopyLo, numBytesToCopyHi uint32, bytesRead, bytesWritten *uint64) uintptr {
+	numBytesToCopy := uint64(numBytesToCopyHi)<<32 | uint64(numBytesToCopyLo)
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	return st.copyTo(dest, numBytesToCopy, bytesRead, bytesWritten)
+}
+
+func byteStreamLockRegion(this *IUnknownABI, offsetLo, offsetHi, numBytesLo, numBytesHi, lockType uint32) uintptr {
+	return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+}
+
+func byteStreamUnlockRegion(this *IUnknownABI, offsetLo, offsetHi, numBytesLo, numBytesHi, lockType uint32) uintptr {
+	return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows && !386
+
+package com
+
+func byteStreamSeek(this *IUnknownABI, offset int64, whence uint32, newPos *uint64) uintptr {
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	return st.seek(offset, whence, newPos)
+}
+
+func byteStreamSetSize(this *IUnknownABI, newSize uint64) uintptr {
+	return hrToCallbackResult(hrSTG_E_ACCESSDENIED)
+}
+
+func byteStreamCopyTo(this *IUnknownABI, dest *IStreamABI, numBytesToCopy uint64, bytesRead, bytesWritten *uint64) uintptr {
+	st := lookupByteStream(this)
+	if st == nil {
+		return hrToCallbackResult(hrE_UNEXPECTED)
+	}
+
+	return st.copyTo(dest, numBytesToCopy, bytesRead, bytesWritten)
+}
+
+func byteStreamLockRegion(this *IUnknownABI, offset, numBytes uint64, lockType uint32) uintptr {
+	return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+}
+
+func byteStreamUnlockRegion(this *IUnknownABI, offset, numBytes uint64, lockType uint32) uintptr {
+	return hrToCallbackResult(hrSTG_E_INVALIDFUNCTION)
+}
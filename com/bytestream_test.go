@@ -0,0 +1,153 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/exp/slices"
+)
+
+func TestReadOnlyStreamFromBytes(t *testing.T) {
+	data := []byte("The quick brown fox jumps over the lazy dog")
+
+	stream, err := NewReadOnlyStreamFromBytes(data)
+	if err != nil {
+		t.Fatalf("NewReadOnlyStreamFromBytes error: %v", err)
+	}
+
+	size, err := stream.Size()
+	if err != nil {
+		t.Fatalf("Size error: %v", err)
+	}
+	if size != uint64(len(data)) {
+		t.Errorf("Size got %d, want %d", size, len(data))
+	}
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll error: %v", err)
+	}
+	if !slices.Equal(got, data) {
+		t.Errorf("ReadAll got %q, want %q", got, data)
+	}
+
+	pos, err := stream.Seek(-8, io.SeekEnd)
+	if err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+	if want := int64(len(data) - 8); pos != want {
+		t.Errorf("Seek got %d, want %d", pos, want)
+	}
+
+	if _, err := stream.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Seek to negative offset unexpectedly succeeded")
+	}
+
+	if _, err := stream.Write([]byte("x")); !errors.Is(err, wingoes.ErrorFromHRESULT(hrSTG_E_ACCESSDENIED)) {
+		t.Errorf("Write got error %v, want STG_E_ACCESSDENIED", err)
+	}
+
+	clone, err := stream.Clone()
+	if err != nil {
+		t.Fatalf("Clone error: %v", err)
+	}
+	got, err = io.ReadAll(clone)
+	if err != nil {
+		t.Fatalf("ReadAll(clone) error: %v", err)
+	}
+	if want := data[len(data)-8:]; !slices.Equal(got, want) {
+		t.Errorf("ReadAll(clone) got %q, want %q", got, want)
+	}
+
+	if _, err := stream.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+	dest, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+	nRead, nWritten, err := stream.CopyTo(dest, 5)
+	if err != nil {
+		t.Fatalf("CopyTo error: %v", err)
+	}
+	if nRead != 5 || nWritten != 5 {
+		t.Errorf("CopyTo got (%d, %d), want (5, 5)", nRead, nWritten)
+	}
+	if _, err := dest.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+	got, err = io.ReadAll(dest)
+	if err != nil {
+		t.Fatalf("ReadAll(dest) error: %v", err)
+	}
+	if want := data[4:9]; !slices.Equal(got, want) {
+		t.Errorf("CopyTo wrote %q, want %q", got, want)
+	}
+
+	if _, err := stream.Lock(0, 1, LOCK_WRITE); !errors.Is(err, ErrLockingNotSupported) {
+		t.Errorf("Lock got error %v, want %v", err, ErrLockingNotSupported)
+	}
+
+	if got := DebugRefCount(stream); got != 1 {
+		t.Errorf("DebugRefCount got %d, want 1", got)
+	}
+}
+
+func TestByteStreamCallbacksInvalidArgs(t *testing.T) {
+	stream, err := NewReadOnlyStreamFromBytes([]byte("data"))
+	if err != nil {
+		t.Fatalf("NewReadOnlyStreamFromBytes error: %v", err)
+	}
+	live := (*IUnknownABI)(unsafe.Pointer(stream.UnsafeUnwrap()))
+
+	wantPointer := hrToCallbackResult(hrE_POINTER)
+	var cbRead uint32
+	if got := byteStreamRead(live, nil, 4, &cbRead); got != wantPointer {
+		t.Errorf("Read with NULL buffer got 0x%08X, want 0x%08X", got, wantPointer)
+	}
+	if got := byteStreamQueryInterface(live, IID_IStream, nil); got != wantPointer {
+		t.Errorf("QueryInterface with NULL ppv got 0x%08X, want 0x%08X", got, wantPointer)
+	}
+	if got := byteStreamStat(live, nil, 0); got != wantPointer {
+		t.Errorf("Stat with NULL STATSTG got 0x%08X, want 0x%08X", got, wantPointer)
+	}
+	if got := byteStreamClone(live, nil); got != wantPointer {
+		t.Errorf("Clone with NULL ppstm got 0x%08X, want 0x%08X", got, wantPointer)
+	}
+	runtime.KeepAlive(stream)
+
+	// unknown is not a byte stream, as if a foreign caller had retained a
+	// pointer to one after its final Release.
+	unknown := &IUnknownABI{}
+	wantUnexpected := hrToCallbackResult(hrE_UNEXPECTED)
+	buf := make([]byte, 4)
+	if got := byteStreamRead(unknown, &buf[0], uint32(len(buf)), &cbRead); got != wantUnexpected {
+		t.Errorf("Read on unknown stream got 0x%08X, want 0x%08X", got, wantUnexpected)
+	}
+	var punk *IUnknownABI
+	if got := byteStreamQueryInterface(unknown, IID_IStream, &punk); got != wantUnexpected || punk != nil {
+		t.Errorf("QueryInterface on unknown stream got (0x%08X, %p), want (0x%08X, nil)", got, punk, wantUnexpected)
+	}
+	var statstg STATSTG
+	if got := byteStreamStat(unknown, &statstg, 0); got != wantUnexpected {
+		t.Errorf("Stat on unknown stream got 0x%08X, want 0x%08X", got, wantUnexpected)
+	}
+	if got := byteStreamClone(unknown, &punk); got != wantUnexpected || punk != nil {
+		t.Errorf("Clone on unknown stream got (0x%08X, %p), want (0x%08X, nil)", got, punk, wantUnexpected)
+	}
+	if got := byteStreamAddRef(unknown); got != 0 {
+		t.Errorf("AddRef on unknown stream got %d, want 0", got)
+	}
+	if got := byteStreamRelease(unknown); got != 0 {
+		t.Errorf("Release on unknown stream got %d, want 0", got)
+	}
+}
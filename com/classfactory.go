@@ -0,0 +1,145 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+)
+
+var (
+	IID_IClassFactory = &IID{0x00000001, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+)
+
+// ClassContext specifies the acceptable location for hosting instances of a
+// COM class.
+type ClassContext uint32
+
+const (
+	// ClassContextInProcess hosts instances within the current process.
+	ClassContextInProcess = ClassContext(coCLSCTX_INPROC_SERVER)
+	// ClassContextLocalServer hosts instances within a separate process on the
+	// local computer.
+	ClassContextLocalServer = ClassContext(coCLSCTX_LOCAL_SERVER)
+)
+
+// IClassFactoryABI represents the COM ABI for the IClassFactory interface.
+type IClassFactoryABI struct {
+	IUnknownABI
+}
+
+// ClassFactory wraps an IClassFactory, which creates instances of a specific
+// COM class. Holding a ClassFactory permits the creation of many instances
+// without repeating the class lookup (and, for out-of-process classes, the
+// server activation) that each call to CreateInstance would otherwise perform.
+type ClassFactory struct {
+	GenericObject[IClassFactoryABI]
+}
+
+func (abi *IClassFactoryABI) CreateInstance(outer *IUnknownABI, iid *IID) (*IUnknownABI, error) {
+	var result *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 5)[3]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(outer)),
+		uintptr(unsafe.Pointer(iid)),
+		uintptr(unsafe.Pointer(&result)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return result, nil
+}
+
+func (abi *IClassFactoryABI) LockServer(lock bool) error {
+	var arg uintptr
+	if lock {
+		arg = 1
+	}
+
+	method := unsafe.Slice(abi.Vtbl, 5)[4]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		arg,
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (o ClassFactory) IID() *IID {
+	return IID_IClassFactory
+}
+
+func (o ClassFactory) Make(r ABIReceiver) any {
+	if r == nil {
+		return ClassFactory{}
+	}
+
+	runtime.SetFinalizer(r, ReleaseABI)
+
+	pp := (**IClassFactoryABI)(unsafe.Pointer(r))
+	return ClassFactory{GenericObject[IClassFactoryABI]{Pp: pp}}
+}
+
+// UnsafeUnwrap returns the underlying IClassFactoryABI of the object. As the
+// name implies, this is unsafe -- you had better know what you are doing!
+func (o ClassFactory) UnsafeUnwrap() *IClassFactoryABI {
+	return *(o.Pp)
+}
+
+// LockServer keeps the server hosting o's class running even when it has no
+// instances outstanding. Each call with lock set to true must be balanced by a
+// call with lock set to false.
+func (o ClassFactory) LockServer(lock bool) error {
+	p := *(o.Pp)
+	return p.LockServer(lock)
+}
+
+// GetClassFactory obtains the class factory for class clsid, hosted in the
+// location specified by ctx.
+func GetClassFactory(clsid *CLSID, ctx ClassContext) (result ClassFactory, _ error) {
+	ppunk := NewABIReceiver()
+
+	hr := coGetClassObject(
+		clsid,
+		coCLSCTX(ctx),
+		nil,
+		IID_IClassFactory,
+		ppunk,
+	)
+	if err := wingoes.ErrorFromHRESULT(hr); err.Failed() {
+		return result, err
+	}
+
+	return result.Make(ppunk).(ClassFactory), nil
+}
+
+// CreateInstanceFromClassFactory instantiates a new COM object of type T using
+// cf. (Go does not permit generic methods, so this cannot be a method of
+// ClassFactory.) Aggregation is not supported.
+func CreateInstanceFromClassFactory[T Object](cf ClassFactory) (T, error) {
+	var t T
+
+	punk, err := cf.UnsafeUnwrap().CreateInstance(nil, t.IID())
+	if err != nil {
+		return t, err
+	}
+
+	ppunk := NewABIReceiver()
+	*ppunk = punk
+	return t.Make(ppunk).(T), nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"testing"
+)
+
+func TestClassFactory(t *testing.T) {
+	cf, err := GetClassFactory(CLSID_GlobalOptions, ClassContextInProcess)
+	if err != nil {
+		t.Fatalf("GetClassFactory error: %v", err)
+	}
+
+	if err := cf.LockServer(true); err != nil {
+		t.Fatalf("LockServer(true) error: %v", err)
+	}
+	defer func() {
+		if err := cf.LockServer(false); err != nil {
+			t.Errorf("LockServer(false) error: %v", err)
+		}
+	}()
+
+	// Create a couple of instances to ensure that the factory is reusable.
+	for i := 0; i < 2; i++ {
+		globalOpts, err := CreateInstanceFromClassFactory[GlobalOptions](cf)
+		if err != nil {
+			t.Fatalf("CreateInstanceFromClassFactory error: %v", err)
+		}
+
+		if _, err := globalOpts.Query(COMGLB_EXCEPTION_HANDLING); err != nil {
+			t.Errorf("Query(COMGLB_EXCEPTION_HANDLING) error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	hrCLASS_E_NOAGGREGATION = wingoes.HRESULT(-((0x80040110 ^ 0xFFFFFFFF) + 1))
+)
+
+// regclsMultipleUse is REGCLS_MULTIPLEUSE, which permits a registered class
+// object to service any number of activation requests.
+const regclsMultipleUse = 1
+
+// ClassFactoryImpl is implemented by Go code that creates instances of a COM
+// class. Use RegisterClassObject to make a ClassFactoryImpl available to COM.
+//
+// COM may invoke its methods from any OS thread that belongs to the apartment
+// in which it was registered, so implementations must be safe for concurrent
+// use when registered from the MTA.
+type ClassFactoryImpl interface {
+	// CreateInstance creates a new instance of the class and returns its
+	// interface pointer for iid. The returned pointer must hold a reference
+	// that is transferred to the caller. If the instance does not implement
+	// iid, CreateInstance should return an error wrapping E_NOINTERFACE.
+	CreateInstance(iid *IID) (*IUnknownABI, error)
+	// LockServer is invoked when a client increments (lock is true) or
+	// decrements (lock is false) the lock count of the server.
+	LockServer(lock bool) error
+}
+
+// RegistrationCookie identifies a class object registered by
+// RegisterClassObject.
+type RegistrationCookie uint32
+
+// goClassFactoryState is the Go-side state of a single COM object created by
+// newGoClassFactory.
+type goClassFactoryState struct {
+	refs atomic.Int32
+	impl ClassFactoryImpl
+}
+
+var (
+	goClassFactoryVtblOnce sync.Once
+	// goClassFactoryVtbl is IClassFactory's vtable, whose entries are callbacks
+	// into Go.
+	goClassFactoryVtbl [5]uintptr
+	// goClassFactories maps each live COM object to its Go-side state. As with
+	// byteStreams, the COM objects themselves reside in COM task memory.
+	goClassFactories   = map[*IUnknownABI]*goClassFactoryState{}
+	goClassFactoriesMu sync.Mutex
+)
+
+func initGoClassFactoryVtbl() {
+	goClassFactoryVtbl = [5]uintptr{
+		syscall.NewCallback(goClassFactoryQueryInterface),
+		syscall.NewCallback(goClassFactoryAddRef),
+		syscall.NewCallback(goClassFactoryRelease),
+		syscall.NewCallback(goClassFactoryCreateInstance),
+		syscall.NewCallback(goClassFactoryLockServer),
+	}
+}
+
+// RegisterClassObject registers factory with COM as the class object for class
+// clsid, hosted in the location specified by ctx. Once registered, COM
+// activation requests for clsid are serviced by factory until the returned
+// RegistrationCookie is passed to RevokeClassObject.
+//
+// Aggregation is not supported; requests to create an aggregated instance fail
+// with CLASS_E_NOAGGREGATION without invoking factory.
+func RegisterClassObject(clsid *CLSID, ctx ClassContext, factory ClassFactoryImpl) (RegistrationCookie, error) {
+	punk, err := newGoClassFactory(factory)
+	if err != nil {
+		return 0, err
+	}
+	// COM adds its own reference upon successful registration, so we always
+	// release ours.
+	defer punk.Release()
+
+	var cookie RegistrationCookie
+	hr := coRegisterClassObject(clsid, punk, coCLSCTX(ctx), regclsMultipleUse, &cookie)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return 0, e
+	}
+
+	return cookie, nil
+}
+
+// RevokeClassObject revokes the registration of the class object identified
+// by cookie, which must have been obtained from RegisterClassObject.
+func RevokeClassObject(cookie RegistrationCookie) error {
+	if e := wingoes.ErrorFromHRESULT(coRevokeClassObject(cookie)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func newGoClassFactory(impl ClassFactoryImpl) (*IUnknownABI, error) {
+	goClassFactoryVtblOnce.Do(initGoClassFactoryVtbl)
+
+	obj := (*IUnknownABI)(coTaskMemAlloc(unsafe.Sizeof(IUnknownABI{})))
+	if obj == nil {
+		return nil, wingoes.ErrorFromHRESULT(hrE_OUTOFMEMORY)
+	}
+	obj.Vtbl = &goClassFactoryVtbl[0]
+
+	st := &goClassFactoryState{impl: impl}
+	st.refs.Store(1)
+
+	goClassFactoriesMu.Lock()
+	defer goClassFactoriesMu.Unlock()
+	goClassFactories[obj] = st
+
+	return obj, nil
+}
+
+func lookupGoClassFactory(this *IUnknownABI) *goClassFactoryState {
+	goClassFactoriesMu.Lock()
+	defer goClassFactoriesMu.Unlock()
+	return goClassFactories[this]
+}
+
+func errToCallbackResult(err error) uintptr {
+	if e, ok := wingoes.ErrorFromError(err); ok && e.Failed() {
+		return hrToCallbackResult(e.AsHRESULT())
+	}
+	return hrToCallbackResult(hrE_FAIL)
+}
+
+func goClassFactoryQueryInterface(this *IUnknownABI, iid *IID, ppv **IUnknownABI) uintptr {
+	if ppv == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	switch *iid {
+	case *IID_IUnknown, *IID_IClassFactory:
+		goClassFactoryAddRef(this)
+		*ppv = this
+		return hrToCallbackResult(hrS_OK)
+	default:
+		*ppv = nil
+		return hrToCallbackResult(hrE_NOINTERFACE)
+	}
+}
+
+func goClassFactoryAddRef(this *IUnknownABI) uintptr {
+	return uintptr(lookupGoClassFactory(this).refs.Add(1))
+}
+
+func goClassFactoryRelease(this *IUnknownABI) uintptr {
+	refs := lookupGoClassFactory(this).refs.Add(-1)
+	if refs == 0 {
+		goClassFactoriesMu.Lock()
+		delete(goClassFactories, this)
+		goClassFactoriesMu.Unlock()
+		windows.CoTaskMemFree(unsafe.Pointer(this))
+	}
+
+	return uintptr(refs)
+}
+
+func goClassFactoryCreateInstance(this *IUnknownABI, outer *IUnknownABI, iid *IID, ppv **IUnknownABI) uintptr {
+	if ppv == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+	*ppv = nil
+
+	if outer != nil {
+		return hrToCallbackResult(hrCLASS_E_NOAGGREGATION)
+	}
+
+	punk, err := lookupGoClassFactory(this).impl.CreateInstance(iid)
+	if err != nil {
+		return errToCallbackResult(err)
+	}
+	if punk == nil {
+		return hrToCallbackResult(hrE_POINTER)
+	}
+
+	*ppv = punk
+	return hrToCallbackResult(hrS_OK)
+}
+
+func goClassFactoryLockServer(this *IUnknownABI, lock uintptr) uintptr {
+	// lock is a BOOL, so we ignore any garbage in its upper bits.
+	if err := lookupGoClassFactory(this).impl.LockServer(uint32(lock) != 0); err != nil {
+		return errToCallbackResult(err)
+	}
+
+	return hrToCallbackResult(hrS_OK)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dblohm7/wingoes"
+)
+
+type testStreamFactory struct {
+	data  []byte
+	locks atomic.Int32
+}
+
+func (f *testStreamFactory) CreateInstance(iid *IID) (*IUnknownABI, error) {
+	stream, err := NewReadOnlyStreamFromBytes(f.data)
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.QueryInterfaceRaw(iid)
+}
+
+func (f *testStreamFactory) LockServer(lock bool) error {
+	if lock {
+		f.locks.Add(1)
+	} else {
+		f.locks.Add(-1)
+	}
+	return nil
+}
+
+func TestRegisterClassObject(t *testing.T) {
+	g, err := wingoes.NewGUIDv4()
+	if err != nil {
+		t.Fatalf("NewGUIDv4 error: %v", err)
+	}
+	clsid := CLSID(g)
+
+	factory := &testStreamFactory{data: []byte("Hello, COM server!")}
+	cookie, err := RegisterClassObject(&clsid, ClassContextInProcess, factory)
+	if err != nil {
+		t.Fatalf("RegisterClassObject error: %v", err)
+	}
+
+	func() {
+		stream, err := CreateInstance[Stream](&clsid)
+		if err != nil {
+			t.Fatalf("CreateInstance error: %v", err)
+		}
+
+		got, err := io.ReadAll(stream)
+		if err != nil {
+			t.Fatalf("ReadAll error: %v", err)
+		}
+		if !bytes.Equal(got, factory.data) {
+			t.Errorf("stream contents got %q, want %q", got, factory.data)
+		}
+
+		cf, err := GetClassFactory(&clsid, ClassContextInProcess)
+		if err != nil {
+			t.Fatalf("GetClassFactory error: %v", err)
+		}
+
+		// Each call must reach factory, not merely succeed.
+		if err := cf.LockServer(true); err != nil {
+			t.Errorf("LockServer(true) error: %v", err)
+		}
+		if locks := factory.locks.Load(); locks != 1 {
+			t.Errorf("lock count after LockServer(true) got %d, want 1", locks)
+		}
+		if err := cf.LockServer(false); err != nil {
+			t.Errorf("LockServer(false) error: %v", err)
+		}
+		if locks := factory.locks.Load(); locks != 0 {
+			t.Errorf("lock count after LockServer(false) got %d, want 0", locks)
+		}
+
+		// IStorage is not implemented by the streams that factory creates.
+		if _, err := CreateInstanceFromClassFactory[Storage](cf); err == nil {
+			t.Errorf("CreateInstanceFromClassFactory[Storage] unexpectedly succeeded")
+		}
+	}()
+
+	if err := RevokeClassObject(cookie); err != nil {
+		t.Fatalf("RevokeClassObject error: %v", err)
+	}
+
+	if _, err := CreateInstance[Stream](&clsid); err == nil {
+		t.Errorf("CreateInstance unexpectedly succeeded after RevokeClassObject")
+	}
+}
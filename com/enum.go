@@ -0,0 +1,197 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+)
+
+var (
+	IID_IEnumString = &IID{0x00000101, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+)
+
+// enumBatchSize is the number of elements that EnumToSlice requests from each
+// call to next.
+const enumBatchSize = 16
+
+// EnumToSlice drives a COM enumerator (ie, any IEnum* interface) to completion,
+// returning every remaining element. next must fill batch with up to len(batch)
+// elements and return the number of elements that it fetched. When the
+// enumerator has been exhausted (ie, its Next method returned S_FALSE), next
+// must return io.EOF, possibly alongside a non-zero count.
+func EnumToSlice[T any](next func(batch []T) (int, error)) ([]T, error) {
+	var result []T
+	batch := make([]T, enumBatchSize)
+	for {
+		n, err := next(batch)
+		result = append(result, batch[:n]...)
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+type IEnumStringABI struct {
+	IUnknownABI
+}
+
+// EnumString wraps an IEnumString, which enumerates a sequence of strings.
+type EnumString struct {
+	GenericObject[IEnumStringABI]
+}
+
+// Next fetches up to len(batch) strings into batch, returning the number of
+// strings that were fetched. The caller must Close each of those strings. It
+// returns io.EOF when the enumeration has been exhausted.
+func (abi *IEnumStringABI) Next(batch []COMAllocatedString) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var fetched uint32
+	method := unsafe.Slice(abi.Vtbl, 7)[3]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(uint32(len(batch))),
+		uintptr(unsafe.Pointer(unsafe.SliceData(batch))),
+		uintptr(unsafe.Pointer(&fetched)),
+	)
+	n := int(fetched)
+	e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc))
+	if e.Failed() {
+		return n, e
+	}
+	if e.AsHRESULT() == wingoes.S_FALSE {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (abi *IEnumStringABI) Skip(count uint32) error {
+	method := unsafe.Slice(abi.Vtbl, 7)[4]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(count),
+	)
+	e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc))
+	if e.Failed() {
+		return e
+	}
+	if e.AsHRESULT() == wingoes.S_FALSE {
+		return io.EOF
+	}
+
+	return nil
+}
+
+func (abi *IEnumStringABI) Reset() error {
+	method := unsafe.Slice(abi.Vtbl, 7)[5]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IEnumStringABI) Clone() (*IUnknownABI, error) {
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 7)[6]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (o EnumString) IID() *IID {
+	return IID_IEnumString
+}
+
+func (o EnumString) Make(r ABIReceiver) any {
+	if r == nil {
+		return EnumString{}
+	}
+
+	runtime.SetFinalizer(r, ReleaseABI)
+
+	pp := (**IEnumStringABI)(unsafe.Pointer(r))
+	return EnumString{GenericObject[IEnumStringABI]{Pp: pp}}
+}
+
+// UnsafeUnwrap returns the underlying IEnumStringABI of the object. As the
+// name implies, this is unsafe -- you had better know what you are doing!
+func (o EnumString) UnsafeUnwrap() *IEnumStringABI {
+	return *(o.Pp)
+}
+
+// Next fetches up to len(batch) strings into batch, returning the number of
+// strings that were fetched. It returns io.EOF once the enumeration has been
+// exhausted, possibly alongside a non-zero count.
+func (o EnumString) Next(batch []string) (int, error) {
+	p := *(o.Pp)
+
+	raw := make([]COMAllocatedString, len(batch))
+	n, err := p.Next(raw)
+	for i := range raw[:n] {
+		batch[i] = raw[i].String()
+		raw[i].Close()
+	}
+
+	return n, err
+}
+
+// All returns every string that remains in the enumeration.
+func (o EnumString) All() ([]string, error) {
+	return EnumToSlice(o.Next)
+}
+
+// Skip skips over the next count strings in the enumeration. It returns io.EOF
+// if fewer than count strings remained.
+func (o EnumString) Skip(count uint32) error {
+	p := *(o.Pp)
+	return p.Skip(count)
+}
+
+// Reset rewinds the enumeration to its beginning.
+func (o EnumString) Reset() error {
+	p := *(o.Pp)
+	return p.Reset()
+}
+
+// Clone creates a new enumerator with the same state as o.
+func (o EnumString) Clone() (result EnumString, _ error) {
+	p := *(o.Pp)
+	punk, err := p.Clone()
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(EnumString), nil
+}
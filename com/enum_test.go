@@ -0,0 +1,176 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sys/windows"
+)
+
+// fakeEnum emulates the semantics of an IEnum* interface's Next method.
+type fakeEnum struct {
+	elems []int
+	pos   int
+}
+
+func (fe *fakeEnum) next(batch []int) (int, error) {
+	n := copy(batch, fe.elems[fe.pos:])
+	fe.pos += n
+	if n < len(batch) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func TestEnumToSlice(t *testing.T) {
+	for _, count := range []int{0, 1, enumBatchSize - 1, enumBatchSize, enumBatchSize + 1, 3 * enumBatchSize} {
+		fe := &fakeEnum{elems: make([]int, count)}
+		for i := range fe.elems {
+			fe.elems[i] = i
+		}
+
+		got, err := EnumToSlice(fe.next)
+		if err != nil {
+			t.Errorf("EnumToSlice(%d elements) error: %v", count, err)
+			continue
+		}
+		if !slices.Equal(got, fe.elems) {
+			t.Errorf("EnumToSlice(%d elements) got %v, want %v", count, got, fe.elems)
+		}
+	}
+
+	errWant := errors.New("sentinel")
+	_, err := EnumToSlice(func(batch []int) (int, error) { return 0, errWant })
+	if err != errWant {
+		t.Errorf("EnumToSlice got error %v, want %v", err, errWant)
+	}
+}
+
+var procCreateBindCtx = modole32.NewProc("CreateBindCtx")
+
+// newBindCtxEnumString creates a system bind context, registers an object
+// parameter under each of keys, and returns the bind context's IEnumString
+// over those keys (via IBindCtx::EnumObjectParam).
+func newBindCtxEnumString(t *testing.T, keys []string) EnumString {
+	t.Helper()
+
+	var bindCtx *IUnknownABI
+	rc, _, _ := syscall.SyscallN(procCreateBindCtx.Addr(), 0, uintptr(unsafe.Pointer(&bindCtx)))
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		t.Fatalf("CreateBindCtx error: %v", e)
+	}
+	defer bindCtx.Release()
+
+	// The bind context holds its own references to the object parameters.
+	param := shCreateMemStream(nil, 0)
+	if param == nil {
+		t.Fatalf("SHCreateMemStream failed")
+	}
+	defer param.Release()
+
+	methods := unsafe.Slice(bindCtx.Vtbl, 13)
+	for _, key := range keys {
+		key16, err := windows.UTF16PtrFromString(key)
+		if err != nil {
+			t.Fatalf("UTF16PtrFromString error: %v", err)
+		}
+
+		// IBindCtx::RegisterObjectParam
+		rc, _, _ := syscall.SyscallN(
+			methods[9],
+			uintptr(unsafe.Pointer(bindCtx)),
+			uintptr(unsafe.Pointer(key16)),
+			uintptr(unsafe.Pointer(param)),
+		)
+		if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+			t.Fatalf("RegisterObjectParam(%q) error: %v", key, e)
+		}
+	}
+
+	// IBindCtx::EnumObjectParam
+	r := NewABIReceiver()
+	rc, _, _ = syscall.SyscallN(
+		methods[11],
+		uintptr(unsafe.Pointer(bindCtx)),
+		uintptr(unsafe.Pointer(r)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		if errors.Is(e, windows.ERROR_CALL_NOT_IMPLEMENTED) {
+			t.Skipf("IBindCtx::EnumObjectParam is not implemented on this system")
+		}
+		t.Fatalf("EnumObjectParam error: %v", e)
+	}
+
+	return EnumString{}.Make(r).(EnumString)
+}
+
+func TestEnumString(t *testing.T) {
+	keys := []string{"alpha", "bravo", "charlie"}
+	enum := newBindCtxEnumString(t, keys)
+
+	// The bind context does not specify the order of its keys, so we record
+	// the order of this enumeration and compare everything else against it.
+	order, err := enum.All()
+	if err != nil {
+		t.Fatalf("All error: %v", err)
+	}
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, keys) {
+		t.Fatalf("All got %v, want %v in any order", order, keys)
+	}
+
+	// The enumeration is now exhausted, so Next must report S_FALSE.
+	batch := make([]string, 2)
+	if n, err := enum.Next(batch); n != 0 || err != io.EOF {
+		t.Errorf("Next at end of enumeration got (%d, %v), want (0, %v)", n, err, io.EOF)
+	}
+	if err := enum.Skip(1); err != io.EOF {
+		t.Errorf("Skip at end of enumeration got error %v, want %v", err, io.EOF)
+	}
+
+	if err := enum.Reset(); err != nil {
+		t.Fatalf("Reset error: %v", err)
+	}
+	if err := enum.Skip(1); err != nil {
+		t.Fatalf("Skip error: %v", err)
+	}
+	if n, err := enum.Next(batch[:1]); n != 1 || err != nil || batch[0] != order[1] {
+		t.Errorf("Next after Skip(1) got (%d, %v, %q), want (1, nil, %q)", n, err, batch[0], order[1])
+	}
+
+	clone, err := enum.Clone()
+	if err != nil {
+		t.Fatalf("Clone error: %v", err)
+	}
+	cloneRest, err := clone.All()
+	if err != nil {
+		t.Fatalf("All on clone error: %v", err)
+	}
+	if !slices.Equal(cloneRest, order[2:]) {
+		t.Errorf("All on clone got %v, want %v", cloneRest, order[2:])
+	}
+
+	// Exhausting the clone must not have affected enum. Requesting more strings
+	// than remain returns the remainder alongside S_FALSE.
+	if n, err := enum.Next(batch); n != 1 || err != io.EOF || batch[0] != order[2] {
+		t.Errorf("Next past end got (%d, %v, %q), want (1, %v, %q)", n, err, batch[0], io.EOF, order[2])
+	}
+
+	if err := enum.Reset(); err != nil {
+		t.Fatalf("Reset error: %v", err)
+	}
+	if err := enum.Skip(uint32(len(keys) + 1)); err != io.EOF {
+		t.Errorf("Skip(%d) got error %v, want %v", len(keys)+1, err, io.EOF)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+const fileAccessInformation = 8 // FILE_INFORMATION_CLASS
+
+// NewStreamFromFile creates a new Stream that reads from (and, if f was opened
+// for writing, writes to) the same file as f. The Stream's seek pointer is
+// initialized to f's current offset, but thereafter the two are independent.
+// The Stream remains valid after f is closed.
+//
+// NewStreamFromFile uses SHCreateStreamOnFileEx to reopen f's file, which
+// requires Windows Vista or newer. The file must have been opened with sharing
+// modes that permit reopening it, which is always the case for files opened
+// via the os package.
+func NewStreamFromFile(f *os.File) (result Stream, _ error) {
+	h := windows.Handle(f.Fd())
+
+	path, err := finalPathNameByHandle(h)
+	if err != nil {
+		return result, err
+	}
+
+	mode := STGM_READ
+	writable, err := isHandleWritable(h)
+	if err != nil {
+		return result, err
+	}
+	if writable {
+		mode = STGM_READWRITE
+	}
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return result, err
+	}
+
+	path16, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return result, err
+	}
+
+	ppstream := NewABIReceiver()
+	hr := shCreateStreamOnFileEx(path16, uint32(mode|STGM_SHARE_DENY_NONE), windows.FILE_ATTRIBUTE_NORMAL, false, nil, ppstream)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return result, e
+	}
+
+	obj := result.Make(ppstream).(Stream)
+	if _, err := obj.Seek(offset, io.SeekStart); err != nil {
+		return result, err
+	}
+
+	return obj, nil
+}
+
+func finalPathNameByHandle(h windows.Handle) (string, error) {
+	buf := make([]uint16, windows.MAX_PATH)
+	for {
+		n, err := windows.GetFinalPathNameByHandle(h, unsafe.SliceData(buf), uint32(len(buf)), 0)
+		if err != nil {
+			return "", err
+		}
+		// On insufficient buffer, n is the required length including the NUL.
+		if n < uint32(len(buf)) {
+			return windows.UTF16ToString(buf[:n]), nil
+		}
+		buf = make([]uint16, n)
+	}
+}
+
+// isHandleWritable returns true if h was opened with write access.
+func isHandleWritable(h windows.Handle) (bool, error) {
+	var iosb windows.IO_STATUS_BLOCK
+	var access uint32 // FILE_ACCESS_INFORMATION
+	if err := ntQueryInformationFile(h, &iosb, unsafe.Pointer(&access), uint32(unsafe.Sizeof(access)), fileAccessInformation); err != nil {
+		return false, err
+	}
+
+	return access&windows.FILE_WRITE_DATA != 0, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestNewStreamFromFile(t *testing.T) {
+	values := makeTestBuf(16)
+	fname := filepath.Join(t.TempDir(), "stream.bin")
+	if err := os.WriteFile(fname, values, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer f.Close()
+
+	const offset = 4
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+
+	stream, err := NewStreamFromFile(f)
+	if err != nil {
+		t.Fatalf("NewStreamFromFile error: %v", err)
+	}
+	// Each stream holds its own handle to the file, so we must release them
+	// explicitly; otherwise t.TempDir may be unable to remove the file.
+	defer func() { releaseTestObject(stream.GenericObject) }()
+
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("Error reading stream: %v", err)
+	}
+	if !slices.Equal(values[offset:], got) {
+		t.Errorf("Slices not equal")
+	}
+
+	// f is read-only, so stream must be too.
+	if _, err := stream.Write(values); err == nil {
+		t.Errorf("Unexpected success writing to read-only stream")
+	}
+
+	// Finish with the read-only stream before reopening the file for writing.
+	releaseTestObject(stream.GenericObject)
+	stream = Stream{}
+
+	fw, err := os.OpenFile(fname, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile error: %v", err)
+	}
+	defer fw.Close()
+
+	wstream, err := NewStreamFromFile(fw)
+	if err != nil {
+		t.Fatalf("NewStreamFromFile error: %v", err)
+	}
+	defer releaseTestObject(wstream.GenericObject)
+	if _, err := wstream.Seek(0, io.SeekEnd); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+	if _, err := wstream.Write(values); err != nil {
+		t.Fatalf("Error writing stream: %v", err)
+	}
+	if err := wstream.Commit(STGC_DEFAULT); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	size, err := wstream.Size()
+	if err != nil {
+		t.Fatalf("Error calling Size: %v", err)
+	}
+	if size != uint64(len(values)*2) {
+		t.Errorf("Unexpected size, got %d, want %d", size, len(values)*2)
+	}
+}
+
+// releaseTestObject immediately releases the reference held by o instead of
+// leaving it to o's finalizer. It does nothing when o is the zero value.
+func releaseTestObject[A ABI](o GenericObject[A]) {
+	if o.Pp == nil {
+		return
+	}
+
+	r := (**IUnknownABI)(unsafe.Pointer(o.Pp))
+	runtime.SetFinalizer(r, nil)
+	ReleaseABI(r)
+}
@@ -22,16 +22,35 @@ var (
 	IID_IGlobalOptions = &IID{0x0000015B, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
 )
 
+// GLOBALOPT_PROPERTIES enumerates the global properties that may be queried
+// and set via GlobalOptions.
 type GLOBALOPT_PROPERTIES int32
 
 const (
-	COMGLB_EXCEPTION_HANDLING     = GLOBALOPT_PROPERTIES(1)
-	COMGLB_APPID                  = GLOBALOPT_PROPERTIES(2)
+	// COMGLB_EXCEPTION_HANDLING determines whether COM catches exceptions that
+	// are raised by COM servers. Its values are the COMGLB_EXCEPTION_*
+	// constants. It may be changed at any time.
+	COMGLB_EXCEPTION_HANDLING = GLOBALOPT_PROPERTIES(1)
+	// COMGLB_APPID is a pointer to the AppID of the process, which COM uses
+	// to locate the process's registry-based settings. It should be set as
+	// early as possible during process initialization.
+	COMGLB_APPID = GLOBALOPT_PROPERTIES(2)
+	// COMGLB_RPC_THREADPOOL_SETTING determines whether COM uses the system
+	// thread pool for RPC. Its values are the COMGLB_RPC_THREADPOOL_SETTING_*
+	// constants. It must be set before COM begins using RPC.
 	COMGLB_RPC_THREADPOOL_SETTING = GLOBALOPT_PROPERTIES(3)
-	COMGLB_RO_SETTINGS            = GLOBALOPT_PROPERTIES(4)
-	COMGLB_UNMARSHALING_POLICY    = GLOBALOPT_PROPERTIES(5)
+	// COMGLB_RO_SETTINGS is a combination of the COMGLB_STA_MODALLOOP_* and
+	// COMGLB_FAST_RUNDOWN flags. It may be set only once, and only prior to the
+	// current process creating any apartments other than the one in which the
+	// call to Set is made. Requires Windows 8 or newer.
+	COMGLB_RO_SETTINGS = GLOBALOPT_PROPERTIES(4)
+	// COMGLB_UNMARSHALING_POLICY restricts the marshalers that COM will accept
+	// when unmarshaling. Its values are the COMGLB_UNMARSHALING_POLICY_*
+	// constants. It may be set only once. Requires Windows 8 or newer.
+	COMGLB_UNMARSHALING_POLICY = GLOBALOPT_PROPERTIES(5)
 )
 
+// Values for COMGLB_EXCEPTION_HANDLING
 const (
 	COMGLB_EXCEPTION_HANDLE             = 0
 	COMGLB_EXCEPTION_DONOT_HANDLE_FATAL = 1
@@ -39,6 +58,28 @@ const (
 	COMGLB_EXCEPTION_DONOT_HANDLE_ANY   = 2
 )
 
+// Values for COMGLB_RPC_THREADPOOL_SETTING
+const (
+	COMGLB_RPC_THREADPOOL_SETTING_DEFAULT_POOL = 0
+	COMGLB_RPC_THREADPOOL_SETTING_PRIVATE_POOL = 1
+)
+
+// Flags for COMGLB_RO_SETTINGS
+const (
+	COMGLB_STA_MODALLOOP_REMOVE_TOUCH_MESSAGES                    = 0x1
+	COMGLB_STA_MODALLOOP_SHARED_QUEUE_REMOVE_INPUT_MESSAGES       = 0x2
+	COMGLB_STA_MODALLOOP_SHARED_QUEUE_DONOT_REMOVE_INPUT_MESSAGES = 0x4
+	COMGLB_FAST_RUNDOWN                                           = 0x8
+	COMGLB_STA_MODALLOOP_SHARED_QUEUE_REORDER_POINTER_MESSAGES    = 0x80
+)
+
+// Values for COMGLB_UNMARSHALING_POLICY
+const (
+	COMGLB_UNMARSHALING_POLICY_NORMAL = 0
+	COMGLB_UNMARSHALING_POLICY_STRONG = 1
+	COMGLB_UNMARSHALING_POLICY_HYBRID = 2
+)
+
 // IGlobalOptionsABI represents the COM ABI for the IGlobalOptions interface.
 type IGlobalOptionsABI struct {
 	IUnknownABI
@@ -107,7 +148,10 @@ func (o GlobalOptions) UnsafeUnwrap() *IGlobalOptionsABI {
 	return *(o.Pp)
 }
 
-// Set sets the global property prop to value.
+// Set sets the global property prop to value. Several properties may only be
+// set once, or only during early process initialization; see the documentation
+// for each GLOBALOPT_PROPERTIES value. Set fails when prop is no longer
+// writable.
 func (o GlobalOptions) Set(prop GLOBALOPT_PROPERTIES, value uintptr) error {
 	p := *(o.Pp)
 	return p.Set(prop, value)
@@ -118,3 +162,30 @@ func (o GlobalOptions) Query(prop GLOBALOPT_PROPERTIES) (uintptr, error) {
 	p := *(o.Pp)
 	return p.Query(prop)
 }
+
+// DisableCOMExceptionHandling sets COMGLB_EXCEPTION_HANDLING to
+// COMGLB_EXCEPTION_DONOT_HANDLE_ANY, preventing the COM runtime from swallowing
+// exceptions raised by COM servers. This is the recommended setting for all
+// applications, and the StartRuntime family of functions apply it
+// automatically. Like all GlobalOptions settings, it must be applied after COM
+// runtime security has been initialized, but before anything else
+// "significant" is done using COM.
+func DisableCOMExceptionHandling() error {
+	ppunk := NewABIReceiver()
+	hr := coCreateInstance(
+		CLSID_GlobalOptions,
+		nil,
+		coCLSCTX_INPROC_SERVER,
+		IID_IGlobalOptions,
+		ppunk,
+	)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return e
+	}
+	// We release the object as soon as we're done with it, as it is not needed
+	// once the option has been set.
+	defer ReleaseABI(ppunk)
+
+	p := (*IGlobalOptionsABI)(unsafe.Pointer(*ppunk))
+	return p.Set(COMGLB_EXCEPTION_HANDLING, COMGLB_EXCEPTION_DONOT_HANDLE_ANY)
+}
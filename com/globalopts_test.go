@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Tailscale Inc & AUTHORS. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package com
+
+import (
+	"testing"
+
+	"github.com/dblohm7/wingoes"
+)
+
+func TestGlobalOptionsQuery(t *testing.T) {
+	globalOpts, err := CreateInstance[GlobalOptions](CLSID_GlobalOptions)
+	if err != nil {
+		t.Fatalf("CreateInstance error: %v", err)
+	}
+
+	val, err := globalOpts.Query(COMGLB_EXCEPTION_HANDLING)
+	if err != nil {
+		t.Fatalf("Query(COMGLB_EXCEPTION_HANDLING) error: %v", err)
+	}
+	if val != COMGLB_EXCEPTION_DONOT_HANDLE_ANY {
+		t.Errorf("COMGLB_EXCEPTION_HANDLING got %d, want %d", val, COMGLB_EXCEPTION_DONOT_HANDLE_ANY)
+	}
+
+	if !wingoes.IsWin8OrGreater() {
+		return
+	}
+
+	if _, err := globalOpts.Query(COMGLB_RO_SETTINGS); err != nil {
+		t.Errorf("Query(COMGLB_RO_SETTINGS) error: %v", err)
+	}
+
+	val, err = globalOpts.Query(COMGLB_UNMARSHALING_POLICY)
+	if err != nil {
+		t.Fatalf("Query(COMGLB_UNMARSHALING_POLICY) error: %v", err)
+	}
+	if val != COMGLB_UNMARSHALING_POLICY_NORMAL {
+		t.Errorf("COMGLB_UNMARSHALING_POLICY got %d, want %d", val, COMGLB_UNMARSHALING_POLICY_NORMAL)
+	}
+}
+
+func TestDisableCOMExceptionHandling(t *testing.T) {
+	// The runtime has already applied this setting, but doing so again must be
+	// harmless.
+	if err := DisableCOMExceptionHandling(); err != nil {
+		t.Fatalf("DisableCOMExceptionHandling error: %v", err)
+	}
+
+	globalOpts, err := CreateInstance[GlobalOptions](CLSID_GlobalOptions)
+	if err != nil {
+		t.Fatalf("CreateInstance error: %v", err)
+	}
+
+	val, err := globalOpts.Query(COMGLB_EXCEPTION_HANDLING)
+	if err != nil {
+		t.Fatalf("Query(COMGLB_EXCEPTION_HANDLING) error: %v", err)
+	}
+	if val != COMGLB_EXCEPTION_DONOT_HANDLE_ANY {
+		t.Errorf("COMGLB_EXCEPTION_HANDLING got %d, want %d", val, COMGLB_EXCEPTION_DONOT_HANDLE_ANY)
+	}
+}
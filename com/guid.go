@@ -20,3 +20,63 @@ type AppID wingoes.GUID
 
 // ServiceID is a GUID that represents a service ID.
 type ServiceID wingoes.GUID
+
+// Equal returns true when iid and other are identical.
+func (iid IID) Equal(other IID) bool {
+	return iid == other
+}
+
+// IsZero returns true when iid contains the zero value.
+func (iid IID) IsZero() bool {
+	return iid == IID{}
+}
+
+// String returns iid in its canonical, braced, upper-case form.
+func (iid IID) String() string {
+	return wingoes.GUID(iid).String()
+}
+
+// Equal returns true when clsid and other are identical.
+func (clsid CLSID) Equal(other CLSID) bool {
+	return clsid == other
+}
+
+// IsZero returns true when clsid contains the zero value.
+func (clsid CLSID) IsZero() bool {
+	return clsid == CLSID{}
+}
+
+// String returns clsid in its canonical, braced, upper-case form.
+func (clsid CLSID) String() string {
+	return wingoes.GUID(clsid).String()
+}
+
+// Equal returns true when appID and other are identical.
+func (appID AppID) Equal(other AppID) bool {
+	return appID == other
+}
+
+// IsZero returns true when appID contains the zero value.
+func (appID AppID) IsZero() bool {
+	return appID == AppID{}
+}
+
+// String returns appID in its canonical, braced, upper-case form.
+func (appID AppID) String() string {
+	return wingoes.GUID(appID).String()
+}
+
+// Equal returns true when svcID and other are identical.
+func (svcID ServiceID) Equal(other ServiceID) bool {
+	return svcID == other
+}
+
+// IsZero returns true when svcID contains the zero value.
+func (svcID ServiceID) IsZero() bool {
+	return svcID == ServiceID{}
+}
+
+// String returns svcID in its canonical, braced, upper-case form.
+func (svcID ServiceID) String() string {
+	return wingoes.GUID(svcID).String()
+}
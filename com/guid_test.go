@@ -0,0 +1,28 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package com
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dblohm7/wingoes"
+)
+
+func TestGUIDTypesString(t *testing.T) {
+	const want = "{0C733A30-2A1C-11CE-ADE5-00AA0044773D}"
+	guid, err := wingoes.GUIDFromString(want)
+	if err != nil {
+		t.Fatalf("GUIDFromString(%q) error: %v", want, err)
+	}
+
+	iid, clsid, appID, svcID := IID(guid), CLSID(guid), AppID(guid), ServiceID(guid)
+	// Pointers to these types are commonplace, so we check those too.
+	testCases := []any{iid, &iid, clsid, &clsid, appID, &appID, svcID, &svcID}
+	for _, tc := range testCases {
+		if got := fmt.Sprintf("%v", tc); got != want {
+			t.Errorf("formatting %T got %q, want %q", tc, got, want)
+		}
+	}
+}
@@ -9,12 +9,24 @@ package com
 //go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go mksyscall.go
 //go:generate go run golang.org/x/tools/cmd/goimports -w zsyscall_windows.go
 
+//sys clsidFromProgID(progID *uint16, clsid *CLSID) (hr wingoes.HRESULT) = ole32.CLSIDFromProgID
 //sys coCreateInstance(clsid *CLSID, unkOuter *IUnknownABI, clsctx coCLSCTX, iid *IID, ppv **IUnknownABI) (hr wingoes.HRESULT) = ole32.CoCreateInstance
-//sys coGetApartmentType(aptType *coAPTTYPE, qual *coAPTTYPEQUALIFIER) (hr wingoes.HRESULT) = ole32.CoGetApartmentType
+//sys coCreateInstanceEx(clsid *CLSID, unkOuter *IUnknownABI, clsctx coCLSCTX, serverInfo *coServerInfo, count uint32, results *multiQI) (hr wingoes.HRESULT) = ole32.CoCreateInstanceEx
+//sys coGetClassObject(clsid *CLSID, clsctx coCLSCTX, serverInfo *coServerInfo, iid *IID, ppv **IUnknownABI) (hr wingoes.HRESULT) = ole32.CoGetClassObject
+//sys coGetApartmentType(aptType *ApartmentType, qual *ApartmentQualifier) (hr wingoes.HRESULT) = ole32.CoGetApartmentType
+//sys coRegisterClassObject(clsid *CLSID, unk *IUnknownABI, clsctx coCLSCTX, flags uint32, cookie *RegistrationCookie) (hr wingoes.HRESULT) = ole32.CoRegisterClassObject
+//sys coRevokeClassObject(cookie RegistrationCookie) (hr wingoes.HRESULT) = ole32.CoRevokeClassObject
+//sys coTaskMemAlloc(size uintptr) (p unsafe.Pointer) = ole32.CoTaskMemAlloc
 //sys coInitializeEx(reserved uintptr, flags uint32) (hr wingoes.HRESULT) = ole32.CoInitializeEx
-//sys coInitializeSecurity(sd *windows.SECURITY_DESCRIPTOR, authSvcLen int32, authSvc *soleAuthenticationService, reserved1 uintptr, authnLevel rpcAuthnLevel, impLevel rpcImpersonationLevel, authList *soleAuthenticationList, capabilities authCapabilities, reserved2 uintptr) (hr wingoes.HRESULT) = ole32.CoInitializeSecurity
+//sys coInitializeSecurity(sd *windows.SECURITY_DESCRIPTOR, authSvcLen int32, authSvc *soleAuthenticationService, reserved1 uintptr, authnLevel RPCAuthnLevel, impLevel RPCImpLevel, authList *soleAuthenticationList, capabilities authCapabilities, reserved2 uintptr) (hr wingoes.HRESULT) = ole32.CoInitializeSecurity
+//sys ntQueryInformationFile(handle windows.Handle, iosb *windows.IO_STATUS_BLOCK, info unsafe.Pointer, infoLen uint32, class uint32) (ntstatus error) = ntdll.NtQueryInformationFile
+//sys stgCreateDocfile(name *uint16, mode STGM, reserved uint32, storage **IUnknownABI) (hr wingoes.HRESULT) = ole32.StgCreateDocfile
+//sys stgOpenStorage(name *uint16, priority *IUnknownABI, mode STGM, exclude uintptr, reserved uint32, storage **IUnknownABI) (hr wingoes.HRESULT) = ole32.StgOpenStorage
+//sys propVariantClear(pv *PropVariant) (hr wingoes.HRESULT) = ole32.PropVariantClear
+//sys progIDFromCLSID(clsid *CLSID, progID *COMAllocatedString) (hr wingoes.HRESULT) = ole32.ProgIDFromCLSID
 
-// We don't use '?' on coIncrementMTAUsage because that doesn't play nicely with HRESULTs. We manually check for its presence in process.go
+// We don't use '?' on coIncrementMTAUsage or coDecrementMTAUsage because that doesn't play nicely with HRESULTs. We manually check for their presence in process.go
+//sys coDecrementMTAUsage(cookie coMTAUsageCookie) (hr wingoes.HRESULT) = ole32.CoDecrementMTAUsage
 //sys coIncrementMTAUsage(cookie *coMTAUsageCookie) (hr wingoes.HRESULT) = ole32.CoIncrementMTAUsage
 
 // Technically this proc is __cdecl, but since it has 0 args this doesn't matter
@@ -22,4 +34,12 @@ package com
 
 // For the following two functions we use IUnknownABI instead of IStreamABI because it makes the callsites cleaner.
 //sys shCreateMemStream(pInit *byte, cbInit uint32) (stream *IUnknownABI) = shlwapi.SHCreateMemStream
+//sys shCreateStreamOnFileEx(file *uint16, mode uint32, attrs uint32, create bool, template *IUnknownABI, stream **IUnknownABI) (hr wingoes.HRESULT) = shlwapi.SHCreateStreamOnFileEx
 //sys createStreamOnHGlobal(hglobal internal.HGLOBAL, deleteOnRelease bool, stream **IUnknownABI) (hr wingoes.HRESULT) = ole32.CreateStreamOnHGlobal
+//sys getHGlobalFromStream(stream *IUnknownABI, hglobal *internal.HGLOBAL) (hr wingoes.HRESULT) = ole32.GetHGlobalFromStream
+
+//sys dispatchMessage(msg *msg) (res uintptr) = user32.DispatchMessageW
+//sys msgWaitForMultipleObjectsEx(count uint32, handles *windows.Handle, millis uint32, wakeMask uint32, flags uint32) (ret uint32, err error) [failretval==0xFFFFFFFF] = user32.MsgWaitForMultipleObjectsEx
+//sys peekMessage(msg *msg, hwnd windows.HWND, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (ret bool) = user32.PeekMessageW
+//sys postThreadMessage(threadID uint32, msg uint32, wParam uintptr, lParam uintptr) (err error) [int32(failretval)==0] = user32.PostThreadMessageW
+//sys translateMessage(msg *msg) (ret bool) = user32.TranslateMessage
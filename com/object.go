@@ -7,6 +7,7 @@
 package com
 
 import (
+	"errors"
 	"fmt"
 	"unsafe"
 )
@@ -20,6 +21,52 @@ func (o GenericObject[A]) pp() **A {
 	return o.Pp
 }
 
+// QueryInterfaceRaw queries o for the interface identified by iid and returns
+// the resulting interface pointer as-is. It is an escape hatch for passing COM
+// interfaces to code that this package does not wrap.
+//
+// Unlike TryAs, the result is NOT garbage-collected: upon success, the caller
+// owns a reference to the result and must eventually call Release on it exactly
+// once. Do not pass the result to Make, since the resulting object's finalizer
+// would then release the same reference a second time.
+func (o GenericObject[A]) QueryInterfaceRaw(iid *IID) (*IUnknownABI, error) {
+	p := (*IUnknownABI)(unsafe.Pointer(*(o.Pp)))
+	i, err := p.QueryInterface(iid)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.(*IUnknownABI), nil
+}
+
+// AddRef increments the reference count of the COM interface wrapped by o, and
+// returns the new count. It is an escape hatch for callers that explicitly
+// manage object lifetimes. Every call to AddRef must eventually be balanced by
+// exactly one call to Release.
+//
+// COM does not guarantee the accuracy of the returned count; use it only for
+// diagnostic purposes.
+func (o GenericObject[A]) AddRef() uint32 {
+	p := (*IUnknownABI)(unsafe.Pointer(*(o.Pp)))
+	return uint32(p.AddRef())
+}
+
+// Release decrements the reference count of the COM interface wrapped by o, and
+// returns the new count.
+//
+// The reference that o acquired upon its creation is owned by its finalizer,
+// which releases it when o is garbage-collected. Therefore Release must only be
+// used to balance prior calls to AddRef: calling Release more times than AddRef
+// will cause the object to be released prematurely, and the finalizer will then
+// release it again.
+//
+// COM does not guarantee the accuracy of the returned count; use it only for
+// diagnostic purposes.
+func (o GenericObject[A]) Release() uint32 {
+	p := (*IUnknownABI)(unsafe.Pointer(*(o.Pp)))
+	return uint32(p.Release())
+}
+
 // Object is the interface that all garbage-collected instances of COM interfaces
 // must implement.
 type Object interface {
@@ -55,18 +102,67 @@ func As[O Object, A ABI, PU PUnknown[A], E EmbedsGenericObject[A]](obj E) O {
 func TryAs[O Object, A ABI, PU PUnknown[A], E EmbedsGenericObject[A]](obj E) (O, error) {
 	var o O
 
-	iid := o.IID()
 	p := (PU)(unsafe.Pointer(*(obj.pp())))
 
-	i, err := p.QueryInterface(iid)
+	result, err := queryAndMake(p, o)
 	if err != nil {
 		return o, err
 	}
 
+	return result.(O), nil
+}
+
+// QueryFirst queries obj for the interface of each object in candidates, in
+// order, and returns a new object of the same type as the first candidate whose
+// interface is implemented by obj. The candidates are only used for their IIDs
+// and types, so zero values are sufficient. If obj implements none of the
+// candidates' interfaces, QueryFirst returns an error listing each IID that was
+// tried.
+func QueryFirst[A ABI, PU PUnknown[A], E EmbedsGenericObject[A]](obj E, candidates ...Object) (Object, error) {
+	if len(candidates) == 0 {
+		return nil, errors.New("wingoes.com.QueryFirst: no candidate interfaces specified")
+	}
+
+	p := (PU)(unsafe.Pointer(*(obj.pp())))
+
+	errs := make([]error, 0, len(candidates))
+	for _, c := range candidates {
+		result, err := queryAndMake(p, c)
+		if err == nil {
+			return result.(Object), nil
+		}
+		errs = append(errs, fmt.Errorf("QueryInterface(%v): %w", c.IID(), err))
+	}
+
+	return nil, fmt.Errorf("wingoes.com.QueryFirst: no candidate interfaces implemented: %w", errors.Join(errs...))
+}
+
+// DebugRefCount returns the current reference count of the COM interface
+// wrapped by obj, which includes the reference held by obj itself.
+//
+// DEBUGGING ONLY! DebugRefCount obtains the count by calling AddRef and Release
+// in succession. COM does not guarantee the accuracy of the values returned by
+// those methods, and another thread may change the count at any time. Never use
+// the result to make decisions in production code; it is only intended for
+// assertions while chasing reference counting bugs.
+func DebugRefCount[A ABI, PU PUnknown[A], E EmbedsGenericObject[A]](obj E) uint32 {
+	p := (PU)(unsafe.Pointer(*(obj.pp())))
+	p.AddRef()
+	return uint32(p.Release())
+}
+
+// queryAndMake queries p for the interface of o and, upon success, wraps the
+// result in a new object of the same type as o.
+func queryAndMake(p IUnknown, o Object) (any, error) {
+	i, err := p.QueryInterface(o.IID())
+	if err != nil {
+		return nil, err
+	}
+
 	r := NewABIReceiver()
 	*r = i.(*IUnknownABI)
 
-	return o.Make(r).(O), nil
+	return o.Make(r), nil
 }
 
 // IsSameObject returns true when both l and r refer to the same underlying object.
@@ -7,6 +7,7 @@
 package com
 
 import (
+	"runtime"
 	"testing"
 )
 
@@ -30,3 +31,99 @@ func TestTryAs(t *testing.T) {
 		t.Errorf("globalOpts ABI != globalOpts2 ABI")
 	}
 }
+
+func TestQueryFirst(t *testing.T) {
+	globalOpts, err := CreateInstance[GlobalOptions](CLSID_GlobalOptions)
+	if err != nil {
+		t.Fatalf("CreateInstance(CLSID_GlobalOptions) error: %v", err)
+	}
+
+	obj, err := QueryFirst(globalOpts, Stream{}, GlobalOptions{}, ObjectBase{})
+	if err != nil {
+		t.Fatalf("QueryFirst error: %v", err)
+	}
+
+	globalOpts2, ok := obj.(GlobalOptions)
+	if !ok {
+		t.Fatalf("QueryFirst returned %T, want GlobalOptions", obj)
+	}
+	if globalOpts.UnsafeUnwrap() != globalOpts2.UnsafeUnwrap() {
+		t.Errorf("globalOpts ABI != globalOpts2 ABI")
+	}
+
+	if _, err := QueryFirst(globalOpts, Stream{}, SequentialStream{}); err == nil {
+		t.Errorf("QueryFirst unexpectedly succeeded for unimplemented interfaces")
+	}
+
+	if _, err := QueryFirst(globalOpts); err == nil {
+		t.Errorf("QueryFirst unexpectedly succeeded without candidates")
+	}
+}
+
+func TestAddRefRelease(t *testing.T) {
+	stream, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+
+	if got := stream.AddRef(); got != 2 {
+		t.Errorf("AddRef got %d, want 2", got)
+	}
+	if got := DebugRefCount(stream); got != 2 {
+		t.Errorf("DebugRefCount after AddRef got %d, want 2", got)
+	}
+	if got := stream.Release(); got != 1 {
+		t.Errorf("Release got %d, want 1", got)
+	}
+	if got := DebugRefCount(stream); got != 1 {
+		t.Errorf("DebugRefCount after Release got %d, want 1", got)
+	}
+}
+
+func TestDebugRefCount(t *testing.T) {
+	stream, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+
+	if got := DebugRefCount(stream); got != 1 {
+		t.Errorf("DebugRefCount got %d, want 1", got)
+	}
+
+	seqStream, err := TryAs[SequentialStream](stream)
+	if err != nil {
+		t.Fatalf("TryAs(SequentialStream) error: %v", err)
+	}
+
+	if got := DebugRefCount(stream); got != 2 {
+		t.Errorf("DebugRefCount after TryAs got %d, want 2", got)
+	}
+
+	runtime.KeepAlive(seqStream)
+}
+
+func TestQueryInterfaceRaw(t *testing.T) {
+	stream, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+
+	punk, err := stream.QueryInterfaceRaw(IID_ISequentialStream)
+	if err != nil {
+		t.Fatalf("QueryInterfaceRaw(IID_ISequentialStream) error: %v", err)
+	}
+
+	if got := DebugRefCount(stream); got != 2 {
+		t.Errorf("DebugRefCount after QueryInterfaceRaw got %d, want 2", got)
+	}
+
+	punk.Release()
+
+	if got := DebugRefCount(stream); got != 1 {
+		t.Errorf("DebugRefCount after Release got %d, want 1", got)
+	}
+
+	if _, err := stream.QueryInterfaceRaw(IID_IGlobalOptions); err == nil {
+		t.Errorf("QueryInterfaceRaw(IID_IGlobalOptions) unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+)
+
+var (
+	IID_IPersist       = &IID{0x0000010C, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	IID_IPersistStream = &IID{0x00000109, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+)
+
+type IPersistABI struct {
+	IUnknownABI
+}
+
+type IPersistStreamABI struct {
+	IPersistABI
+}
+
+// PersistStream wraps an IPersistStream, which is implemented by COM objects
+// that are able to save their state to, and load their state from, a Stream.
+type PersistStream struct {
+	GenericObject[IPersistStreamABI]
+}
+
+func (abi *IPersistABI) GetClassID() (result CLSID, _ error) {
+	method := unsafe.Slice(abi.Vtbl, 4)[3]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(&result)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return result, e
+	}
+
+	return result, nil
+}
+
+func (abi *IPersistStreamABI) IsDirty() (bool, error) {
+	method := unsafe.Slice(abi.Vtbl, 8)[4]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+	)
+	e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc))
+	if e.Failed() {
+		return false, e
+	}
+
+	return e.AsHRESULT() != wingoes.S_FALSE, nil
+}
+
+func (abi *IPersistStreamABI) Load(stream *IStreamABI) error {
+	method := unsafe.Slice(abi.Vtbl, 8)[5]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(stream)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IPersistStreamABI) Save(stream *IStreamABI, clearDirty bool) error {
+	var clearDirtyArg uintptr
+	if clearDirty {
+		clearDirtyArg = 1
+	}
+
+	method := unsafe.Slice(abi.Vtbl, 8)[6]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(stream)),
+		clearDirtyArg,
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IPersistStreamABI) GetSizeMax() (uint64, error) {
+	var result uint64
+	method := unsafe.Slice(abi.Vtbl, 8)[7]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(&result)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return 0, e
+	}
+
+	return result, nil
+}
+
+func (o PersistStream) IID() *IID {
+	return IID_IPersistStream
+}
+
+func (o PersistStream) Make(r ABIReceiver) any {
+	if r == nil {
+		return PersistStream{}
+	}
+
+	runtime.SetFinalizer(r, ReleaseABI)
+
+	pp := (**IPersistStreamABI)(unsafe.Pointer(r))
+	return PersistStream{GenericObject[IPersistStreamABI]{Pp: pp}}
+}
+
+// UnsafeUnwrap returns the underlying IPersistStreamABI of the object. As the
+// name implies, this is unsafe -- you had better know what you are doing!
+func (o PersistStream) UnsafeUnwrap() *IPersistStreamABI {
+	return *(o.Pp)
+}
+
+// GetClassID returns the CLSID of the object, which is needed to instantiate a
+// new object into which its saved state may be loaded.
+func (o PersistStream) GetClassID() (CLSID, error) {
+	p := *(o.Pp)
+	return p.GetClassID()
+}
+
+// IsDirty returns true when the object has changed since it was last saved.
+func (o PersistStream) IsDirty() (bool, error) {
+	p := *(o.Pp)
+	return p.IsDirty()
+}
+
+// Load initializes the object using the state that was previously saved to s,
+// beginning at s's current seek pointer.
+func (o PersistStream) Load(s Stream) error {
+	p := *(o.Pp)
+	return p.Load(s.UnsafeUnwrap())
+}
+
+// Save writes the object's state to s, beginning at s's current seek pointer.
+// When clearDirty is true, the object's dirty flag is cleared upon success.
+func (o PersistStream) Save(s Stream, clearDirty bool) error {
+	p := *(o.Pp)
+	return p.Save(s.UnsafeUnwrap(), clearDirty)
+}
+
+// GetSizeMax returns the maximum number of bytes that Save would write.
+func (o PersistStream) GetSizeMax() (uint64, error) {
+	p := *(o.Pp)
+	return p.GetSizeMax()
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+var clsidShellLink = &CLSID{0x00021401, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+
+func savePersistStream(t *testing.T, ps PersistStream) []byte {
+	t.Helper()
+
+	stream, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+
+	if err := ps.Save(stream, true); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	if _, err := stream.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+
+	saved, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll error: %v", err)
+	}
+
+	return saved
+}
+
+func TestPersistStream(t *testing.T) {
+	ps, err := CreateInstance[PersistStream](clsidShellLink)
+	if err != nil {
+		t.Fatalf("CreateInstance error: %v", err)
+	}
+
+	clsid, err := ps.GetClassID()
+	if err != nil {
+		t.Fatalf("GetClassID error: %v", err)
+	}
+	if clsid != *clsidShellLink {
+		t.Errorf("GetClassID got %v, want %v", clsid, *clsidShellLink)
+	}
+
+	saved := savePersistStream(t, ps)
+	if len(saved) == 0 {
+		t.Fatalf("Save wrote no data")
+	}
+
+	if dirty, err := ps.IsDirty(); err != nil {
+		t.Errorf("IsDirty error: %v", err)
+	} else if dirty {
+		t.Errorf("IsDirty got true after clearing dirty flag, want false")
+	}
+
+	ps2, err := CreateInstance[PersistStream](&clsid)
+	if err != nil {
+		t.Fatalf("CreateInstance error: %v", err)
+	}
+
+	stream, err := NewMemoryStream(saved)
+	if err != nil {
+		t.Fatalf("NewMemoryStream error: %v", err)
+	}
+	if err := ps2.Load(stream); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	if saved2 := savePersistStream(t, ps2); !slices.Equal(saved, saved2) {
+		t.Errorf("round-tripped state does not match original")
+	}
+}
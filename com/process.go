@@ -7,8 +7,11 @@
 package com
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/dblohm7/wingoes"
 	"golang.org/x/sys/windows"
@@ -55,6 +58,42 @@ func StartRuntime(processType ProcessType) error {
 // An excellent location to call StartRuntimeWithDACL is in the init function of
 // the main package.
 func StartRuntimeWithDACL(processType ProcessType, dacl *windows.ACL) error {
+	return StartRuntimeWithSecurity(processType, SecurityOptions{DACL: dacl})
+}
+
+// SecurityOptions specifies the process-wide COM security settings to be used
+// by StartRuntimeWithSecurity. Its zero value is equivalent to the settings
+// used by StartRuntime.
+type SecurityOptions struct {
+	// DACL is an ACL that controls access of other processes connecting to the
+	// current process over COM. When nil, COM obtains all of its security
+	// settings from the registry, using system-wide defaults where necessary;
+	// AuthnLevel and ImpLevel must then also be left at their default values.
+	DACL *windows.ACL
+	// AuthnLevel is the minimum authentication level for COM calls into the
+	// current process, as well as the default authentication level for outgoing
+	// COM calls.
+	AuthnLevel RPCAuthnLevel
+	// ImpLevel is the default impersonation level for outgoing COM calls. When
+	// RPC_C_IMP_LEVEL_DEFAULT, RPC_C_IMP_LEVEL_IDENTIFY is used.
+	ImpLevel RPCImpLevel
+}
+
+// StartRuntimeWithSecurity permanently initializes COM for the remaining
+// lifetime of the current process, using the process-wide security settings
+// specified by opts. It otherwise behaves identically to StartRuntime. It
+// returns os.ErrInvalid when opts specifies non-default authentication or
+// impersonation levels without also specifying a DACL.
+// Hardened services may use opts to raise the authentication level that COM
+// requires, such as to RPC_C_AUTHN_LEVEL_PKT_PRIVACY.
+// An excellent location to call StartRuntimeWithSecurity is in the init
+// function of the main package.
+func StartRuntimeWithSecurity(processType ProcessType, opts SecurityOptions) error {
+	if opts.DACL == nil && (opts.AuthnLevel != RPC_C_AUTHN_LEVEL_DEFAULT || opts.ImpLevel != RPC_C_IMP_LEVEL_DEFAULT) {
+		// Without a DACL, COM ignores everything but the registry.
+		return os.ErrInvalid
+	}
+
 	runtime.LockOSThread()
 
 	defer func() {
@@ -94,7 +133,7 @@ func StartRuntimeWithDACL(processType ProcessType, dacl *windows.ACL) error {
 
 	// Order is extremely important here: initSecurity must be called immediately
 	// after apartments are set up, but before doing anything else.
-	if err := initSecurity(dacl); err != nil {
+	if err := initSecurity(&opts); err != nil {
 		return err
 	}
 
@@ -102,12 +141,7 @@ func StartRuntimeWithDACL(processType ProcessType, dacl *windows.ACL) error {
 	// exception handler at its API boundary. This is dangerous, so we override it.
 	// This work must happen after security settings are initialized, but before
 	// anything "significant" is done with COM.
-	globalOpts, err := CreateInstance[GlobalOptions](CLSID_GlobalOptions)
-	if err != nil {
-		return err
-	}
-
-	err = globalOpts.Set(COMGLB_EXCEPTION_HANDLING, COMGLB_EXCEPTION_DONOT_HANDLE_ANY)
+	err := DisableCOMExceptionHandling()
 
 	// The BSTR cache never invalidates itself, so we disable it unconditionally.
 	// We do this here to ensure that the BSTR cache is off before anything
@@ -137,6 +171,48 @@ func startMTAImplicitly() error {
 	return nil
 }
 
+// EnterMTA ensures that the COM multi-threaded apartment (MTA) exists until
+// leave is called, so that OS threads that have not explicitly entered an
+// apartment may participate in the MTA as implicit members. Unlike
+// StartRuntime, EnterMTA does not perform any process-wide initialization and
+// does not affect the apartment of the current OS thread; it is suitable for
+// use by library code that cannot dictate a process's COM policy.
+//
+// Callers must invoke leave once they no longer require the MTA; subsequent
+// invocations of leave are no-ops. EnterMTA requires Windows 8 or newer; on
+// older versions it returns an error wrapping wingoes.ErrUnsupportedOSVersion.
+func EnterMTA() (leave func(), err error) {
+	if err := findProcs(procCoIncrementMTAUsage, procCoDecrementMTAUsage); err != nil {
+		return nil, err
+	}
+
+	var cookie coMTAUsageCookie
+	hr := coIncrementMTAUsage(&cookie)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return nil, e
+	}
+
+	var once sync.Once
+	leave = func() {
+		once.Do(func() {
+			coDecrementMTAUsage(cookie)
+		})
+	}
+
+	return leave, nil
+}
+
+// findProcs ensures that every proc in procs is present in its DLL, otherwise
+// it returns an error wrapping wingoes.ErrUnsupportedOSVersion.
+func findProcs(procs ...*windows.LazyProc) error {
+	for _, proc := range procs {
+		if err := proc.Find(); err != nil {
+			return fmt.Errorf("%w: %w", wingoes.ErrUnsupportedOSVersion, err)
+		}
+	}
+	return nil
+}
+
 // startMTAImplicitlyLegacy works by having a background OS thread explicitly enter
 // the multi-threaded apartment. All other OS threads that have not explicitly
 // entered an apartment will become implicit members of that MTA. This function is
@@ -185,6 +261,115 @@ func enterSTA() error {
 	return coInit(windows.COINIT_APARTMENTTHREADED)
 }
 
+// ErrInitializingSTA is wrapped by the errors returned by RunOnSTA when it
+// is unable to initialize a single-threaded apartment.
+var ErrInitializingSTA = errors.New("unable to initialize single-threaded apartment")
+
+// RunOnSTA creates a dedicated OS thread, enters a new single-threaded apartment
+// (STA) on that thread, and then invokes fn from within that STA's message
+// loop. The loop remains active until fn has returned: any messages that are
+// queued on the STA's thread are dispatched before fn is invoked, whenever fn
+// waits in a manner that pumps messages (as COM does on fn's behalf while fn
+// is blocked on outgoing COM calls), and after fn returns. Once the queue has
+// been drained, RunOnSTA leaves the STA and returns fn's error.
+//
+// When RunOnSTA is unable to initialize the STA or its message loop, it does
+// not call fn and returns an error that wraps ErrInitializingSTA.
+//
+// If fn panics, the STA is still torn down, and RunOnSTA then re-panics with
+// the same value in the calling goroutine. Similarly, if fn calls
+// runtime.Goexit, RunOnSTA calls runtime.Goexit in the calling goroutine.
+func RunOnSTA(fn func() error) error {
+	c := make(chan staResult, 1)
+	go func() {
+		defer close(c)
+		c <- runOnSTA(fn)
+	}()
+
+	result, ok := <-c
+	if !ok {
+		// fn called runtime.Goexit.
+		runtime.Goexit()
+	}
+	if result.panicked {
+		panic(result.panicVal)
+	}
+	return result.err
+}
+
+// staResult holds the outcome of invoking a RunOnSTA callback.
+type staResult struct {
+	err      error
+	panicked bool
+	panicVal any
+}
+
+// wmRunOnSTA is the private thread message that instructs the message loop in
+// runOnSTA to invoke its callback.
+const wmRunOnSTA = wmApp
+
+func runOnSTA(fn func() error) (result staResult) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := enterSTA(); err != nil {
+		result.err = fmt.Errorf("%w: %w", ErrInitializingSTA, err)
+		return result
+	}
+	defer windows.CoUninitialize()
+
+	// We invoke fn by way of a thread message so that fn is called from within
+	// the message loop below, after any messages that precede it in the queue.
+	if err := postThreadMessage(windows.GetCurrentThreadId(), wmRunOnSTA, 0, 0); err != nil {
+		result.err = fmt.Errorf("%w: %w", ErrInitializingSTA, err)
+		return result
+	}
+
+	for {
+		var m msg
+		called := false
+		for peekMessage(&m, 0, 0, 0, pmRemove) {
+			if m.hwnd == 0 {
+				switch m.message {
+				case wmRunOnSTA:
+					result = callSTAFunc(fn)
+					called = true
+					// Keep going so that we drain anything that fn left in the queue.
+					continue
+				case wmQuit:
+					if called {
+						return result
+					}
+					continue
+				}
+			}
+			translateMessage(&m)
+			dispatchMessage(&m)
+		}
+		if called {
+			return result
+		}
+
+		if _, err := msgWaitForMultipleObjectsEx(0, nil, windows.INFINITE, qsAllInput, mwmoInputAvailable); err != nil {
+			// We have not called fn yet, and we cannot wait for it to be dispatched.
+			result.err = fmt.Errorf("%w: %w", ErrInitializingSTA, err)
+			return result
+		}
+	}
+}
+
+// callSTAFunc invokes fn, recovering from any panic so that runOnSTA may
+// tear down its STA before RunOnSTA propagates the panic to its caller.
+func callSTAFunc(fn func() error) (result staResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = staResult{panicked: true, panicVal: r}
+		}
+	}()
+
+	return staResult{err: fn()}
+}
+
 // coInit is a wrapper for CoInitializeEx that properly handles the S_FALSE
 // error code (x/sys/windows.CoInitializeEx does not).
 func coInit(apartment uint32) error {
@@ -200,10 +385,10 @@ const (
 	authSvcCOMChooses = -1
 )
 
-// initSecurity initializes COM security using the ACL specified by dacl.
-// A nil dacl implies that a default ACL should be used instead.
-func initSecurity(dacl *windows.ACL) error {
-	sd, err := buildSecurityDescriptor(dacl)
+// initSecurity initializes COM security using the settings specified by opts.
+// A nil opts.DACL implies that a default ACL should be used instead.
+func initSecurity(opts *SecurityOptions) error {
+	sd, err := buildSecurityDescriptor(opts.DACL)
 	if err != nil {
 		return err
 	}
@@ -214,13 +399,18 @@ func initSecurity(dacl *windows.ACL) error {
 		caps |= authCapAppID
 	}
 
+	impLevel := opts.ImpLevel
+	if impLevel == RPC_C_IMP_LEVEL_DEFAULT {
+		impLevel = RPC_C_IMP_LEVEL_IDENTIFY
+	}
+
 	hr := coInitializeSecurity(
 		sd,
 		authSvcCOMChooses,
 		nil, // authSvc (not used because previous arg is authSvcCOMChooses)
 		0,   // Reserved, must be 0
-		rpcAuthnLevelDefault,
-		rpcImpLevelIdentify,
+		opts.AuthnLevel,
+		impLevel,
 		nil, // authlist: use defaults
 		caps,
 		0, // Reserved, must be 0
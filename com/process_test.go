@@ -7,8 +7,11 @@
 package com
 
 import (
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/dblohm7/wingoes"
 )
 
 // Each of these tests needs to run as their own process, since StartRuntime
@@ -38,3 +41,64 @@ func TestNonGUI(t *testing.T) {
 		t.Errorf("%s\n", strings.TrimPrefix(output, "error: "))
 	}
 }
+
+func TestNonGUISecurity(t *testing.T) {
+	output := strings.TrimSpace(runTestProg(t, "testprocessruntime", "NonGUIAppSecurity"))
+	want := "OK"
+	if output != want {
+		t.Errorf("%s\n", strings.TrimPrefix(output, "error: "))
+	}
+}
+
+func TestEnterMTA(t *testing.T) {
+	output := strings.TrimSpace(runTestProg(t, "testprocessruntime", "EnterMTA"))
+	want := "OK"
+	if output != want {
+		t.Errorf("%s\n", strings.TrimPrefix(output, "error: "))
+	}
+}
+
+func TestFindProcsUnsupported(t *testing.T) {
+	if err := findProcs(procCoIncrementMTAUsage, procCoDecrementMTAUsage); err != nil {
+		t.Errorf("findProcs on MTA usage procs got error %v, want nil", err)
+	}
+
+	// A proc that no version of ole32 exports stands in for running on a version
+	// of Windows that predates the MTA usage APIs.
+	missing := modole32.NewProc("WingoesNonexistentProc")
+	err := findProcs(procCoIncrementMTAUsage, missing)
+	if !errors.Is(err, wingoes.ErrUnsupportedOSVersion) {
+		t.Errorf("findProcs with missing proc got error %v, want %v", err, wingoes.ErrUnsupportedOSVersion)
+	}
+}
+
+func TestRunOnSTA(t *testing.T) {
+	errWant := errors.New("sentinel")
+	err := RunOnSTA(func() error {
+		if !IsCurrentOSThreadSTA() {
+			t.Errorf("RunOnSTA did not invoke fn from an STA")
+		}
+		return errWant
+	})
+	if err != errWant {
+		t.Errorf("RunOnSTA got error %v, want %v", err, errWant)
+	}
+
+	if err := RunOnSTA(func() error { return nil }); err != nil {
+		t.Errorf("RunOnSTA got error %v, want nil", err)
+	}
+}
+
+func TestRunOnSTAPanic(t *testing.T) {
+	const panicWant = "sentinel panic"
+	defer func() {
+		if r := recover(); r != panicWant {
+			t.Errorf("RunOnSTA got panic value %v, want %q", r, panicWant)
+		}
+	}()
+
+	RunOnSTA(func() error {
+		panic(panicWant)
+	})
+	t.Errorf("RunOnSTA did not propagate fn's panic")
+}
@@ -0,0 +1,232 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+// VARTYPE is an enumeration from the Windows SDK that identifies the type of
+// the value held by a PropVariant.
+type VARTYPE uint16
+
+const (
+	VT_EMPTY    = VARTYPE(0)
+	VT_BOOL     = VARTYPE(11)
+	VT_UI4      = VARTYPE(19)
+	VT_LPWSTR   = VARTYPE(31)
+	VT_FILETIME = VARTYPE(64)
+	VT_VECTOR   = VARTYPE(0x1000)
+)
+
+// PropVariant is the PROPVARIANT structure from the Windows SDK. Any memory
+// referenced by a PropVariant is allocated by COM and is not garbage collected;
+// call Clear to free it once the PropVariant is no longer needed.
+type PropVariant struct {
+	vt VARTYPE
+	_  uint16 // wReserved1
+	_  uint16 // wReserved2
+	_  uint16 // wReserved3
+	// data is large enough to hold any member of PROPVARIANT's union that we
+	// support, and is correctly sized and aligned on all supported archs.
+	data [2]uintptr
+}
+
+// calpwstr is the CALPWSTR structure from the Windows SDK, which is the
+// representation of VT_VECTOR|VT_LPWSTR values within a PropVariant.
+type calpwstr struct {
+	count uint32
+	elems *uintptr
+}
+
+const (
+	variantTrue  = int16(-1)
+	variantFalse = int16(0)
+)
+
+// newTaskMemUTF16 allocates a NUL-terminated copy of s using the COM task
+// memory allocator.
+func newTaskMemUTF16(s string) (uintptr, error) {
+	s16, err := windows.UTF16FromString(s)
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := TaskMemAlloc(uintptr(len(s16)) * unsafe.Sizeof(s16[0]))
+	if err != nil {
+		return 0, err
+	}
+
+	copy(unsafe.Slice((*uint16)(p), len(s16)), s16)
+	return uintptr(p), nil
+}
+
+// NewPropVariantString creates a new VT_LPWSTR PropVariant containing a copy
+// of s. Call Clear on the result when it is no longer needed.
+func NewPropVariantString(s string) (result PropVariant, _ error) {
+	p, err := newTaskMemUTF16(s)
+	if err != nil {
+		return result, err
+	}
+
+	result.vt = VT_LPWSTR
+	result.data[0] = p
+	return result, nil
+}
+
+// NewPropVariantStringVector creates a new VT_VECTOR|VT_LPWSTR PropVariant
+// containing copies of ss. Call Clear on the result when it is no longer needed.
+func NewPropVariantStringVector(ss []string) (result PropVariant, _ error) {
+	result.vt = VT_VECTOR | VT_LPWSTR
+	if len(ss) == 0 {
+		return result, nil
+	}
+
+	arr, err := TaskMemAlloc(uintptr(len(ss)) * unsafe.Sizeof(uintptr(0)))
+	if err != nil {
+		return PropVariant{}, err
+	}
+
+	ca := result.calpwstr()
+	ca.elems = (*uintptr)(arr)
+	elems := unsafe.Slice(ca.elems, len(ss))
+	for i, s := range ss {
+		p, err := newTaskMemUTF16(s)
+		if err != nil {
+			// Clear frees everything that we have allocated thus far.
+			result.Clear()
+			return PropVariant{}, err
+		}
+		elems[i] = p
+		ca.count++
+	}
+
+	return result, nil
+}
+
+// NewPropVariantUint32 creates a new VT_UI4 PropVariant containing v.
+func NewPropVariantUint32(v uint32) (result PropVariant) {
+	result.vt = VT_UI4
+	*(*uint32)(unsafe.Pointer(&result.data)) = v
+	return result
+}
+
+// NewPropVariantFiletime creates a new VT_FILETIME PropVariant containing ft.
+func NewPropVariantFiletime(ft windows.Filetime) (result PropVariant) {
+	result.vt = VT_FILETIME
+	*(*windows.Filetime)(unsafe.Pointer(&result.data)) = ft
+	return result
+}
+
+// NewPropVariantBool creates a new VT_BOOL PropVariant containing b.
+func NewPropVariantBool(b bool) (result PropVariant) {
+	result.vt = VT_BOOL
+	v := variantFalse
+	if b {
+		v = variantTrue
+	}
+	*(*int16)(unsafe.Pointer(&result.data)) = v
+	return result
+}
+
+func (pv *PropVariant) calpwstr() *calpwstr {
+	return (*calpwstr)(unsafe.Pointer(&pv.data))
+}
+
+// Type returns the VARTYPE of the value held by pv.
+func (pv *PropVariant) Type() VARTYPE {
+	return pv.vt
+}
+
+// IsEmpty returns true when pv does not hold a value.
+func (pv *PropVariant) IsEmpty() bool {
+	return pv.vt == VT_EMPTY
+}
+
+// AsString returns a copy of the string held by pv. It returns false if pv
+// is not a VT_LPWSTR.
+func (pv *PropVariant) AsString() (string, bool) {
+	if pv.vt != VT_LPWSTR {
+		return "", false
+	}
+
+	return windows.UTF16PtrToString((*uint16)(unsafe.Pointer(pv.data[0]))), true
+}
+
+// AsStringVector returns a copy of the strings held by pv. It returns false
+// if pv is not a VT_VECTOR|VT_LPWSTR.
+func (pv *PropVariant) AsStringVector() ([]string, bool) {
+	if pv.vt != VT_VECTOR|VT_LPWSTR {
+		return nil, false
+	}
+
+	ca := pv.calpwstr()
+	if ca.count == 0 || ca.elems == nil {
+		return []string{}, true
+	}
+
+	elems := unsafe.Slice(ca.elems, ca.count)
+	result := make([]string, 0, len(elems))
+	for _, e := range elems {
+		result = append(result, windows.UTF16PtrToString((*uint16)(unsafe.Pointer(e))))
+	}
+
+	return result, true
+}
+
+// AsUint32 returns the value held by pv. It returns false if pv is not a VT_UI4.
+func (pv *PropVariant) AsUint32() (uint32, bool) {
+	if pv.vt != VT_UI4 {
+		return 0, false
+	}
+
+	return *(*uint32)(unsafe.Pointer(&pv.data)), true
+}
+
+// AsFiletime returns the value held by pv. It returns false if pv is not a
+// VT_FILETIME.
+func (pv *PropVariant) AsFiletime() (windows.Filetime, bool) {
+	if pv.vt != VT_FILETIME {
+		return windows.Filetime{}, false
+	}
+
+	return *(*windows.Filetime)(unsafe.Pointer(&pv.data)), true
+}
+
+// AsTime returns the value held by pv, converted to a time.Time. It returns
+// false if pv is not a VT_FILETIME.
+func (pv *PropVariant) AsTime() (time.Time, bool) {
+	ft, ok := pv.AsFiletime()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return wingoes.TimeFromFiletime(ft), true
+}
+
+// AsBool returns the value held by pv. It returns false (as its second result)
+// if pv is not a VT_BOOL.
+func (pv *PropVariant) AsBool() (bool, bool) {
+	if pv.vt != VT_BOOL {
+		return false, false
+	}
+
+	return *(*int16)(unsafe.Pointer(&pv.data)) != variantFalse, true
+}
+
+// Clear frees any memory referenced by pv and resets it to VT_EMPTY.
+func (pv *PropVariant) Clear() error {
+	hr := propVariantClear(pv)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/sys/windows"
+)
+
+func TestPropVariant(t *testing.T) {
+	const str = "Hello, PROPVARIANT"
+	pvs, err := NewPropVariantString(str)
+	if err != nil {
+		t.Fatalf("NewPropVariantString error: %v", err)
+	}
+	if got, ok := pvs.AsString(); !ok || got != str {
+		t.Errorf("AsString got (%q, %v), want (%q, true)", got, ok, str)
+	}
+	if _, ok := pvs.AsUint32(); ok {
+		t.Errorf("AsUint32 unexpectedly succeeded on VT_LPWSTR")
+	}
+	if err := pvs.Clear(); err != nil {
+		t.Errorf("Clear error: %v", err)
+	}
+	if !pvs.IsEmpty() {
+		t.Errorf("PropVariant not empty after Clear, type %d", pvs.Type())
+	}
+
+	strs := []string{"foo", "bar", "baz"}
+	pvv, err := NewPropVariantStringVector(strs)
+	if err != nil {
+		t.Fatalf("NewPropVariantStringVector error: %v", err)
+	}
+	if got, ok := pvv.AsStringVector(); !ok || !slices.Equal(got, strs) {
+		t.Errorf("AsStringVector got (%v, %v), want (%v, true)", got, ok, strs)
+	}
+	if err := pvv.Clear(); err != nil {
+		t.Errorf("Clear error: %v", err)
+	}
+
+	pvu := NewPropVariantUint32(42)
+	if got, ok := pvu.AsUint32(); !ok || got != 42 {
+		t.Errorf("AsUint32 got (%d, %v), want (42, true)", got, ok)
+	}
+
+	pvb := NewPropVariantBool(true)
+	if got, ok := pvb.AsBool(); !ok || !got {
+		t.Errorf("AsBool got (%v, %v), want (true, true)", got, ok)
+	}
+
+	ft := windows.Filetime{LowDateTime: 0x12345678, HighDateTime: 0x01D00000}
+	pvf := NewPropVariantFiletime(ft)
+	if got, ok := pvf.AsFiletime(); !ok || got != ft {
+		t.Errorf("AsFiletime got (%v, %v), want (%v, true)", got, ok, ft)
+	}
+}
@@ -0,0 +1,453 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	IID_IStorage     = &IID{0x0000000B, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	IID_IEnumSTATSTG = &IID{0x0000000D, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+)
+
+type IStorageABI struct {
+	IUnknownABI
+}
+
+type IEnumSTATSTGABI struct {
+	IUnknownABI
+}
+
+// Storage wraps an IStorage, which is a structured storage object containing
+// a hierarchy of named storages and streams. Compound files (such as .msg files
+// and legacy Office documents) are accessed via Storage.
+type Storage struct {
+	GenericObject[IStorageABI]
+}
+
+// EnumSTATSTG wraps an IEnumSTATSTG, which enumerates the elements of a
+// Storage.
+type EnumSTATSTG struct {
+	GenericObject[IEnumSTATSTGABI]
+}
+
+func (abi *IStorageABI) CreateStream(name string, mode STGM) (*IUnknownABI, error) {
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 18)[3]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(name16)),
+		uintptr(mode),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (abi *IStorageABI) OpenStream(name string, mode STGM) (*IUnknownABI, error) {
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 18)[4]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(name16)),
+		0,
+		uintptr(mode),
+		0,
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (abi *IStorageABI) CreateStorage(name string, mode STGM) (*IUnknownABI, error) {
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 18)[5]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(name16)),
+		uintptr(mode),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (abi *IStorageABI) OpenStorage(name string, mode STGM) (*IUnknownABI, error) {
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 18)[6]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(name16)),
+		0,
+		uintptr(mode),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (abi *IStorageABI) Commit(flags STGC) error {
+	method := unsafe.Slice(abi.Vtbl, 18)[9]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(flags),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IStorageABI) Revert() error {
+	method := unsafe.Slice(abi.Vtbl, 18)[10]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IStorageABI) EnumElements() (*IUnknownABI, error) {
+	var punk *IUnknownABI
+	method := unsafe.Slice(abi.Vtbl, 18)[11]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		0,
+		0,
+		0,
+		uintptr(unsafe.Pointer(&punk)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return punk, nil
+}
+
+func (abi *IStorageABI) DestroyElement(name string) error {
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	method := unsafe.Slice(abi.Vtbl, 18)[12]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(name16)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (abi *IStorageABI) Stat(flags STATFLAG) (*STATSTG, error) {
+	result := new(STATSTG)
+	method := unsafe.Slice(abi.Vtbl, 18)[17]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(unsafe.Pointer(result)),
+		uintptr(flags),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return nil, e
+	}
+
+	return result, nil
+}
+
+func (o Storage) IID() *IID {
+	return IID_IStorage
+}
+
+func (o Storage) Make(r ABIReceiver) any {
+	if r == nil {
+		return Storage{}
+	}
+
+	runtime.SetFinalizer(r, ReleaseABI)
+
+	pp := (**IStorageABI)(unsafe.Pointer(r))
+	return Storage{GenericObject[IStorageABI]{Pp: pp}}
+}
+
+// UnsafeUnwrap returns the underlying IStorageABI of the object. As the name
+// implies, this is unsafe -- you had better know what you are doing!
+func (o Storage) UnsafeUnwrap() *IStorageABI {
+	return *(o.Pp)
+}
+
+// CreateStream creates a new stream named name within o, using mode.
+// Note that COM requires mode to include STGM_SHARE_EXCLUSIVE.
+func (o Storage) CreateStream(name string, mode STGM) (result Stream, _ error) {
+	p := *(o.Pp)
+	punk, err := p.CreateStream(name, mode)
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(Stream), nil
+}
+
+// OpenStream opens the existing stream named name within o, using mode.
+// Note that COM requires mode to include STGM_SHARE_EXCLUSIVE.
+func (o Storage) OpenStream(name string, mode STGM) (result Stream, _ error) {
+	p := *(o.Pp)
+	punk, err := p.OpenStream(name, mode)
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(Stream), nil
+}
+
+// CreateStorage creates a new child storage named name within o, using mode.
+// Note that COM requires mode to include STGM_SHARE_EXCLUSIVE.
+func (o Storage) CreateStorage(name string, mode STGM) (result Storage, _ error) {
+	p := *(o.Pp)
+	punk, err := p.CreateStorage(name, mode)
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(Storage), nil
+}
+
+// OpenStorage opens the existing child storage named name within o, using
+// mode. Note that COM requires mode to include STGM_SHARE_EXCLUSIVE.
+func (o Storage) OpenStorage(name string, mode STGM) (result Storage, _ error) {
+	p := *(o.Pp)
+	punk, err := p.OpenStorage(name, mode)
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(Storage), nil
+}
+
+func (o Storage) Commit(flags STGC) error {
+	p := *(o.Pp)
+	return p.Commit(flags)
+}
+
+func (o Storage) Revert() error {
+	p := *(o.Pp)
+	return p.Revert()
+}
+
+// EnumElements returns an enumerator over the storages and streams that are
+// direct children of o.
+func (o Storage) EnumElements() (result EnumSTATSTG, _ error) {
+	p := *(o.Pp)
+	punk, err := p.EnumElements()
+	if err != nil {
+		return result, err
+	}
+
+	return result.Make(&punk).(EnumSTATSTG), nil
+}
+
+// Elements returns information about each of the storages and streams that are
+// direct children of o. The caller must Close each element of the result.
+func (o Storage) Elements() ([]STATSTG, error) {
+	enum, err := o.EnumElements()
+	if err != nil {
+		return nil, err
+	}
+
+	return EnumToSlice(enum.Next)
+}
+
+func (o Storage) DestroyElement(name string) error {
+	p := *(o.Pp)
+	return p.DestroyElement(name)
+}
+
+func (o Storage) Stat(flags STATFLAG) (*STATSTG, error) {
+	p := *(o.Pp)
+	return p.Stat(flags)
+}
+
+func (abi *IEnumSTATSTGABI) Next(batch []STATSTG) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var fetched uint32
+	method := unsafe.Slice(abi.Vtbl, 7)[3]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+		uintptr(uint32(len(batch))),
+		uintptr(unsafe.Pointer(unsafe.SliceData(batch))),
+		uintptr(unsafe.Pointer(&fetched)),
+	)
+	n := int(fetched)
+	e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc))
+	if e.Failed() {
+		return n, e
+	}
+	if e.AsHRESULT() == wingoes.S_FALSE {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (abi *IEnumSTATSTGABI) Reset() error {
+	method := unsafe.Slice(abi.Vtbl, 7)[5]
+
+	rc, _, _ := syscall.SyscallN(
+		method,
+		uintptr(unsafe.Pointer(abi)),
+	)
+	if e := wingoes.ErrorFromHRESULT(wingoes.HRESULT(rc)); e.Failed() {
+		return e
+	}
+
+	return nil
+}
+
+func (o EnumSTATSTG) IID() *IID {
+	return IID_IEnumSTATSTG
+}
+
+func (o EnumSTATSTG) Make(r ABIReceiver) any {
+	if r == nil {
+		return EnumSTATSTG{}
+	}
+
+	runtime.SetFinalizer(r, ReleaseABI)
+
+	pp := (**IEnumSTATSTGABI)(unsafe.Pointer(r))
+	return EnumSTATSTG{GenericObject[IEnumSTATSTGABI]{Pp: pp}}
+}
+
+// UnsafeUnwrap returns the underlying IEnumSTATSTGABI of the object. As the
+// name implies, this is unsafe -- you had better know what you are doing!
+func (o EnumSTATSTG) UnsafeUnwrap() *IEnumSTATSTGABI {
+	return *(o.Pp)
+}
+
+// Next fetches up to len(batch) elements into batch, returning the number of
+// elements that were fetched. The caller must Close each of those elements. It
+// returns io.EOF once the enumeration has been exhausted, possibly alongside a
+// non-zero count.
+func (o EnumSTATSTG) Next(batch []STATSTG) (int, error) {
+	p := *(o.Pp)
+	return p.Next(batch)
+}
+
+// Reset rewinds the enumeration to its beginning.
+func (o EnumSTATSTG) Reset() error {
+	p := *(o.Pp)
+	return p.Reset()
+}
+
+// StgOpenStorage opens the existing compound file located at path, using mode.
+// Note that, unless mode includes STGM_TRANSACTED, COM requires mode to include
+// either STGM_SHARE_EXCLUSIVE or (when read-only) STGM_SHARE_DENY_WRITE.
+func StgOpenStorage(path string, mode STGM) (result Storage, _ error) {
+	path16, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return result, err
+	}
+
+	ppstg := NewABIReceiver()
+	hr := stgOpenStorage(path16, nil, mode, 0, 0, ppstg)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return result, e
+	}
+
+	return result.Make(ppstg).(Storage), nil
+}
+
+// StgCreateDocfile creates a new compound file located at path, using mode.
+// Note that COM requires mode to include STGM_SHARE_EXCLUSIVE unless it also
+// includes STGM_TRANSACTED.
+func StgCreateDocfile(path string, mode STGM) (result Storage, _ error) {
+	path16, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return result, err
+	}
+
+	ppstg := NewABIReceiver()
+	hr := stgCreateDocfile(path16, mode, 0, ppstg)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		return result, e
+	}
+
+	return result.Make(ppstg).(Storage), nil
+}
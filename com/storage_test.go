@@ -0,0 +1,150 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	// We cannot use t.TempDir because the storage remains open until its
+	// finalizer runs, which would cause the directory's cleanup to fail.
+	dir, err := os.MkdirTemp("", "wingoes-storage")
+	if err != nil {
+		t.Fatalf("MkdirTemp error: %v", err)
+	}
+	toRemove = append(toRemove, dir)
+
+	const mode = STGM_READWRITE | STGM_SHARE_EXCLUSIVE
+	stg, err := StgCreateDocfile(filepath.Join(dir, "test.stg"), mode|STGM_CREATE)
+	if err != nil {
+		t.Fatalf("StgCreateDocfile error: %v", err)
+	}
+
+	const contents = "Hello, structured storage!"
+	stream, err := stg.CreateStream("Contents", mode)
+	if err != nil {
+		t.Fatalf("CreateStream error: %v", err)
+	}
+	if _, err := io.WriteString(stream, contents); err != nil {
+		t.Fatalf("WriteString error: %v", err)
+	}
+
+	if _, err := stg.CreateStorage("Child", mode); err != nil {
+		t.Fatalf("CreateStorage error: %v", err)
+	}
+
+	if err := stg.Commit(STGC_DEFAULT); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	elems, err := stg.Elements()
+	if err != nil {
+		t.Fatalf("Elements error: %v", err)
+	}
+
+	got := map[string]STGTY{}
+	for i := range elems {
+		got[elems[i].Name.String()] = elems[i].Type
+		elems[i].Close()
+	}
+	want := map[string]STGTY{"Contents": STGTY_STREAM, "Child": STGTY_STORAGE}
+	if len(got) != len(want) {
+		t.Errorf("Elements got %v, want %v", got, want)
+	}
+	for name, typ := range want {
+		if got[name] != typ {
+			t.Errorf("Elements got type %d for %q, want %d", got[name], name, typ)
+		}
+	}
+
+	if _, err := stream.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek error: %v", err)
+	}
+	b, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll error: %v", err)
+	}
+	if string(b) != contents {
+		t.Errorf("stream contents got %q, want %q", b, contents)
+	}
+
+	if _, err := stg.OpenStream("Nonexistent", STGM_READ|STGM_SHARE_EXCLUSIVE); err == nil {
+		t.Errorf("OpenStream unexpectedly succeeded for a nonexistent stream")
+	}
+
+	if _, err := StgOpenStorage(filepath.Join(dir, "nonexistent.stg"), STGM_READ|STGM_SHARE_DENY_WRITE); err == nil {
+		t.Errorf("StgOpenStorage unexpectedly succeeded for a nonexistent file")
+	}
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "roundtrip.stg")
+
+	const mode = STGM_READWRITE | STGM_SHARE_EXCLUSIVE
+	const contents = "Hello from a child storage!"
+	func() {
+		stg, err := StgCreateDocfile(fname, mode|STGM_CREATE)
+		if err != nil {
+			t.Fatalf("StgCreateDocfile error: %v", err)
+		}
+		defer releaseTestObject(stg.GenericObject)
+
+		child, err := stg.CreateStorage("Child", mode)
+		if err != nil {
+			t.Fatalf("CreateStorage error: %v", err)
+		}
+		defer releaseTestObject(child.GenericObject)
+
+		stream, err := child.CreateStream("Data", mode)
+		if err != nil {
+			t.Fatalf("CreateStream error: %v", err)
+		}
+		defer releaseTestObject(stream.GenericObject)
+
+		if _, err := io.WriteString(stream, contents); err != nil {
+			t.Fatalf("WriteString error: %v", err)
+		}
+		if err := child.Commit(STGC_DEFAULT); err != nil {
+			t.Fatalf("Commit (child) error: %v", err)
+		}
+		if err := stg.Commit(STGC_DEFAULT); err != nil {
+			t.Fatalf("Commit error: %v", err)
+		}
+	}()
+
+	// Every reference to the docfile has now been released, so reopening it
+	// reads back what was written to disk.
+	const readMode = STGM_READ | STGM_SHARE_EXCLUSIVE
+	stg, err := StgOpenStorage(fname, readMode)
+	if err != nil {
+		t.Fatalf("StgOpenStorage error: %v", err)
+	}
+	defer releaseTestObject(stg.GenericObject)
+
+	child, err := stg.OpenStorage("Child", readMode)
+	if err != nil {
+		t.Fatalf("OpenStorage error: %v", err)
+	}
+	defer releaseTestObject(child.GenericObject)
+
+	stream, err := child.OpenStream("Data", readMode)
+	if err != nil {
+		t.Fatalf("OpenStream error: %v", err)
+	}
+	defer releaseTestObject(stream.GenericObject)
+
+	b, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll error: %v", err)
+	}
+	if string(b) != contents {
+		t.Errorf("stream contents got %q, want %q", b, contents)
+	}
+}
@@ -7,9 +7,12 @@
 package com
 
 import (
+	"context"
+	"errors"
 	"io"
 	"runtime"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/dblohm7/wingoes"
@@ -22,6 +25,21 @@ var (
 	IID_IStream           = &IID{0x0000000C, 0x0000, 0x0000, [8]byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
 )
 
+type STGM uint32
+
+const (
+	STGM_READ             = STGM(0x00000000)
+	STGM_WRITE            = STGM(0x00000001)
+	STGM_READWRITE        = STGM(0x00000002)
+	STGM_SHARE_DENY_NONE  = STGM(0x00000040)
+	STGM_SHARE_DENY_READ  = STGM(0x00000030)
+	STGM_SHARE_DENY_WRITE = STGM(0x00000020)
+	STGM_SHARE_EXCLUSIVE  = STGM(0x00000010)
+	STGM_CREATE           = STGM(0x00001000)
+	STGM_TRANSACTED       = STGM(0x00010000)
+	STGM_DELETEONRELEASE  = STGM(0x04000000)
+)
+
 type STGC uint32
 
 const (
@@ -75,6 +93,21 @@ func (st *STATSTG) Close() error {
 	return st.Name.Close()
 }
 
+// ModifiedTime returns st's MTime as a time.Time.
+func (st *STATSTG) ModifiedTime() time.Time {
+	return wingoes.TimeFromFiletime(st.MTime)
+}
+
+// CreationTime returns st's CTime as a time.Time.
+func (st *STATSTG) CreationTime() time.Time {
+	return wingoes.TimeFromFiletime(st.CTime)
+}
+
+// AccessTime returns st's ATime as a time.Time.
+func (st *STATSTG) AccessTime() time.Time {
+	return wingoes.TimeFromFiletime(st.ATime)
+}
+
 type ISequentialStreamABI struct {
 	IUnknownABI
 }
@@ -83,15 +116,25 @@ type IStreamABI struct {
 	ISequentialStreamABI
 }
 
+// SequentialStream wraps an ISequentialStream, which supports sequential
+// reads and writes but not seeking. It implements io.Reader and io.Writer. Some
+// COM objects only expose ISequentialStream; use TryAs[SequentialStream] to
+// obtain one from any object, including a Stream.
 type SequentialStream struct {
 	GenericObject[ISequentialStreamABI]
 }
 
+// Stream wraps an IStream. It implements io.Reader, io.Writer and io.Seeker.
 type Stream struct {
 	GenericObject[IStreamABI]
 }
 
 func (abi *ISequentialStreamABI) Read(p []byte) (int, error) {
+	// An empty p has no &p[0] to pass as the buffer (unsafe.SliceData would
+	// yield nil or a pointer to nothing), so we never make the call at all.
+	if len(p) == 0 {
+		return 0, nil
+	}
 	if len(p) > maxStreamRWLen {
 		p = p[:maxStreamRWLen]
 	}
@@ -122,6 +165,11 @@ func (abi *ISequentialStreamABI) Read(p []byte) (int, error) {
 }
 
 func (abi *ISequentialStreamABI) Write(p []byte) (int, error) {
+	// As in Read, avoid taking &p[0] on an empty p.
+	if len(p) == 0 {
+		return 0, nil
+	}
+
 	w := p
 	if len(w) > maxStreamRWLen {
 		w = w[:maxStreamRWLen]
@@ -272,7 +320,24 @@ func (o Stream) Write(buf []byte) (int, error) {
 
 func (o Stream) Seek(offset int64, whence int) (n int64, _ error) {
 	p := *(o.Pp)
-	return p.Seek(offset, whence)
+	if whence != io.SeekEnd {
+		return p.Seek(offset, whence)
+	}
+
+	// IStream implementations disagree about seeking relative to the end of the
+	// stream (in particular, the legacy HGLOBAL-backed memory stream differs
+	// from SHCreateMemStream), so we resolve the absolute position ourselves.
+	size, err := o.Size()
+	if err != nil {
+		return 0, err
+	}
+
+	pos := int64(size) + offset
+	if pos < 0 {
+		return 0, wingoes.ErrorFromHRESULT(hrSTG_E_INVALIDFUNCTION)
+	}
+
+	return p.Seek(pos, io.SeekStart)
 }
 
 func (o Stream) SetSize(newSize uint64) error {
@@ -285,6 +350,36 @@ func (o Stream) CopyTo(dest Stream, numBytesToCopy uint64) (bytesRead, bytesWrit
 	return p.CopyTo(dest.UnsafeUnwrap(), numBytesToCopy)
 }
 
+// copyToContextChunkSize is the maximum number of bytes that CopyToContext
+// requests from each call to CopyTo.
+const copyToContextChunkSize = 1 << 20
+
+// CopyToContext is like CopyTo, but copies in chunks of bounded size so that
+// it may check ctx for cancellation between chunks. If ctx is cancelled, it
+// returns the number of bytes read and written thus far, along with ctx.Err().
+func (o Stream) CopyToContext(ctx context.Context, dest Stream, numBytesToCopy uint64) (bytesRead, bytesWritten uint64, _ error) {
+	for bytesRead < numBytesToCopy {
+		if err := ctx.Err(); err != nil {
+			return bytesRead, bytesWritten, err
+		}
+
+		chunk := min(numBytesToCopy-bytesRead, copyToContextChunkSize)
+		nRead, nWritten, err := o.CopyTo(dest, chunk)
+		bytesRead += nRead
+		bytesWritten += nWritten
+		if err != nil {
+			return bytesRead, bytesWritten, err
+		}
+
+		// A short read means that we have reached the end of o.
+		if nRead < chunk {
+			break
+		}
+	}
+
+	return bytesRead, bytesWritten, nil
+}
+
 func (o Stream) Commit(flags STGC) error {
 	p := *(o.Pp)
 	return p.Commit(flags)
@@ -305,11 +400,65 @@ func (o Stream) UnlockRegion(offset, numBytes uint64, lockType LOCKTYPE) error {
 	return p.UnlockRegion(offset, numBytes, lockType)
 }
 
+const hrSTG_E_INVALIDFUNCTION = wingoes.HRESULT(-((0x80030001 ^ 0xFFFFFFFF) + 1))
+
+// ErrLockingNotSupported is the error that many Stream implementations return
+// from LockRegion (and thus Lock) when they do not support region locking.
+var ErrLockingNotSupported = wingoes.ErrorFromHRESULT(hrSTG_E_INVALIDFUNCTION)
+
+// Lock locks numBytes bytes of the stream beginning at offset, using lockType.
+// Upon success, it returns a function that unlocks the same region, suitable
+// for deferring. Errors from the underlying LockRegion are returned unmodified;
+// in particular, use errors.Is with ErrLockingNotSupported to detect streams
+// that do not support locking.
+func (o Stream) Lock(offset, numBytes uint64, lockType LOCKTYPE) (release func() error, _ error) {
+	if err := o.LockRegion(offset, numBytes, lockType); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return o.UnlockRegion(offset, numBytes, lockType)
+	}, nil
+}
+
 func (o Stream) Stat(flags STATFLAG) (*STATSTG, error) {
 	p := *(o.Pp)
 	return p.Stat(flags)
 }
 
+// Size returns the current size of the stream, in bytes.
+func (o Stream) Size() (uint64, error) {
+	statstg, err := o.Stat(STATFLAG_NONAME)
+	if err != nil {
+		return 0, err
+	}
+	// STATFLAG_NONAME should prevent Name from being allocated, but we close it
+	// anyway in case the implementation ignores that flag.
+	defer statstg.Close()
+
+	return statstg.Size, nil
+}
+
+// WithPreservedPosition records o's current seek pointer, calls fn, and then
+// restores the seek pointer to its recorded position, even when fn fails. This
+// permits fn to freely seek within o without disturbing the caller's position.
+// If fn fails, its error is returned; otherwise any error from restoring the
+// seek pointer is returned.
+func (o Stream) WithPreservedPosition(fn func() error) (err error) {
+	pos, err := o.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, seekErr := o.Seek(pos, io.SeekStart); seekErr != nil && err == nil {
+			err = seekErr
+		}
+	}()
+
+	return fn()
+}
+
 func (o Stream) Clone() (result Stream, _ error) {
 	p := *(o.Pp)
 	punk, err := p.Clone()
@@ -320,6 +469,30 @@ func (o Stream) Clone() (result Stream, _ error) {
 	return result.Make(&punk).(Stream), nil
 }
 
+const hrE_INVALIDARG = wingoes.HRESULT(-((0x80070057 ^ 0xFFFFFFFF) + 1))
+
+// ErrNotHGlobalStream is returned by GetHGlobal when the stream is not backed
+// by an HGLOBAL.
+var ErrNotHGlobalStream = errors.New("stream is not backed by an HGLOBAL")
+
+// GetHGlobal returns the global memory handle that backs o, for use with Win32
+// APIs that consume HGLOBALs directly. It only succeeds for streams that were
+// created over an HGLOBAL (such as those created by the legacy implementation
+// of NewMemoryStream); it returns ErrNotHGlobalStream otherwise. The handle
+// remains owned by o, and is only valid for as long as o is alive.
+func (o Stream) GetHGlobal() (internal.HGLOBAL, error) {
+	var hglobal internal.HGLOBAL
+	hr := getHGlobalFromStream((*IUnknownABI)(unsafe.Pointer(o.UnsafeUnwrap())), &hglobal)
+	if e := wingoes.ErrorFromHRESULT(hr); e.Failed() {
+		if hr == hrE_INVALIDARG {
+			return 0, ErrNotHGlobalStream
+		}
+		return 0, e
+	}
+
+	return hglobal, nil
+}
+
 const hrE_OUTOFMEMORY = wingoes.HRESULT(-((0x8007000E ^ 0xFFFFFFFF) + 1))
 
 // NewMemoryStream creates a new in-memory Stream object initially containing a
@@ -329,6 +502,26 @@ func NewMemoryStream(initialBytes []byte) (result Stream, _ error) {
 	return newMemoryStreamInternal(initialBytes, false)
 }
 
+// MemoryStreamOptions specifies optional behavior for
+// NewMemoryStreamWithOptions.
+type MemoryStreamOptions struct {
+	// NoLegacyFallback prevents the use of a legacy, HGLOBAL-backed stream
+	// implementation on versions of Windows prior to Windows 8. When set on
+	// those versions, NewMemoryStreamWithOptions returns ErrUnsupportedOSVersion.
+	NoLegacyFallback bool
+}
+
+// NewMemoryStreamWithOptions creates a new in-memory Stream object initially
+// containing a copy of initialBytes, configured as specified by opts. It
+// otherwise behaves identically to NewMemoryStream.
+func NewMemoryStreamWithOptions(initialBytes []byte, opts MemoryStreamOptions) (result Stream, _ error) {
+	if opts.NoLegacyFallback && !wingoes.IsWin8OrGreater() {
+		return result, ErrUnsupportedOSVersion
+	}
+
+	return newMemoryStreamInternal(initialBytes, false)
+}
+
 func newMemoryStreamInternal(initialBytes []byte, forceLegacy bool) (result Stream, _ error) {
 	if len(initialBytes) > maxStreamRWLen {
 		return result, wingoes.ErrorFromHRESULT(hrE_OUTOFMEMORY)
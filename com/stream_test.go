@@ -7,10 +7,13 @@
 package com
 
 import (
+	"context"
+	"errors"
 	"io"
 	"runtime"
 	"testing"
 
+	"github.com/dblohm7/wingoes"
 	"golang.org/x/exp/slices"
 )
 
@@ -19,14 +22,109 @@ func TestStream(t *testing.T) {
 	t.Run("Legacy", func(t *testing.T) { memoryStream(t, true) })
 }
 
+func TestStreamSeekEnd(t *testing.T) {
+	t.Run("Default", func(t *testing.T) { memoryStreamSeekEnd(t, false) })
+	t.Run("Legacy", func(t *testing.T) { memoryStreamSeekEnd(t, true) })
+}
+
+func memoryStreamSeekEnd(t *testing.T, useLegacy bool) {
+	values := makeTestBuf(16)
+	stream, err := newMemoryStreamInternal(values, useLegacy)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
+	}
+
+	for n := 0; n <= len(values); n++ {
+		pos, err := stream.Seek(int64(-n), io.SeekEnd)
+		if err != nil {
+			t.Fatalf("Error calling Seek(%d, io.SeekEnd): %v", -n, err)
+		}
+		if want := int64(len(values) - n); pos != want {
+			t.Errorf("Unexpected seek pos from Seek(%d, io.SeekEnd), got %d, want %d", -n, pos, want)
+		}
+
+		got, err := io.ReadAll(stream)
+		if err != nil {
+			t.Fatalf("Error calling ReadAll: %v", err)
+		}
+		if !slices.Equal(got, values[len(values)-n:]) {
+			t.Errorf("Unexpected contents after Seek(%d, io.SeekEnd), got %v, want %v", -n, got, values[len(values)-n:])
+		}
+	}
+
+	// Seeking past the end is permitted.
+	pos, err := stream.Seek(2, io.SeekEnd)
+	if err != nil {
+		t.Fatalf("Error calling Seek(2, io.SeekEnd): %v", err)
+	}
+	if want := int64(len(values) + 2); pos != want {
+		t.Errorf("Unexpected seek pos from Seek(2, io.SeekEnd), got %d, want %d", pos, want)
+	}
+
+	// Seeking before the start is not.
+	if _, err := stream.Seek(int64(-len(values)-1), io.SeekEnd); err == nil {
+		t.Errorf("Unexpected success seeking before the start of the stream")
+	}
+}
+
+func TestStreamGetHGlobal(t *testing.T) {
+	values := makeTestBuf(16)
+	legacy, err := newMemoryStreamInternal(values, true)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
+	}
+
+	hglobal, err := legacy.GetHGlobal()
+	if err != nil {
+		t.Fatalf("Error calling GetHGlobal: %v", err)
+	}
+	if hglobal == 0 {
+		t.Errorf("GetHGlobal returned a NULL HGLOBAL")
+	}
+
+	if !wingoes.IsWin8OrGreater() {
+		return
+	}
+
+	modern, err := newMemoryStreamInternal(values, false)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
+	}
+	if _, err := modern.GetHGlobal(); err != ErrNotHGlobalStream {
+		t.Errorf("Unexpected error calling GetHGlobal, got %v, want %v", err, ErrNotHGlobalStream)
+	}
+}
+
+func TestMemoryStreamNoLegacyFallback(t *testing.T) {
+	values := makeTestBuf(16)
+	stream, err := NewMemoryStreamWithOptions(values, MemoryStreamOptions{NoLegacyFallback: true})
+	if !wingoes.IsWin8OrGreater() {
+		if !errors.Is(err, ErrUnsupportedOSVersion) {
+			t.Errorf("NewMemoryStreamWithOptions got error %v, want %v", err, ErrUnsupportedOSVersion)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("NewMemoryStreamWithOptions: %v", err)
+	}
+
+	size, err := stream.Size()
+	if err != nil {
+		t.Fatalf("Error calling Size: %v", err)
+	}
+	if size != uint64(len(values)) {
+		t.Errorf("Unexpected size, got %d, want %d", size, len(values))
+	}
+}
+
 func memoryStream(t *testing.T, useLegacy bool) {
 	empty1, err := newMemoryStreamInternal(nil, useLegacy)
 	if err != nil {
 		t.Fatalf("Error calling NewMemoryStream(nil): %v", err)
 	}
-	size, err := getSize(empty1)
+	size, err := empty1.Size()
 	if err != nil {
-		t.Fatalf("Error calling getSize: %v", err)
+		t.Fatalf("Error calling Size: %v", err)
 	}
 	if size != 0 {
 		t.Errorf("Unexpected size, got %d, want 0", size)
@@ -36,9 +134,9 @@ func memoryStream(t *testing.T, useLegacy bool) {
 	if err != nil {
 		t.Fatalf("Error calling NewMemoryStream(nil): %v", err)
 	}
-	size, err = getSize(empty2)
+	size, err = empty2.Size()
 	if err != nil {
-		t.Fatalf("Error calling getSize: %v", err)
+		t.Fatalf("Error calling Size: %v", err)
 	}
 	if size != 0 {
 		t.Errorf("Unexpected size, got %d, want 0", size)
@@ -59,9 +157,9 @@ func memoryStream(t *testing.T, useLegacy bool) {
 	if err != nil {
 		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
 	}
-	size, err = getSize(stream)
+	size, err = stream.Size()
 	if err != nil {
-		t.Fatalf("Error calling getSize: %v", err)
+		t.Fatalf("Error calling Size: %v", err)
 	}
 	if size != uint64(len(values)) {
 		t.Errorf("Unexpected size, got %d, want %d", size, len(values))
@@ -74,6 +172,25 @@ func memoryStream(t *testing.T, useLegacy bool) {
 		t.Errorf("Unexpected seek pos, got %d, want 0", pos)
 	}
 
+	// Empty buffers must be no-ops.
+	for _, buf := range [][]byte{nil, {}} {
+		nRead, err := stream.Read(buf)
+		if nRead != 0 || err != nil {
+			t.Errorf("Unexpected result calling Read with empty buffer, got (%d, %v), want (0, nil)", nRead, err)
+		}
+		nWritten, err := stream.Write(buf)
+		if nWritten != 0 || err != nil {
+			t.Errorf("Unexpected result calling Write with empty buffer, got (%d, %v), want (0, nil)", nWritten, err)
+		}
+	}
+	pos, err = getSeekPos(stream)
+	if err != nil {
+		t.Fatalf("Error calling getSeekPos: %v", err)
+	}
+	if pos != 0 {
+		t.Errorf("Unexpected seek pos after empty Read/Write, got %d, want 0", pos)
+	}
+
 	readBuf := make([]byte, len(values))
 	nRead, err := stream.Read(readBuf)
 	if err != nil {
@@ -227,13 +344,145 @@ func memoryStream(t *testing.T, useLegacy bool) {
 	}
 }
 
-func getSize(stream Stream) (uint64, error) {
-	statstg, err := stream.Stat(STATFLAG_NONAME)
+func TestSequentialStream(t *testing.T) {
+	values := makeTestBuf(16)
+	stream, err := NewMemoryStream(values)
 	if err != nil {
-		return 0, err
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
 	}
 
-	return statstg.Size, nil
+	seqStream, err := TryAs[SequentialStream](stream)
+	if err != nil {
+		t.Fatalf("TryAs[SequentialStream] error: %v", err)
+	}
+
+	var r io.Reader = seqStream
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Error reading SequentialStream: %v", err)
+	}
+	if !slices.Equal(values, got) {
+		t.Errorf("Slices not equal")
+	}
+
+	var w io.Writer = seqStream
+	nWritten, err := w.Write(values)
+	if err != nil {
+		t.Fatalf("Error writing SequentialStream: %v", err)
+	}
+	if nWritten != len(values) {
+		t.Errorf("Unexpected number of bytes written, got %d, want %d", nWritten, len(values))
+	}
+
+	// Writes through seqStream must be visible through stream.
+	size, err := stream.Size()
+	if err != nil {
+		t.Fatalf("Error calling Size: %v", err)
+	}
+	if size != uint64(len(values)*2) {
+		t.Errorf("Unexpected size, got %d, want %d", size, len(values)*2)
+	}
+}
+
+func TestStreamLock(t *testing.T) {
+	stream, err := NewMemoryStream(makeTestBuf(16))
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream: %v", err)
+	}
+
+	release, err := stream.Lock(4, 8, LOCK_EXCLUSIVE)
+	if errors.Is(err, ErrLockingNotSupported) {
+		t.Skipf("memory stream does not support locking")
+	}
+	if err != nil {
+		t.Fatalf("Error calling Lock: %v", err)
+	}
+	if err := release(); err != nil {
+		t.Errorf("Error releasing lock: %v", err)
+	}
+}
+
+func TestStreamCopyToContext(t *testing.T) {
+	values := make([]byte, copyToContextChunkSize*2+16)
+	for i := range values {
+		values[i] = byte(i)
+	}
+
+	src, err := NewMemoryStream(values)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
+	}
+	dest, err := NewMemoryStream(nil)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(nil): %v", err)
+	}
+
+	// Requesting more than is available must stop at the end of src.
+	nRead, nWritten, err := src.CopyToContext(context.Background(), dest, uint64(len(values))*2)
+	if err != nil {
+		t.Fatalf("Error calling CopyToContext: %v", err)
+	}
+	if nRead != uint64(len(values)) || nWritten != uint64(len(values)) {
+		t.Errorf("Unexpected byte counts, got (%d, %d), want (%d, %d)", nRead, nWritten, len(values), len(values))
+	}
+
+	if _, err := dest.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Error calling Seek: %v", err)
+	}
+	got, err := io.ReadAll(dest)
+	if err != nil {
+		t.Fatalf("Error reading dest: %v", err)
+	}
+	if !slices.Equal(values, got) {
+		t.Errorf("Slices not equal")
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Error calling Seek: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nRead, nWritten, err = src.CopyToContext(ctx, dest, uint64(len(values)))
+	if err != context.Canceled {
+		t.Errorf("Unexpected error calling CopyToContext, got %v, want %v", err, context.Canceled)
+	}
+	if nRead != 0 || nWritten != 0 {
+		t.Errorf("Unexpected byte counts, got (%d, %d), want (0, 0)", nRead, nWritten)
+	}
+}
+
+func TestStreamWithPreservedPosition(t *testing.T) {
+	values := makeTestBuf(16)
+	stream, err := NewMemoryStream(values)
+	if err != nil {
+		t.Fatalf("Error calling NewMemoryStream(%d): %v", len(values), err)
+	}
+
+	const startPos = 5
+	if _, err := stream.Seek(startPos, io.SeekStart); err != nil {
+		t.Fatalf("Error calling Seek: %v", err)
+	}
+
+	errTest := errors.New("test error")
+	for _, wantErr := range []error{nil, errTest} {
+		err := stream.WithPreservedPosition(func() error {
+			if _, err := stream.Seek(0, io.SeekEnd); err != nil {
+				return err
+			}
+			return wantErr
+		})
+		if err != wantErr {
+			t.Errorf("Unexpected error from WithPreservedPosition, got %v, want %v", err, wantErr)
+		}
+
+		pos, err := getSeekPos(stream)
+		if err != nil {
+			t.Fatalf("Error calling getSeekPos: %v", err)
+		}
+		if pos != startPos {
+			t.Errorf("Unexpected seek pos after WithPreservedPosition, got %d, want %d", pos, startPos)
+		}
+	}
 }
 
 func getSeekPos(stream Stream) (int64, error) {
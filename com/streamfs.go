@@ -0,0 +1,137 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"io"
+	"io/fs"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// streamFile adapts a Stream to fs.File. It holds its own reference to the
+// underlying IStream so that Close may release it deterministically.
+type streamFile struct {
+	r       ABIReceiver
+	stream  Stream
+	name    string
+	modTime time.Time
+}
+
+// streamFileInfo implements fs.FileInfo for a streamFile.
+type streamFileInfo struct {
+	name    string
+	statstg STATSTG
+	modTime time.Time
+}
+
+// StreamFS wraps s in an fs.File named name, allowing s to be consumed by code
+// that uses the io/fs package, such as http.FS. The returned fs.File also
+// implements io.Seeker. Its Stat method derives its result from s's STATSTG;
+// when modTime is the zero time.Time, the modification time is also taken from
+// the STATSTG.
+//
+// The fs.File holds its own reference to s, which is released by its Close
+// method. Closing the fs.File does not affect s itself. Note that both share
+// the same underlying IStream, and therefore the same seek pointer.
+func StreamFS(s Stream, name string, modTime time.Time) fs.File {
+	punk := (*IUnknownABI)(unsafe.Pointer(s.UnsafeUnwrap()))
+	punk.AddRef()
+
+	r := NewABIReceiver()
+	*r = punk
+
+	return &streamFile{
+		r:       r,
+		stream:  Stream{}.Make(r).(Stream),
+		name:    name,
+		modTime: modTime,
+	}
+}
+
+func (f *streamFile) Stat() (fs.FileInfo, error) {
+	if f.r == nil {
+		return nil, fs.ErrClosed
+	}
+
+	statstg, err := f.stream.Stat(STATFLAG_NONAME)
+	if err != nil {
+		return nil, err
+	}
+	// STATFLAG_NONAME should prevent Name from being allocated, but we close it
+	// anyway in case the implementation ignores that flag.
+	statstg.Close()
+
+	result := &streamFileInfo{
+		name:    f.name,
+		statstg: *statstg,
+		modTime: f.modTime,
+	}
+	if result.modTime.IsZero() {
+		result.modTime = statstg.ModifiedTime()
+	}
+
+	return result, nil
+}
+
+func (f *streamFile) Read(b []byte) (int, error) {
+	if f.r == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.stream.Read(b)
+}
+
+func (f *streamFile) Seek(offset int64, whence int) (int64, error) {
+	if f.r == nil {
+		return 0, fs.ErrClosed
+	}
+	return f.stream.Seek(offset, whence)
+}
+
+func (f *streamFile) Close() error {
+	if f.r == nil {
+		return fs.ErrClosed
+	}
+
+	// We release our reference now instead of waiting for the finalizer.
+	runtime.SetFinalizer(f.r, nil)
+	ReleaseABI(f.r)
+	f.r = nil
+	f.stream = Stream{}
+	return nil
+}
+
+var _ io.Seeker = (*streamFile)(nil)
+
+func (fi *streamFileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *streamFileInfo) Size() int64 {
+	return int64(fi.statstg.Size)
+}
+
+func (fi *streamFileInfo) Mode() fs.FileMode {
+	if STGM(fi.statstg.Mode)&(STGM_WRITE|STGM_READWRITE) != 0 {
+		return 0666
+	}
+	return 0444
+}
+
+func (fi *streamFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi *streamFileInfo) IsDir() bool {
+	return false
+}
+
+// Sys returns the STATSTG from which fi was derived. Its Name field is always
+// nil.
+func (fi *streamFileInfo) Sys() any {
+	return fi.statstg
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestStreamFS(t *testing.T) {
+	values := makeTestBuf(64)
+	stream, err := NewMemoryStream(values)
+	if err != nil {
+		t.Fatalf("NewMemoryStream: %v", err)
+	}
+
+	modTime := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	f := StreamFS(stream, "test.bin", modTime)
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Name() != "test.bin" {
+		t.Errorf("Name got %q, want %q", fi.Name(), "test.bin")
+	}
+	if fi.Size() != int64(len(values)) {
+		t.Errorf("Size got %d, want %d", fi.Size(), len(values))
+	}
+	if !fi.ModTime().Equal(modTime) {
+		t.Errorf("ModTime got %v, want %v", fi.ModTime(), modTime)
+	}
+	if fi.IsDir() || !fi.Mode().IsRegular() {
+		t.Errorf("unexpected Mode %v", fi.Mode())
+	}
+
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !slices.Equal(got, values) {
+		t.Errorf("ReadAll got %v, want %v", got, values)
+	}
+
+	seeker, ok := f.(io.Seeker)
+	if !ok {
+		t.Fatalf("fs.File does not implement io.Seeker")
+	}
+	if pos, err := seeker.Seek(0, io.SeekStart); err != nil || pos != 0 {
+		t.Errorf("Seek got (%d, %v), want (0, nil)", pos, err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Read after Close got error %v, want %v", err, fs.ErrClosed)
+	}
+	if err := f.Close(); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("second Close got error %v, want %v", err, fs.ErrClosed)
+	}
+
+	// The original stream must remain usable after closing the fs.File.
+	if size, err := stream.Size(); err != nil || size != uint64(len(values)) {
+		t.Errorf("Size of original stream got (%d, %v), want (%d, nil)", size, err, len(values))
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/sys/windows"
+)
+
+// TaskMemAlloc allocates size bytes from the COM task memory allocator. The
+// returned memory is not managed by the Go GC and must eventually be freed by
+// calling TaskMemFree, or by passing ownership to a COM API that will free it.
+func TaskMemAlloc(size uintptr) (unsafe.Pointer, error) {
+	p := coTaskMemAlloc(size)
+	if p == nil {
+		return nil, wingoes.ErrorFromHRESULT(hrE_OUTOFMEMORY)
+	}
+
+	return p, nil
+}
+
+// TaskMemFree frees p, which must have been allocated by the COM task memory
+// allocator. p may be nil.
+func TaskMemFree(p unsafe.Pointer) {
+	windows.CoTaskMemFree(p)
+}
+
+// TaskMemToSlice copies n elements of type T from p into a new Go-managed
+// slice. p typically references an array that was returned by a COM method;
+// it is not freed by this function.
+func TaskMemToSlice[T any](p unsafe.Pointer, n int) []T {
+	if p == nil || n <= 0 {
+		return nil
+	}
+
+	return append([]T{}, unsafe.Slice((*T)(p), n)...)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package com
+
+import (
+	"testing"
+	"unsafe"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestTaskMem(t *testing.T) {
+	values := []uint32{1, 2, 3, 4}
+
+	p, err := TaskMemAlloc(uintptr(len(values)) * unsafe.Sizeof(values[0]))
+	if err != nil {
+		t.Fatalf("TaskMemAlloc error: %v", err)
+	}
+	defer TaskMemFree(p)
+
+	copy(unsafe.Slice((*uint32)(p), len(values)), values)
+
+	got := TaskMemToSlice[uint32](p, len(values))
+	if !slices.Equal(got, values) {
+		t.Errorf("TaskMemToSlice got %v, want %v", got, values)
+	}
+
+	if got := TaskMemToSlice[uint32](nil, len(values)); got != nil {
+		t.Errorf("TaskMemToSlice(nil) got %v, want nil", got)
+	}
+}
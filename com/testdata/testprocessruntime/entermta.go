@@ -0,0 +1,42 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/dblohm7/wingoes/com"
+)
+
+func init() {
+	register("EnterMTA", EnterMTA)
+}
+
+// EnterMTA intentionally does not register an init function: the process must
+// not reside in any COM apartment until com.EnterMTA is called.
+func EnterMTA() {
+	if checkBackgroundThread(true) {
+		fmt.Println("error: background OS thread is MTA before calling EnterMTA")
+		return
+	}
+
+	leave, err := com.EnterMTA()
+	if err != nil {
+		fmt.Printf("error: got %v, want nil\n", err)
+		return
+	}
+
+	if !checkBackgroundThread(true) {
+		fmt.Println("error: background OS thread is not MTA")
+		return
+	}
+
+	// leave must tolerate being called more than once.
+	leave()
+	leave()
+
+	fmt.Println("OK")
+}
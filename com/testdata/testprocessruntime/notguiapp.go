@@ -34,6 +34,16 @@ func NonGUIApp() {
 		return
 	}
 
+	apt, qual, err := com.CurrentApartment()
+	if err != nil {
+		fmt.Printf("error: got %v, want nil\n", err)
+		return
+	}
+	if apt != com.ApartmentTypeMTA || qual != com.ApartmentQualifierNone {
+		fmt.Printf("error: CurrentApartment got (%v, %v), want (%v, %v)\n", apt, qual, com.ApartmentTypeMTA, com.ApartmentQualifierNone)
+		return
+	}
+
 	globalOpts, err := com.CreateInstance[com.GlobalOptions](com.CLSID_GlobalOptions)
 	if err != nil {
 		fmt.Printf("error: got %v, want nil\n", err)
@@ -0,0 +1,37 @@
+// Copyright (c) 2022 Tailscale Inc & AUTHORS. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/dblohm7/wingoes/com"
+	"golang.org/x/sys/windows"
+)
+
+func init() {
+	registerInit("NonGUIAppSecurity", NonGUIAppSecurityInit)
+	register("NonGUIAppSecurity", NonGUIApp) // We reuse NonGUIApp for this part of the test
+}
+
+func NonGUIAppSecurityInit() {
+	var dacl *windows.ACL
+	dacl, err = makeDACL()
+	if err != nil {
+		fmt.Println("error: ", err)
+		return
+	}
+
+	opts := com.SecurityOptions{
+		DACL:       dacl,
+		AuthnLevel: com.RPC_C_AUTHN_LEVEL_PKT_PRIVACY,
+		ImpLevel:   com.RPC_C_IMP_LEVEL_IDENTIFY,
+	}
+	if err = com.StartRuntimeWithSecurity(com.ConsoleApp, opts); err != nil {
+		fmt.Println("error: ", err)
+	}
+}
@@ -7,6 +7,7 @@
 package com
 
 import (
+	"fmt"
 	"unsafe"
 
 	"github.com/dblohm7/wingoes"
@@ -25,33 +26,114 @@ const (
 	coCLSCTX_REMOTE_SERVER = coCLSCTX(0x10)
 )
 
-type coAPTTYPE int32
+// coServerInfo is the Win32 COSERVERINFO structure.
+type coServerInfo struct {
+	reserved1 uint32
+	name      *uint16
+	authInfo  uintptr
+	reserved2 uint32
+}
+
+// multiQI is the Win32 MULTI_QI structure.
+type multiQI struct {
+	iid *IID
+	itf *IUnknownABI
+	hr  wingoes.HRESULT
+}
+
+// ApartmentType identifies the kind of COM apartment that an OS thread
+// resides in.
+type ApartmentType int32
 
 const (
-	coAPTTYPE_CURRENT = coAPTTYPE(-1)
-	coAPTTYPE_STA     = coAPTTYPE(0)
-	coAPTTYPE_MTA     = coAPTTYPE(1)
-	coAPTTYPE_NA      = coAPTTYPE(2)
-	coAPTTYPE_MAINSTA = coAPTTYPE(3)
+	ApartmentTypeCurrent = ApartmentType(-1)
+	ApartmentTypeSTA     = ApartmentType(0)
+	ApartmentTypeMTA     = ApartmentType(1)
+	ApartmentTypeNA      = ApartmentType(2)
+	ApartmentTypeMainSTA = ApartmentType(3)
 )
 
-type coAPTTYPEQUALIFIER int32
+func (at ApartmentType) String() string {
+	switch at {
+	case ApartmentTypeCurrent:
+		return "Current"
+	case ApartmentTypeSTA:
+		return "STA"
+	case ApartmentTypeMTA:
+		return "MTA"
+	case ApartmentTypeNA:
+		return "NA"
+	case ApartmentTypeMainSTA:
+		return "MainSTA"
+	default:
+		return fmt.Sprintf("ApartmentType(%d)", int32(at))
+	}
+}
+
+// ApartmentQualifier provides additional detail about an ApartmentType, such
+// as whether an OS thread's membership in the MTA is implicit.
+type ApartmentQualifier int32
 
 const (
-	coAPTTYPEQUALIFIER_NONE               = coAPTTYPEQUALIFIER(0)
-	coAPTTYPEQUALIFIER_IMPLICIT_MTA       = coAPTTYPEQUALIFIER(1)
-	coAPTTYPEQUALIFIER_NA_ON_MTA          = coAPTTYPEQUALIFIER(2)
-	coAPTTYPEQUALIFIER_NA_ON_STA          = coAPTTYPEQUALIFIER(3)
-	coAPTTYPEQUALIFIER_NA_ON_IMPLICIT_MTA = coAPTTYPEQUALIFIER(4)
-	coAPTTYPEQUALIFIER_NA_ON_MAINSTA      = coAPTTYPEQUALIFIER(5)
-	coAPTTYPEQUALIFIER_APPLICATION_STA    = coAPTTYPEQUALIFIER(6)
+	ApartmentQualifierNone            = ApartmentQualifier(0)
+	ApartmentQualifierImplicitMTA     = ApartmentQualifier(1)
+	ApartmentQualifierNAOnMTA         = ApartmentQualifier(2)
+	ApartmentQualifierNAOnSTA         = ApartmentQualifier(3)
+	ApartmentQualifierNAOnImplicitMTA = ApartmentQualifier(4)
+	ApartmentQualifierNAOnMainSTA     = ApartmentQualifier(5)
+	ApartmentQualifierApplicationSTA  = ApartmentQualifier(6)
 )
 
+func (aq ApartmentQualifier) String() string {
+	switch aq {
+	case ApartmentQualifierNone:
+		return "None"
+	case ApartmentQualifierImplicitMTA:
+		return "ImplicitMTA"
+	case ApartmentQualifierNAOnMTA:
+		return "NAOnMTA"
+	case ApartmentQualifierNAOnSTA:
+		return "NAOnSTA"
+	case ApartmentQualifierNAOnImplicitMTA:
+		return "NAOnImplicitMTA"
+	case ApartmentQualifierNAOnMainSTA:
+		return "NAOnMainSTA"
+	case ApartmentQualifierApplicationSTA:
+		return "ApplicationSTA"
+	default:
+		return fmt.Sprintf("ApartmentQualifier(%d)", int32(aq))
+	}
+}
+
 type aptInfo struct {
-	apt       coAPTTYPE
-	qualifier coAPTTYPEQUALIFIER
+	apt       ApartmentType
+	qualifier ApartmentQualifier
+}
+
+type point struct {
+	x int32
+	y int32
+}
+
+// msg is the Win32 MSG structure.
+type msg struct {
+	hwnd     windows.HWND
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	pt       point
+	lPrivate uint32
 }
 
+const (
+	mwmoInputAvailable = 0x0004
+	pmRemove           = 1
+	qsAllInput         = 0x04FF
+	wmApp              = 0x8000
+	wmQuit             = 0x0012
+)
+
 type soleAuthenticationInfo struct {
 	authnSvc uint32
 	authzSvc uint32
@@ -90,26 +172,30 @@ const (
 	authCapNoCustomMarshal = authCapabilities(0x2000)
 )
 
-type rpcAuthnLevel uint32
+// RPCAuthnLevel specifies the level of authentication that COM applies to
+// its network communications.
+type RPCAuthnLevel uint32
 
 const (
-	rpcAuthnLevelDefault      = rpcAuthnLevel(0)
-	rpcAuthnLevelNone         = rpcAuthnLevel(1)
-	rpcAuthnLevelConnect      = rpcAuthnLevel(2)
-	rpcAuthnLevelCall         = rpcAuthnLevel(3)
-	rpcAuthnLevelPkt          = rpcAuthnLevel(4)
-	rpcAuthnLevelPktIntegrity = rpcAuthnLevel(5)
-	rpcAuthnLevelPkgPrivacy   = rpcAuthnLevel(6)
+	RPC_C_AUTHN_LEVEL_DEFAULT       = RPCAuthnLevel(0)
+	RPC_C_AUTHN_LEVEL_NONE          = RPCAuthnLevel(1)
+	RPC_C_AUTHN_LEVEL_CONNECT       = RPCAuthnLevel(2)
+	RPC_C_AUTHN_LEVEL_CALL          = RPCAuthnLevel(3)
+	RPC_C_AUTHN_LEVEL_PKT           = RPCAuthnLevel(4)
+	RPC_C_AUTHN_LEVEL_PKT_INTEGRITY = RPCAuthnLevel(5)
+	RPC_C_AUTHN_LEVEL_PKT_PRIVACY   = RPCAuthnLevel(6)
 )
 
-type rpcImpersonationLevel uint32
+// RPCImpLevel specifies the degree to which COM servers may impersonate their
+// clients.
+type RPCImpLevel uint32
 
 const (
-	rpcImpLevelDefault     = rpcImpersonationLevel(0)
-	rpcImpLevelAnonymous   = rpcImpersonationLevel(1)
-	rpcImpLevelIdentify    = rpcImpersonationLevel(2)
-	rpcImpLevelImpersonate = rpcImpersonationLevel(3)
-	rpcImpLevelDelegate    = rpcImpersonationLevel(4)
+	RPC_C_IMP_LEVEL_DEFAULT     = RPCImpLevel(0)
+	RPC_C_IMP_LEVEL_ANONYMOUS   = RPCImpLevel(1)
+	RPC_C_IMP_LEVEL_IDENTIFY    = RPCImpLevel(2)
+	RPC_C_IMP_LEVEL_IMPERSONATE = RPCImpLevel(3)
+	RPC_C_IMP_LEVEL_DELEGATE    = RPCImpLevel(4)
 )
 
 // COMAllocatedString encapsulates a UTF-16 string that was allocated by COM
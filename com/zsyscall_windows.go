@@ -40,32 +40,77 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
+	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
 	modole32    = windows.NewLazySystemDLL("ole32.dll")
 	modoleaut32 = windows.NewLazySystemDLL("oleaut32.dll")
 	modshlwapi  = windows.NewLazySystemDLL("shlwapi.dll")
-
-	procCoCreateInstance      = modole32.NewProc("CoCreateInstance")
-	procCoGetApartmentType    = modole32.NewProc("CoGetApartmentType")
-	procCoIncrementMTAUsage   = modole32.NewProc("CoIncrementMTAUsage")
-	procCoInitializeEx        = modole32.NewProc("CoInitializeEx")
-	procCoInitializeSecurity  = modole32.NewProc("CoInitializeSecurity")
-	procCreateStreamOnHGlobal = modole32.NewProc("CreateStreamOnHGlobal")
-	procSetOaNoCache          = modoleaut32.NewProc("SetOaNoCache")
-	procSHCreateMemStream     = modshlwapi.NewProc("SHCreateMemStream")
+	moduser32   = windows.NewLazySystemDLL("user32.dll")
+
+	procNtQueryInformationFile      = modntdll.NewProc("NtQueryInformationFile")
+	procCLSIDFromProgID             = modole32.NewProc("CLSIDFromProgID")
+	procCoCreateInstance            = modole32.NewProc("CoCreateInstance")
+	procCoCreateInstanceEx          = modole32.NewProc("CoCreateInstanceEx")
+	procCoDecrementMTAUsage         = modole32.NewProc("CoDecrementMTAUsage")
+	procCoGetApartmentType          = modole32.NewProc("CoGetApartmentType")
+	procCoGetClassObject            = modole32.NewProc("CoGetClassObject")
+	procCoIncrementMTAUsage         = modole32.NewProc("CoIncrementMTAUsage")
+	procCoInitializeEx              = modole32.NewProc("CoInitializeEx")
+	procCoInitializeSecurity        = modole32.NewProc("CoInitializeSecurity")
+	procCoRegisterClassObject       = modole32.NewProc("CoRegisterClassObject")
+	procCoRevokeClassObject         = modole32.NewProc("CoRevokeClassObject")
+	procCoTaskMemAlloc              = modole32.NewProc("CoTaskMemAlloc")
+	procCreateStreamOnHGlobal       = modole32.NewProc("CreateStreamOnHGlobal")
+	procGetHGlobalFromStream        = modole32.NewProc("GetHGlobalFromStream")
+	procProgIDFromCLSID             = modole32.NewProc("ProgIDFromCLSID")
+	procPropVariantClear            = modole32.NewProc("PropVariantClear")
+	procStgCreateDocfile            = modole32.NewProc("StgCreateDocfile")
+	procStgOpenStorage              = modole32.NewProc("StgOpenStorage")
+	procSetOaNoCache                = modoleaut32.NewProc("SetOaNoCache")
+	procSHCreateMemStream           = modshlwapi.NewProc("SHCreateMemStream")
+	procSHCreateStreamOnFileEx      = modshlwapi.NewProc("SHCreateStreamOnFileEx")
+	procDispatchMessageW            = moduser32.NewProc("DispatchMessageW")
+	procMsgWaitForMultipleObjectsEx = moduser32.NewProc("MsgWaitForMultipleObjectsEx")
+	procPeekMessageW                = moduser32.NewProc("PeekMessageW")
+	procPostThreadMessageW          = moduser32.NewProc("PostThreadMessageW")
+	procTranslateMessage            = moduser32.NewProc("TranslateMessage")
 )
 
+func clsidFromProgID(progID *uint16, clsid *CLSID) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procCLSIDFromProgID.Addr(), 2, uintptr(unsafe.Pointer(progID)), uintptr(unsafe.Pointer(clsid)), 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
 func coCreateInstance(clsid *CLSID, unkOuter *IUnknownABI, clsctx coCLSCTX, iid *IID, ppv **IUnknownABI) (hr wingoes.HRESULT) {
 	r0, _, _ := syscall.Syscall6(procCoCreateInstance.Addr(), 5, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(unkOuter)), uintptr(clsctx), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(ppv)), 0)
 	hr = wingoes.HRESULT(r0)
 	return
 }
 
-func coGetApartmentType(aptType *coAPTTYPE, qual *coAPTTYPEQUALIFIER) (hr wingoes.HRESULT) {
+func coCreateInstanceEx(clsid *CLSID, unkOuter *IUnknownABI, clsctx coCLSCTX, serverInfo *coServerInfo, count uint32, results *multiQI) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall6(procCoCreateInstanceEx.Addr(), 6, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(unkOuter)), uintptr(clsctx), uintptr(unsafe.Pointer(serverInfo)), uintptr(count), uintptr(unsafe.Pointer(results)))
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func coDecrementMTAUsage(cookie coMTAUsageCookie) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procCoDecrementMTAUsage.Addr(), 1, uintptr(cookie), 0, 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func coGetApartmentType(aptType *ApartmentType, qual *ApartmentQualifier) (hr wingoes.HRESULT) {
 	r0, _, _ := syscall.Syscall(procCoGetApartmentType.Addr(), 2, uintptr(unsafe.Pointer(aptType)), uintptr(unsafe.Pointer(qual)), 0)
 	hr = wingoes.HRESULT(r0)
 	return
 }
 
+func coGetClassObject(clsid *CLSID, clsctx coCLSCTX, serverInfo *coServerInfo, iid *IID, ppv **IUnknownABI) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall6(procCoGetClassObject.Addr(), 5, uintptr(unsafe.Pointer(clsid)), uintptr(clsctx), uintptr(unsafe.Pointer(serverInfo)), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(ppv)), 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
 func coIncrementMTAUsage(cookie *coMTAUsageCookie) (hr wingoes.HRESULT) {
 	r0, _, _ := syscall.Syscall(procCoIncrementMTAUsage.Addr(), 1, uintptr(unsafe.Pointer(cookie)), 0, 0)
 	hr = wingoes.HRESULT(r0)
@@ -78,12 +123,30 @@ func coInitializeEx(reserved uintptr, flags uint32) (hr wingoes.HRESULT) {
 	return
 }
 
-func coInitializeSecurity(sd *windows.SECURITY_DESCRIPTOR, authSvcLen int32, authSvc *soleAuthenticationService, reserved1 uintptr, authnLevel rpcAuthnLevel, impLevel rpcImpersonationLevel, authList *soleAuthenticationList, capabilities authCapabilities, reserved2 uintptr) (hr wingoes.HRESULT) {
+func coInitializeSecurity(sd *windows.SECURITY_DESCRIPTOR, authSvcLen int32, authSvc *soleAuthenticationService, reserved1 uintptr, authnLevel RPCAuthnLevel, impLevel RPCImpLevel, authList *soleAuthenticationList, capabilities authCapabilities, reserved2 uintptr) (hr wingoes.HRESULT) {
 	r0, _, _ := syscall.Syscall9(procCoInitializeSecurity.Addr(), 9, uintptr(unsafe.Pointer(sd)), uintptr(authSvcLen), uintptr(unsafe.Pointer(authSvc)), uintptr(reserved1), uintptr(authnLevel), uintptr(impLevel), uintptr(unsafe.Pointer(authList)), uintptr(capabilities), uintptr(reserved2))
 	hr = wingoes.HRESULT(r0)
 	return
 }
 
+func coRegisterClassObject(clsid *CLSID, unk *IUnknownABI, clsctx coCLSCTX, flags uint32, cookie *RegistrationCookie) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall6(procCoRegisterClassObject.Addr(), 5, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(unk)), uintptr(clsctx), uintptr(flags), uintptr(unsafe.Pointer(cookie)), 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func coRevokeClassObject(cookie RegistrationCookie) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procCoRevokeClassObject.Addr(), 1, uintptr(cookie), 0, 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func coTaskMemAlloc(size uintptr) (p unsafe.Pointer) {
+	r0, _, _ := syscall.Syscall(procCoTaskMemAlloc.Addr(), 1, uintptr(size), 0, 0)
+	p = unsafe.Pointer(r0)
+	return
+}
+
 func createStreamOnHGlobal(hglobal internal.HGLOBAL, deleteOnRelease bool, stream **IUnknownABI) (hr wingoes.HRESULT) {
 	var _p0 uint32
 	if deleteOnRelease {
@@ -94,6 +157,61 @@ func createStreamOnHGlobal(hglobal internal.HGLOBAL, deleteOnRelease bool, strea
 	return
 }
 
+func getHGlobalFromStream(stream *IUnknownABI, hglobal *internal.HGLOBAL) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procGetHGlobalFromStream.Addr(), 2, uintptr(unsafe.Pointer(stream)), uintptr(unsafe.Pointer(hglobal)), 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func dispatchMessage(msg *msg) (res uintptr) {
+	r0, _, _ := syscall.Syscall(procDispatchMessageW.Addr(), 1, uintptr(unsafe.Pointer(msg)), 0, 0)
+	res = uintptr(r0)
+	return
+}
+
+func msgWaitForMultipleObjectsEx(count uint32, handles *windows.Handle, millis uint32, wakeMask uint32, flags uint32) (ret uint32, err error) {
+	r0, _, e1 := syscall.Syscall6(procMsgWaitForMultipleObjectsEx.Addr(), 5, uintptr(count), uintptr(unsafe.Pointer(handles)), uintptr(millis), uintptr(wakeMask), uintptr(flags), 0)
+	ret = uint32(r0)
+	if ret == 0xFFFFFFFF {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func ntQueryInformationFile(handle windows.Handle, iosb *windows.IO_STATUS_BLOCK, info unsafe.Pointer, infoLen uint32, class uint32) (ntstatus error) {
+	r0, _, _ := syscall.Syscall6(procNtQueryInformationFile.Addr(), 5, uintptr(handle), uintptr(unsafe.Pointer(iosb)), uintptr(info), uintptr(infoLen), uintptr(class), 0)
+	if r0 != 0 {
+		ntstatus = windows.NTStatus(r0)
+	}
+	return
+}
+
+func peekMessage(msg *msg, hwnd windows.HWND, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (ret bool) {
+	r0, _, _ := syscall.Syscall6(procPeekMessageW.Addr(), 5, uintptr(unsafe.Pointer(msg)), uintptr(hwnd), uintptr(msgFilterMin), uintptr(msgFilterMax), uintptr(removeMsg), 0)
+	ret = r0 != 0
+	return
+}
+
+func postThreadMessage(threadID uint32, msg uint32, wParam uintptr, lParam uintptr) (err error) {
+	r1, _, e1 := syscall.Syscall6(procPostThreadMessageW.Addr(), 4, uintptr(threadID), uintptr(msg), uintptr(wParam), uintptr(lParam), 0, 0)
+	if int32(r1) == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func progIDFromCLSID(clsid *CLSID, progID *COMAllocatedString) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procProgIDFromCLSID.Addr(), 2, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(progID)), 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func propVariantClear(pv *PropVariant) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall(procPropVariantClear.Addr(), 1, uintptr(unsafe.Pointer(pv)), 0, 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
 func setOaNoCache() {
 	syscall.Syscall(procSetOaNoCache.Addr(), 0, 0, 0, 0)
 	return
@@ -104,3 +222,31 @@ func shCreateMemStream(pInit *byte, cbInit uint32) (stream *IUnknownABI) {
 	stream = (*IUnknownABI)(unsafe.Pointer(r0))
 	return
 }
+
+func shCreateStreamOnFileEx(file *uint16, mode uint32, attrs uint32, create bool, template *IUnknownABI, stream **IUnknownABI) (hr wingoes.HRESULT) {
+	var _p0 uint32
+	if create {
+		_p0 = 1
+	}
+	r0, _, _ := syscall.Syscall6(procSHCreateStreamOnFileEx.Addr(), 6, uintptr(unsafe.Pointer(file)), uintptr(mode), uintptr(attrs), uintptr(_p0), uintptr(unsafe.Pointer(template)), uintptr(unsafe.Pointer(stream)))
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func stgCreateDocfile(name *uint16, mode STGM, reserved uint32, storage **IUnknownABI) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall6(procStgCreateDocfile.Addr(), 4, uintptr(unsafe.Pointer(name)), uintptr(mode), uintptr(reserved), uintptr(unsafe.Pointer(storage)), 0, 0)
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func stgOpenStorage(name *uint16, priority *IUnknownABI, mode STGM, exclude uintptr, reserved uint32, storage **IUnknownABI) (hr wingoes.HRESULT) {
+	r0, _, _ := syscall.Syscall6(procStgOpenStorage.Addr(), 6, uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(priority)), uintptr(mode), uintptr(exclude), uintptr(reserved), uintptr(unsafe.Pointer(storage)))
+	hr = wingoes.HRESULT(r0)
+	return
+}
+
+func translateMessage(msg *msg) (ret bool) {
+	r0, _, _ := syscall.Syscall(procTranslateMessage.Addr(), 1, uintptr(unsafe.Pointer(msg)), 0, 0)
+	ret = r0 != 0
+	return
+}
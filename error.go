@@ -7,6 +7,7 @@
 package wingoes
 
 import (
+	"errors"
 	"fmt"
 
 	"golang.org/x/sys/windows"
@@ -36,7 +37,7 @@ const S_FALSE = HRESULT(1)
 
 var (
 	// genericError encodes an Error whose message string is very generic.
-	genericError = Error(hresultFromFacilityAndCode(hrFail, facilityWin32, hrCode(windows.ERROR_UNIDENTIFIED_ERROR)))
+	genericError = Error(hresultFromFacilityAndCode(hrFail, FACILITY_WIN32, hrCode(windows.ERROR_UNIDENTIFIED_ERROR)))
 )
 
 // Common HRESULT codes that don't use Win32 facilities, but have meanings that
@@ -50,9 +51,12 @@ var commonHRESULTToErrno = map[HRESULT]windows.Errno{
 }
 
 type hrCode uint16
-type hrFacility uint16
 type failBit bool
 
+// HRESULTFacility is the facility field of an HRESULT, which identifies the
+// subsystem responsible for the error.
+type HRESULTFacility uint16
+
 const (
 	hrFlagBitsMask  = 0xF8000000
 	hrFacilityMax   = 0x00001FFF
@@ -65,7 +69,32 @@ const (
 )
 
 const (
-	facilityWin32 = hrFacility(7)
+	FACILITY_NULL             = HRESULTFacility(0)
+	FACILITY_RPC              = HRESULTFacility(1)
+	FACILITY_DISPATCH         = HRESULTFacility(2)
+	FACILITY_STORAGE          = HRESULTFacility(3)
+	FACILITY_ITF              = HRESULTFacility(4)
+	FACILITY_WIN32            = HRESULTFacility(7)
+	FACILITY_WINDOWS          = HRESULTFacility(8)
+	FACILITY_SECURITY         = HRESULTFacility(9)
+	FACILITY_SSPI             = FACILITY_SECURITY
+	FACILITY_CONTROL          = HRESULTFacility(10)
+	FACILITY_CERT             = HRESULTFacility(11)
+	FACILITY_INTERNET         = HRESULTFacility(12)
+	FACILITY_MEDIASERVER      = HRESULTFacility(13)
+	FACILITY_MSMQ             = HRESULTFacility(14)
+	FACILITY_SETUPAPI         = HRESULTFacility(15)
+	FACILITY_SCARD            = HRESULTFacility(16)
+	FACILITY_COMPLUS          = HRESULTFacility(17)
+	FACILITY_URT              = HRESULTFacility(19)
+	FACILITY_SXS              = HRESULTFacility(23)
+	FACILITY_HTTP             = HRESULTFacility(25)
+	FACILITY_BACKGROUNDCOPY   = HRESULTFacility(32)
+	FACILITY_CONFIGURATION    = HRESULTFacility(33)
+	FACILITY_WINDOWSUPDATE    = HRESULTFacility(36)
+	FACILITY_DIRECTORYSERVICE = HRESULTFacility(37)
+	FACILITY_SHELL            = HRESULTFacility(39)
+	FACILITY_WINRM            = HRESULTFacility(51)
 )
 
 // Succeeded returns true when hr is successful, but its actual error code
@@ -97,12 +126,19 @@ func (hr HRESULT) isNormal() bool {
 	return (hr & (hrCustomerBit | hrFacilityNTBit)) == 0
 }
 
-// facility returns the facility bits of hr. Only valid when isNormal is true.
-func (hr HRESULT) facility() hrFacility {
-	return hrFacility((uint32(hr) >> 16) & hrFacilityMax)
+// Facility returns the facility bits of hr. The result is only meaningful when
+// hr is neither an encoded NTSTATUS nor a customer-defined code.
+func (hr HRESULT) Facility() HRESULTFacility {
+	return HRESULTFacility((uint32(hr) >> 16) & hrFacilityMax)
+}
+
+// Code returns the code bits of hr. The result is only meaningful when hr is
+// neither an encoded NTSTATUS nor a customer-defined code.
+func (hr HRESULT) Code() uint16 {
+	return uint16(hr.code())
 }
 
-// facility returns the code bits of hr. Only valid when isNormal is true.
+// code returns the code bits of hr. Only valid when isNormal is true.
 func (hr HRESULT) code() hrCode {
 	return hrCode(uint32(hr) & hrCodeMask)
 }
@@ -112,7 +148,7 @@ const (
 	hrSuccess = failBit(false)
 )
 
-func hresultFromFacilityAndCode(isFail failBit, f hrFacility, c hrCode) HRESULT {
+func hresultFromFacilityAndCode(isFail failBit, f HRESULTFacility, c hrCode) HRESULT {
 	var r uint32
 	if isFail {
 		r |= hrFailBit
@@ -136,7 +172,7 @@ func ErrorFromErrno(e windows.Errno) Error {
 		// Can't be encoded in HRESULT, return generic error instead
 		return genericError
 	}
-	return Error(hresultFromFacilityAndCode(hrFail, facilityWin32, hrCode(e)))
+	return Error(hresultFromFacilityAndCode(hrFail, FACILITY_WIN32, hrCode(e)))
 }
 
 // ErrorFromNTStatus creates an Error from s.
@@ -169,6 +205,31 @@ func NewError(e any) (Error, bool) {
 	}
 }
 
+// ErrorFromError searches err's tree (via errors.As) for an Error,
+// windows.NTStatus, or windows.Errno, and converts it into an Error. The types
+// are searched in that order of priority: an Error anywhere in err's tree takes
+// precedence over any windows.NTStatus, which in turn takes precedence over any
+// windows.Errno. It returns both the Error and a bool indicating whether the
+// conversion was successful.
+func ErrorFromError(err error) (Error, bool) {
+	var e Error
+	if errors.As(err, &e) {
+		return NewError(e)
+	}
+
+	var status windows.NTStatus
+	if errors.As(err, &status) {
+		return NewError(status)
+	}
+
+	var errno windows.Errno
+	if errors.As(err, &errno) {
+		return NewError(errno)
+	}
+
+	return NewError(err)
+}
+
 // IsOK returns true when the Error is unconditionally successful.
 func (e Error) IsOK() bool {
 	return HRESULT(e) == hrS_OK
@@ -207,7 +268,7 @@ func (e Error) toErrno(f errnoFailHandler) windows.Errno {
 		return e.AsNTStatus().Errno()
 	}
 
-	if hr.facility() == facilityWin32 {
+	if hr.Facility() == FACILITY_WIN32 {
 		return windows.Errno(hr.code())
 	}
 
@@ -282,7 +343,7 @@ func (e Error) IsAvailableAsHRESULT() bool {
 // IsAvailableAsErrno returns true if e may be converted to a windows.Errno.
 func (e Error) IsAvailableAsErrno() bool {
 	hr := HRESULT(e)
-	if hr.isCustomer() || e.IsAvailableAsNTStatus() || (hr.facility() == facilityWin32) {
+	if hr.isCustomer() || e.IsAvailableAsNTStatus() || (hr.Facility() == FACILITY_WIN32) {
 		return true
 	}
 	_, convertable := commonHRESULTToErrno[hr]
@@ -294,25 +355,63 @@ func (e Error) IsAvailableAsNTStatus() bool {
 	return HRESULT(e) == hrS_OK || HRESULT(e).isNT()
 }
 
-// Error produces a human-readable message describing Error e.
+var modntdll = windows.NewLazySystemDLL("ntdll.dll")
+
+// Error produces a human-readable message describing Error e. The message is
+// obtained from the OS (and is thus localized). When the OS does not provide a
+// message for e, the message consists of e's hexadecimal value.
 func (e Error) Error() string {
 	if HRESULT(e).isCustomer() {
 		return windows.Errno(uint32(e) ^ hrFailBit).Error()
 	}
 
+	flags := uint32(windows.FORMAT_MESSAGE_FROM_SYSTEM | windows.FORMAT_MESSAGE_IGNORE_INSERTS)
+	var module uintptr
+	code := uint32(e)
+
+	// NTSTATUS messages are stored in ntdll's message table.
+	if HRESULT(e).isNT() {
+		if err := modntdll.Load(); err == nil {
+			flags |= windows.FORMAT_MESSAGE_FROM_HMODULE
+			module = modntdll.Handle()
+			code = uint32(e.AsNTStatus())
+		}
+	}
+
 	buf := make([]uint16, 300)
-	const flags = windows.FORMAT_MESSAGE_FROM_SYSTEM | windows.FORMAT_MESSAGE_IGNORE_INSERTS
-	lenExclNul, err := windows.FormatMessage(flags, 0, uint32(e), 0, buf, nil)
+	lenExclNul, err := windows.FormatMessage(flags, module, code, 0, buf, nil)
 	if err != nil {
-		return fmt.Sprintf("wingoes.Error 0x%08X", uint32(e))
+		return HRESULT(e).String()
 	}
 	for ; lenExclNul > 0 && (buf[lenExclNul-1] == '\n' || buf[lenExclNul-1] == '\r'); lenExclNul-- {
 	}
+	if lenExclNul == 0 {
+		return HRESULT(e).String()
+	}
 	return windows.UTF16ToString(buf[:lenExclNul])
 }
 
+// Is returns true when target is semantically equivalent to e. target may be
+// an Error, a windows.NTStatus, or a windows.Errno. This permits errors.Is
+// to match e against the most natural representation of target, regardless
+// of how e was originally created.
+func (e Error) Is(target error) bool {
+	switch v := target.(type) {
+	case Error:
+		return e == v
+	case windows.NTStatus:
+		return e.IsAvailableAsNTStatus() && e.AsNTStatus() == v
+	case windows.Errno:
+		return e.IsAvailableAsErrno() && e.AsErrno() == v
+	default:
+		return false
+	}
+}
+
 // Unwrap permits extraction of underlying windows.NTStatus or windows.Errno
-// errors that are encoded within e.
+// errors that are encoded within e. It returns nil when e is not convertable
+// to either of those types (for example, HRESULTs using COM-specific facility
+// codes), so that errors.Is does not produce misleading matches.
 func (e Error) Unwrap() error {
 	// Order is important! We need earlier checks to exclude certain things that
 	// would otherwise be (in this case) false positives in later checks!
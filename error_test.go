@@ -7,6 +7,8 @@
 package wingoes
 
 import (
+	"errors"
+	"fmt"
 	"syscall"
 	"testing"
 
@@ -15,8 +17,8 @@ import (
 
 type hrTestCase struct {
 	hr              HRESULT
-	expectFacility  hrFacility // only valid when both expectNT and expectCustomer are false
-	expectCode      hrCode     // only valid when both expectNT and expectCustomer are false
+	expectFacility  HRESULTFacility // only valid when both expectNT and expectCustomer are false
+	expectCode      uint16          // only valid when both expectNT and expectCustomer are false
 	expectSucceeded bool
 	expectNT        bool
 	expectCustomer  bool
@@ -46,11 +48,11 @@ func TestHRESULT(t *testing.T) {
 			t.Errorf("hr 0x%08X isCustomer() got %v, want %v", uint32(hr), hr.isCustomer(), tc.expectCustomer)
 		}
 		if !hr.isNT() && !hr.isCustomer() {
-			if hr.facility() != tc.expectFacility {
-				t.Errorf("hr 0x%08X facility() got %v, want %v", uint32(hr), hr.facility(), tc.expectFacility)
+			if hr.Facility() != tc.expectFacility {
+				t.Errorf("hr 0x%08X Facility() got %v, want %v", uint32(hr), hr.Facility(), tc.expectFacility)
 			}
-			if hr.code() != tc.expectCode {
-				t.Errorf("hr 0x%08X code() got %v, want %v", uint32(hr), hr.code(), tc.expectCode)
+			if hr.Code() != tc.expectCode {
+				t.Errorf("hr 0x%08X Code() got %v, want %v", uint32(hr), hr.Code(), tc.expectCode)
 			}
 		}
 	}
@@ -94,3 +96,99 @@ func TestNewError(t *testing.T) {
 		}
 	}
 }
+
+type unwrapTestCase struct {
+	err  Error
+	want error
+}
+
+var unwrapTestCases = []unwrapTestCase{
+	unwrapTestCase{Error(hrS_OK), nil},
+	unwrapTestCase{Error(hrE_POINTER), nil},
+	unwrapTestCase{Error(hrTYPE_E_WRONGTYPEKIND), nil},
+	unwrapTestCase{Error(hrE_NOTIMPL), windows.ERROR_CALL_NOT_IMPLEMENTED},
+	unwrapTestCase{ErrorFromErrno(windows.ERROR_ACCESS_DENIED), windows.ERROR_ACCESS_DENIED},
+	unwrapTestCase{ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED), windows.STATUS_ACCESS_DENIED},
+}
+
+func TestUnwrap(t *testing.T) {
+	for _, tc := range unwrapTestCases {
+		got := tc.err.Unwrap()
+		if got != tc.want {
+			t.Errorf("Error 0x%08X Unwrap() got %v, want %v", uint32(tc.err), got, tc.want)
+		}
+		if tc.want != nil && !errors.Is(tc.err, tc.want) {
+			t.Errorf("errors.Is(0x%08X, %v) got false, want true", uint32(tc.err), tc.want)
+		}
+	}
+}
+
+type isTestCase struct {
+	err    Error
+	target error
+	want   bool
+}
+
+var isTestCases = []isTestCase{
+	isTestCase{ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED), windows.STATUS_ACCESS_DENIED, true},
+	isTestCase{ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED), windows.ERROR_ACCESS_DENIED, true},
+	isTestCase{ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED), windows.STATUS_INVALID_PARAMETER, false},
+	isTestCase{ErrorFromErrno(windows.ERROR_ACCESS_DENIED), windows.ERROR_ACCESS_DENIED, true},
+	isTestCase{ErrorFromErrno(windows.ERROR_ACCESS_DENIED), windows.STATUS_ACCESS_DENIED, false},
+	isTestCase{Error(hrE_POINTER), windows.ERROR_INVALID_ADDRESS, false},
+	isTestCase{Error(hrE_POINTER), Error(hrE_POINTER), true},
+}
+
+func TestIs(t *testing.T) {
+	for _, tc := range isTestCases {
+		if got := errors.Is(tc.err, tc.target); got != tc.want {
+			t.Errorf("errors.Is(0x%08X, %v) got %v, want %v", uint32(tc.err), tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	errs := []Error{
+		Error(hrE_POINTER),
+		ErrorFromErrno(windows.ERROR_ACCESS_DENIED),
+		ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED),
+	}
+	for _, e := range errs {
+		s := e.Error()
+		if s == "" || s == HRESULT(e).String() {
+			t.Errorf("Error 0x%08X has no message", uint32(e))
+		}
+		if s2 := e.Error(); s2 != s {
+			t.Errorf("Error 0x%08X inconsistent messages %q and %q", uint32(e), s, s2)
+		}
+	}
+
+	unknown := Error(hresultFromFacilityAndCode(hrFail, HRESULTFacility(0x1FFF), hrCode(0xFFFF)))
+	if got, want := unknown.Error(), HRESULT(unknown).String(); got != want {
+		t.Errorf("Error 0x%08X got %q, want %q", uint32(unknown), got, want)
+	}
+}
+
+func TestErrorFromError(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", windows.ERROR_ACCESS_DENIED)
+	e, ok := ErrorFromError(wrapped)
+	if !ok {
+		t.Fatalf("ErrorFromError(%v) failed", wrapped)
+	}
+	if want := ErrorFromErrno(windows.ERROR_ACCESS_DENIED); e != want {
+		t.Errorf("ErrorFromError(%v) got 0x%08X, want 0x%08X", wrapped, uint32(e), uint32(want))
+	}
+
+	wrapped = fmt.Errorf("wrapped: %w", windows.STATUS_ACCESS_DENIED)
+	e, ok = ErrorFromError(wrapped)
+	if !ok {
+		t.Fatalf("ErrorFromError(%v) failed", wrapped)
+	}
+	if want := ErrorFromNTStatus(windows.STATUS_ACCESS_DENIED); e != want {
+		t.Errorf("ErrorFromError(%v) got 0x%08X, want 0x%08X", wrapped, uint32(e), uint32(want))
+	}
+
+	if _, ok := ErrorFromError(errors.New("not a Windows error")); ok {
+		t.Errorf("ErrorFromError unexpectedly succeeded on a non-Windows error")
+	}
+}
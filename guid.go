@@ -4,12 +4,169 @@
 package wingoes
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrInvalidGUID is returned by GUIDFromString when its input is not a
+	// validly-formatted GUID.
+	ErrInvalidGUID = errors.New("invalid GUID string")
 )
 
 func guidToString(guid GUID) string {
-	return fmt.Sprintf("{%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X}",
+	return "{" + GUIDStringNoBraces(guid) + "}"
+}
+
+// GUIDStringNoBraces returns guid in its canonical, upper-case form, but
+// without enclosing braces (ie, "XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX"). This
+// form is suitable for URLs, file names, and other contexts where braces are
+// undesirable. GUIDFromString accepts its output.
+func GUIDStringNoBraces(guid GUID) string {
+	return fmt.Sprintf("%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X",
 		guid.Data1, guid.Data2, guid.Data3,
 		guid.Data4[0], guid.Data4[1],
 		guid.Data4[2], guid.Data4[3], guid.Data4[4], guid.Data4[5], guid.Data4[6], guid.Data4[7])
 }
+
+// GUIDEqual returns true when a and b are identical.
+func GUIDEqual(a, b GUID) bool {
+	return a == b
+}
+
+// GUIDIsZero returns true when guid contains the zero value.
+func GUIDIsZero(guid GUID) bool {
+	return guid == GUID{}
+}
+
+const (
+	guidStrLenNoBraces = 36
+	guidStrLenBraces   = guidStrLenNoBraces + 2
+)
+
+// GUIDFromString parses s, a string containing a GUID. s may be specified
+// either as "{XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX}" or without the enclosing
+// braces. Hexadecimal digits may be upper or lower case. It returns
+// ErrInvalidGUID if s is not formatted correctly. Unlike
+// windows.GUIDFromString, this function is implemented in pure Go and is
+// available on all platforms.
+func GUIDFromString(s string) (guid GUID, _ error) {
+	switch len(s) {
+	case guidStrLenBraces:
+		if s[0] != '{' || s[len(s)-1] != '}' {
+			return guid, ErrInvalidGUID
+		}
+		s = s[1 : len(s)-1]
+	case guidStrLenNoBraces:
+	default:
+		return guid, ErrInvalidGUID
+	}
+
+	for _, i := range []int{8, 13, 18, 23} {
+		if s[i] != '-' {
+			return guid, ErrInvalidGUID
+		}
+	}
+
+	parseHex := func(h string, bitSize int) (uint64, bool) {
+		for _, c := range h {
+			// ParseUint accepts some things that we don't (like underscores), so
+			// we check each character ourselves first.
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return 0, false
+			}
+		}
+		v, err := strconv.ParseUint(h, 16, bitSize)
+		return v, err == nil
+	}
+
+	d1, ok := parseHex(s[0:8], 32)
+	if !ok {
+		return guid, ErrInvalidGUID
+	}
+	d2, ok := parseHex(s[9:13], 16)
+	if !ok {
+		return guid, ErrInvalidGUID
+	}
+	d3, ok := parseHex(s[14:18], 16)
+	if !ok {
+		return guid, ErrInvalidGUID
+	}
+
+	// Data4 consists of the final two groups of hex digits.
+	d4 := s[19:23] + s[24:]
+	for i := range guid.Data4 {
+		b, ok := parseHex(d4[i*2:i*2+2], 8)
+		if !ok {
+			return guid, ErrInvalidGUID
+		}
+		guid.Data4[i] = byte(b)
+	}
+
+	guid.Data1 = uint32(d1)
+	guid.Data2 = uint16(d2)
+	guid.Data3 = uint16(d3)
+	return guid, nil
+}
+
+// NewGUIDv4 generates a random (version 4) GUID as specified by RFC 4122,
+// using crypto/rand as its source of randomness. Unlike windows.GenerateGUID,
+// this function is available on all platforms.
+func NewGUIDv4() (guid GUID, _ error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return guid, err
+	}
+
+	// Set the version (4) and the variant (RFC 4122).
+	b[6] = (b[6] & 0x0F) | 0x40
+	b[8] = (b[8] & 0x3F) | 0x80
+
+	// RFC 4122 specifies that the first three fields are big-endian, so we
+	// decode them as such. This ensures that guid's string representation
+	// places the version and variant bits in their expected positions.
+	guid.Data1 = binary.BigEndian.Uint32(b[0:4])
+	guid.Data2 = binary.BigEndian.Uint16(b[4:6])
+	guid.Data3 = binary.BigEndian.Uint16(b[6:8])
+	copy(guid.Data4[:], b[8:])
+	return guid, nil
+}
+
+// TextGUID is a GUID that implements encoding.TextMarshaler and
+// encoding.TextUnmarshaler, making it suitable for use in structs that are
+// serialized to formats such as JSON. GUID itself intentionally implements
+// neither: on Windows it is an alias for windows.GUID and thus cannot have
+// methods of its own, so the only way to encode GUIDs identically on every
+// platform is to use TextGUID.
+type TextGUID GUID
+
+// MarshalText implements encoding.TextMarshaler. The resulting text is the
+// canonical, braced, upper-case form of tg.
+func (tg TextGUID) MarshalText() ([]byte, error) {
+	return []byte(guidToString(GUID(tg))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts any input that
+// is accepted by GUIDFromString.
+func (tg *TextGUID) UnmarshalText(text []byte) error {
+	guid, err := GUIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*tg = TextGUID(guid)
+	return nil
+}
+
+// String returns tg in its canonical, braced, upper-case form.
+func (tg TextGUID) String() string {
+	return guidToString(GUID(tg))
+}
+
+// StringNoBraces returns tg in its canonical, upper-case form, but without
+// enclosing braces.
+func (tg TextGUID) StringNoBraces() string {
+	return GUIDStringNoBraces(GUID(tg))
+}
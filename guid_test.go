@@ -0,0 +1,147 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package wingoes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type guidFromStringTestCase struct {
+	s      string
+	wantOK bool
+}
+
+var guidFromStringTests = []guidFromStringTestCase{
+	guidFromStringTestCase{"{00000000-0000-0000-C000-000000000046}", true},
+	guidFromStringTestCase{"0C733A30-2A1C-11CE-ADE5-00AA0044773D", true},
+	guidFromStringTestCase{"{0c733a30-2a1c-11ce-ade5-00aa0044773d}", true},
+	guidFromStringTestCase{"", false},
+	guidFromStringTestCase{"{0C733A30-2A1C-11CE-ADE5-00AA0044773D", false},
+	guidFromStringTestCase{"0C733A30-2A1C-11CE-ADE5-00AA0044773D}", false},
+	guidFromStringTestCase{"{0C733A30_2A1C-11CE-ADE5-00AA0044773D}", false},
+	guidFromStringTestCase{"{0C733A30-2A1C-11CE-ADE5-00AA0044773G}", false},
+	guidFromStringTestCase{"{+C733A30-2A1C-11CE-ADE5-00AA0044773D}", false},
+	guidFromStringTestCase{"{0C733A30-2A1C-11CE-ADE500AA0044773D0}", false},
+}
+
+func TestGUIDFromString(t *testing.T) {
+	for _, tc := range guidFromStringTests {
+		_, err := GUIDFromString(tc.s)
+		if (err == nil) != tc.wantOK {
+			t.Errorf("GUIDFromString(%q) got error %v, want ok %v", tc.s, err, tc.wantOK)
+		}
+	}
+
+	const canonical = "{0C733A30-2A1C-11CE-ADE5-00AA0044773D}"
+	guid, err := GUIDFromString(canonical)
+	if err != nil {
+		t.Fatalf("GUIDFromString(%q) error: %v", canonical, err)
+	}
+	if got := guidToString(guid); got != canonical {
+		t.Errorf("round trip got %q, want %q", got, canonical)
+	}
+
+	const noBraces = "0C733A30-2A1C-11CE-ADE5-00AA0044773D"
+	if got := GUIDStringNoBraces(guid); got != noBraces {
+		t.Errorf("GUIDStringNoBraces got %q, want %q", got, noBraces)
+	}
+	if got := TextGUID(guid).StringNoBraces(); got != noBraces {
+		t.Errorf("TextGUID.StringNoBraces got %q, want %q", got, noBraces)
+	}
+	if rt, err := GUIDFromString(noBraces); err != nil || rt != guid {
+		t.Errorf("GUIDFromString(%q) got (%v, %v), want (%v, nil)", noBraces, rt, err, guid)
+	}
+	want := GUID{Data1: 0x0C733A30, Data2: 0x2A1C, Data3: 0x11CE, Data4: [8]byte{0xAD, 0xE5, 0x00, 0xAA, 0x00, 0x44, 0x77, 0x3D}}
+	if guid != want {
+		t.Errorf("GUIDFromString(%q) got %v, want %v", canonical, guid, want)
+	}
+}
+
+func TestGUIDEqualAndIsZero(t *testing.T) {
+	guid1, err := GUIDFromString("{0C733A30-2A1C-11CE-ADE5-00AA0044773D}")
+	if err != nil {
+		t.Fatalf("GUIDFromString error: %v", err)
+	}
+	guid2 := guid1
+	guid2.Data4[7]++
+
+	if !GUIDEqual(guid1, guid1) {
+		t.Errorf("GUIDEqual(%v, %v) returned false", guid1, guid1)
+	}
+	if GUIDEqual(guid1, guid2) {
+		t.Errorf("GUIDEqual(%v, %v) returned true", guid1, guid2)
+	}
+	if !GUIDIsZero(GUID{}) {
+		t.Errorf("GUIDIsZero(GUID{}) returned false")
+	}
+	if GUIDIsZero(guid1) {
+		t.Errorf("GUIDIsZero(%v) returned true", guid1)
+	}
+}
+
+func TestTextGUID(t *testing.T) {
+	type config struct {
+		ID TextGUID
+	}
+
+	const canonical = "{0C733A30-2A1C-11CE-ADE5-00AA0044773D}"
+	guid, err := GUIDFromString(canonical)
+	if err != nil {
+		t.Fatalf("GUIDFromString(%q) error: %v", canonical, err)
+	}
+
+	enc, err := json.Marshal(config{ID: TextGUID(guid)})
+	if err != nil {
+		t.Fatalf("json.Marshal error: %v", err)
+	}
+	if got, want := string(enc), `{"ID":"`+canonical+`"}`; got != want {
+		t.Errorf("json.Marshal got %s, want %s", got, want)
+	}
+
+	var dec config
+	if err := json.Unmarshal([]byte(`{"ID":"0c733a30-2a1c-11ce-ade5-00aa0044773d"}`), &dec); err != nil {
+		t.Fatalf("json.Unmarshal error: %v", err)
+	}
+	if GUID(dec.ID) != guid {
+		t.Errorf("json.Unmarshal got %v, want %v", dec.ID, canonical)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ID":"bogus"}`), &dec); err == nil {
+		t.Errorf("json.Unmarshal unexpectedly succeeded on invalid GUID")
+	}
+
+	// A plain GUID must encode identically on all platforms (ie, as a struct),
+	// since it cannot implement encoding.TextMarshaler on Windows.
+	enc, err = json.Marshal(struct{ ID GUID }{ID: guid})
+	if err != nil {
+		t.Fatalf("json.Marshal error: %v", err)
+	}
+	if got, want := string(enc), `{"ID":{"Data1":208878128,"Data2":10780,"Data3":4558,"Data4":[173,229,0,170,0,68,119,61]}}`; got != want {
+		t.Errorf("json.Marshal of GUID got %s, want %s", got, want)
+	}
+}
+
+func TestNewGUIDv4(t *testing.T) {
+	guid1, err := NewGUIDv4()
+	if err != nil {
+		t.Fatalf("NewGUIDv4 error: %v", err)
+	}
+
+	s := guidToString(guid1)
+	if s[15] != '4' {
+		t.Errorf("GUID %s does not have version 4", s)
+	}
+	if v := s[20]; v != '8' && v != '9' && v != 'A' && v != 'B' {
+		t.Errorf("GUID %s does not have RFC 4122 variant", s)
+	}
+
+	guid2, err := NewGUIDv4()
+	if err != nil {
+		t.Fatalf("NewGUIDv4 error: %v", err)
+	}
+	if guid1 == guid2 {
+		t.Errorf("NewGUIDv4 generated identical GUIDs %s", s)
+	}
+}
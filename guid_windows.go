@@ -10,6 +10,10 @@ import (
 	"golang.org/x/sys/windows"
 )
 
+// GUID is an alias for windows.GUID. Because it is an alias, it cannot have
+// methods of its own beyond those provided by windows.GUID. Use the GUID*
+// functions in this package (such as GUIDEqual) for portable operations on
+// GUIDs.
 type GUID = windows.GUID
 
 // MustGetGUID parses s, a string containing a GUID and returns a pointer to the
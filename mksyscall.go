@@ -0,0 +1,11 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package wingoes
+
+//go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go mksyscall.go
+//go:generate go run golang.org/x/tools/cmd/goimports -w zsyscall_windows.go
+
+//sys getProductInfo(osMajor uint32, osMinor uint32, spMajor uint32, spMinor uint32, productType *ProductType) (err error) [int32(failretval)==0] = kernel32.GetProductInfo
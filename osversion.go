@@ -7,6 +7,7 @@
 package wingoes
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
@@ -14,6 +15,10 @@ import (
 	"golang.org/x/sys/windows/registry"
 )
 
+// ErrUnsupportedOSVersion is returned (possibly wrapped) by functionality that
+// requires a newer version of Windows than the one currently running.
+var ErrUnsupportedOSVersion = errors.New("unsupported version of Windows")
+
 var (
 	verOnce sync.Once
 	verInfo osVersionInfo // must access via getVersionInfo()
@@ -26,6 +31,7 @@ type osVersionInfo struct {
 	minor       uint32
 	build       uint32
 	servicePack uint16
+	ubr         uint32
 	str         string
 	isDC        bool
 	isServer    bool
@@ -53,6 +59,7 @@ func getVersionInfo() *osVersionInfo {
 		// UBR is only available on Windows 10 and 11 (MajorVersion == 10).
 		if osv.MajorVersion == 10 {
 			if ubr, err := getUBR(); err == nil {
+				verInfo.ubr = ubr
 				verInfo.str = fmt.Sprintf("%s.%d", verInfo.str, ubr)
 			}
 		}
@@ -81,6 +88,40 @@ func getUBR() (uint32, error) {
 	return uint32(val), nil
 }
 
+// OSVersionInfo describes the version of Windows running on the current machine.
+type OSVersionInfo struct {
+	Major            uint32
+	Minor            uint32
+	Build            uint32
+	UBR              uint32 // Update build revision; only available on Windows 10 and 11, otherwise 0.
+	ServicePackMajor uint16
+}
+
+// String returns osv in dotted-decimal form. The version string contains 3
+// components when osv.UBR is 0, and 4 components otherwise.
+func (osv OSVersionInfo) String() string {
+	if osv.UBR == 0 {
+		return fmt.Sprintf("%d.%d.%d", osv.Major, osv.Minor, osv.Build)
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", osv.Major, osv.Minor, osv.Build, osv.UBR)
+}
+
+// OSVersion returns the version of Windows running on the current machine. It
+// is obtained via RtlGetVersion, and is thus not subject to the compatibility
+// shims that cause GetVersionEx to report different versions depending on the
+// contents of the application manifest. Since RtlGetVersion cannot fail,
+// neither can OSVersion.
+func OSVersion() OSVersionInfo {
+	vi := getVersionInfo()
+	return OSVersionInfo{
+		Major:            vi.major,
+		Minor:            vi.minor,
+		Build:            vi.build,
+		UBR:              vi.ubr,
+		ServicePackMajor: vi.servicePack,
+	}
+}
+
 // GetOSVersionString returns the Windows version of the current machine in
 // dotted-decimal form. The version string contains 3 components on Windows 7
 // and 8.x, and 4 components on Windows 10 and 11.
@@ -89,12 +130,22 @@ func GetOSVersionString() string {
 }
 
 // IsWinServer returns true if and only if this computer's version of Windows is
-// a server edition.
+// a server edition. Domain controllers are always considered to be servers.
+// The product type is obtained via RtlGetVersion, so the result is not
+// affected by the application manifest, and no VerifyVersionInfo condition
+// masks are required.
 func IsWinServer() bool {
 	return getVersionInfo().isServer
 }
 
-// IsWinDomainController returs true if this computer's version of Windows is
+// IsWindowsServer is equivalent to IsWinServer, and never returns an error.
+//
+// Deprecated: Use IsWinServer instead.
+func IsWindowsServer() (bool, error) {
+	return IsWinServer(), nil
+}
+
+// IsWinDomainController returns true if this computer's version of Windows is
 // configured to act as a domain controller.
 func IsWinDomainController() bool {
 	return getVersionInfo().isDC
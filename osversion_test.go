@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"golang.org/x/sys/windows"
 )
 
 type isVerGETestCase struct {
@@ -63,3 +65,62 @@ func TestUBR(t *testing.T) {
 	}
 	t.Errorf("getUBR error: %v", err)
 }
+
+func TestOSVersion(t *testing.T) {
+	osv := OSVersion()
+	if got, want := osv.String(), GetOSVersionString(); got != want {
+		t.Errorf("OSVersion().String() got %q, want %q", got, want)
+	}
+	if IsWin10OrGreater() != (osv.Major >= 10) {
+		t.Errorf("OSVersion major version %d inconsistent with IsWin10OrGreater", osv.Major)
+	}
+}
+
+func TestProductTypeString(t *testing.T) {
+	testCases := []struct {
+		pt        ProductType
+		wantStr   string
+		wantKnown bool
+	}{
+		{PRODUCT_PROFESSIONAL, "Pro", true},
+		{PRODUCT_DATACENTER_SERVER_CORE, "Server Datacenter (Core)", true},
+		{ProductType(0x00001234), "ProductType(0x00001234)", false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.pt.String(); got != tc.wantStr {
+			t.Errorf("ProductType(0x%08X).String() got %q, want %q", uint32(tc.pt), got, tc.wantStr)
+		}
+		if got := tc.pt.IsKnown(); got != tc.wantKnown {
+			t.Errorf("ProductType(0x%08X).IsKnown() got %v, want %v", uint32(tc.pt), got, tc.wantKnown)
+		}
+	}
+}
+
+func TestGetProductType(t *testing.T) {
+	pt, err := GetProductType()
+	if err != nil {
+		t.Fatalf("GetProductType error: %v", err)
+	}
+	// GetProductInfo only reports PRODUCT_UNDEFINED when its version arguments
+	// are invalid, which would mean that we passed it the wrong ones.
+	if pt == PRODUCT_UNDEFINED {
+		t.Errorf("GetProductType got %v", pt)
+	}
+	t.Logf("Product type: %s", pt)
+}
+
+func TestIsWinServer(t *testing.T) {
+	isServer := IsWinServer()
+	wantServer := windows.RtlGetVersion().ProductType != _VER_NT_WORKSTATION
+	if isServer != wantServer {
+		t.Errorf("IsWinServer got %v, want %v", isServer, wantServer)
+	}
+	if IsWinDomainController() && !isServer {
+		t.Errorf("IsWinServer returned false on a domain controller")
+	}
+
+	if got, err := IsWindowsServer(); got != isServer || err != nil {
+		t.Errorf("IsWindowsServer got (%v, %v), want (%v, nil)", got, err, isServer)
+	}
+}
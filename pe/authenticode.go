@@ -0,0 +1,352 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrNotPKCS7 is returned when attempting to parse an AuthenticodeCert
+	// whose type is not WIN_CERT_TYPE_PKCS_SIGNED_DATA, or whose data does not
+	// contain PKCS#7 signed data.
+	ErrNotPKCS7 = errors.New("certificate does not contain PKCS#7 signed data")
+)
+
+var (
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	// The following OIDs identify unauthenticated attributes that contain
+	// timestamps. The former is a legacy (PKCS#9) countersignature, while the
+	// latter is an RFC 3161 timestamp in Microsoft's encapsulation.
+	oidCounterSignature   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 6}
+	oidMSRFC3161Timestamp = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 3, 3, 1}
+	// The following OIDs identify the Authenticode-specific content of a
+	// signature, as well as the two versions of page hashes that it may
+	// contain. Version 1 page hashes use SHA-1, while version 2 use SHA-256.
+	oidSPCIndirectData = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 4}
+	oidSPCPEImageData  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 15}
+	oidSPCPageHashesV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 3, 1}
+	oidSPCPageHashesV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 3, 2}
+)
+
+// spcSerializedObjectClassID identifies the SpcSerializedObject that contains
+// an Authenticode signature's page hashes.
+var spcSerializedObjectClassID = []byte{
+	0xA6, 0xB5, 0x86, 0xD5, 0xB4, 0xA1, 0x24, 0x66,
+	0xAE, 0x05, 0xA2, 0x17, 0xDA, 0x8E, 0x60, 0xD6,
+}
+
+// The following types describe the subset of PKCS#7 (RFC 2315) that we need to
+// understand in order to summarize an Authenticode signature.
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7RawCertificates struct {
+	Raw asn1.RawContent
+}
+
+type pkcs7SignedData struct {
+	Version          int                        `asn1:"default:1"`
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     pkcs7RawCertificates   `asn1:"optional,tag:0"`
+	CRLs             []pkix.CertificateList `asn1:"optional,tag:1"`
+	SignerInfos      []pkcs7SignerInfo      `asn1:"set"`
+}
+
+type pkcs7IssuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type pkcs7Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type pkcs7SignerInfo struct {
+	Version                   int `asn1:"default:1"`
+	IssuerAndSerialNumber     pkcs7IssuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   []pkcs7Attribute `asn1:"optional,omitempty,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes []pkcs7Attribute `asn1:"optional,omitempty,tag:1"`
+}
+
+// The following types describe the Authenticode-specific content of a
+// signature, as specified by Microsoft's "Windows Authenticode Portable
+// Executable Signature Format".
+
+type spcAttributeTypeAndOptionalValue struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"optional"`
+}
+
+type spcDigestInfo struct {
+	DigestAlgorithm pkix.AlgorithmIdentifier
+	Digest          []byte
+}
+
+type spcIndirectDataContent struct {
+	Data          spcAttributeTypeAndOptionalValue
+	MessageDigest spcDigestInfo
+}
+
+type spcPEImageData struct {
+	Flags asn1.BitString `asn1:"optional"`
+	File  asn1.RawValue  `asn1:"optional,explicit,tag:0"`
+}
+
+type spcSerializedObject struct {
+	ClassID        []byte
+	SerializedData []byte
+}
+
+type spcPageHashes struct {
+	Type   asn1.ObjectIdentifier
+	Hashes asn1.RawValue `asn1:"set"`
+}
+
+// AuthenticodeSigner summarizes one signer of an Authenticode signature.
+type AuthenticodeSigner struct {
+	// Certificate is the signer's certificate. It is nil when the signer's
+	// certificate is not embedded in the signature.
+	Certificate *x509.Certificate
+	// HasTimestamp is true when the signature has been countersigned by a
+	// timestamping authority.
+	HasTimestamp bool
+}
+
+// Subject returns the subject of the signer's certificate, or the empty string
+// if the certificate is not available.
+func (s *AuthenticodeSigner) Subject() string {
+	if s.Certificate == nil {
+		return ""
+	}
+	return s.Certificate.Subject.String()
+}
+
+// Issuer returns the issuer of the signer's certificate, or the empty string
+// if the certificate is not available.
+func (s *AuthenticodeSigner) Issuer() string {
+	if s.Certificate == nil {
+		return ""
+	}
+	return s.Certificate.Issuer.String()
+}
+
+func (ac *AuthenticodeCert) parseSignedData() (*pkcs7SignedData, error) {
+	if ac.Type() != WIN_CERT_TYPE_PKCS_SIGNED_DATA {
+		return nil, ErrNotPKCS7
+	}
+
+	// ac.data may include trailing padding, so we ignore any remaining bytes.
+	var ci pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(ac.data, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, ErrNotPKCS7
+	}
+
+	sd := new(pkcs7SignedData)
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, sd); err != nil {
+		return nil, err
+	}
+
+	return sd, nil
+}
+
+// Certificates returns all X.509 certificates that are embedded in ac. ac must
+// be of type WIN_CERT_TYPE_PKCS_SIGNED_DATA, otherwise ErrNotPKCS7 is returned.
+func (ac *AuthenticodeCert) Certificates() ([]*x509.Certificate, error) {
+	sd, err := ac.parseSignedData()
+	if err != nil {
+		return nil, err
+	}
+
+	return sd.certificates()
+}
+
+func (sd *pkcs7SignedData) certificates() ([]*x509.Certificate, error) {
+	if len(sd.Certificates.Raw) == 0 {
+		return nil, nil
+	}
+
+	var certs asn1.RawValue
+	if _, err := asn1.Unmarshal(sd.Certificates.Raw, &certs); err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificates(certs.Bytes)
+}
+
+// Signers returns a summary of each signer of ac. ac must be of type
+// WIN_CERT_TYPE_PKCS_SIGNED_DATA, otherwise ErrNotPKCS7 is returned.
+func (ac *AuthenticodeCert) Signers() ([]AuthenticodeSigner, error) {
+	sd, err := ac.parseSignedData()
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := sd.certificates()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AuthenticodeSigner, 0, len(sd.SignerInfos))
+	for _, si := range sd.SignerInfos {
+		var signer AuthenticodeSigner
+
+		ias := si.IssuerAndSerialNumber
+		for _, cert := range certs {
+			if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, ias.IssuerName.FullBytes) {
+				signer.Certificate = cert
+				break
+			}
+		}
+
+		for _, attr := range si.UnauthenticatedAttributes {
+			if attr.Type.Equal(oidCounterSignature) || attr.Type.Equal(oidMSRFC3161Timestamp) {
+				signer.HasTimestamp = true
+				break
+			}
+		}
+
+		result = append(result, signer)
+	}
+
+	return result, nil
+}
+
+// PageHash is the digest of a single page of a PE binary, as recorded in its
+// Authenticode signature.
+type PageHash struct {
+	// Offset is the file offset of the beginning of the page.
+	Offset uint32
+	// Digest is the hash of the page, computed using SHA-1 or SHA-256
+	// (depending on the length of Digest). The final entry marks the end of
+	// the hashed data; its Digest consists entirely of zeros.
+	Digest []byte
+}
+
+// PageHashes returns the page hashes embedded in ac, which permit verification
+// of a binary's integrity at page granularity. ac must be of type
+// WIN_CERT_TYPE_PKCS_SIGNED_DATA, otherwise ErrNotPKCS7 is returned. It returns
+// ErrNotPresent if ac does not contain page hashes. Page hashes contained by
+// nested signatures are not included.
+func (ac *AuthenticodeCert) PageHashes() ([]PageHash, error) {
+	sd, err := ac.parseSignedData()
+	if err != nil {
+		return nil, err
+	}
+	if !sd.ContentInfo.ContentType.Equal(oidSPCIndirectData) {
+		return nil, ErrNotPresent
+	}
+
+	var idc spcIndirectDataContent
+	if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &idc); err != nil {
+		return nil, err
+	}
+	if !idc.Data.Type.Equal(oidSPCPEImageData) || len(idc.Data.Value.FullBytes) == 0 {
+		return nil, ErrNotPresent
+	}
+
+	var pid spcPEImageData
+	if _, err := asn1.Unmarshal(idc.Data.Value.FullBytes, &pid); err != nil {
+		return nil, err
+	}
+
+	if len(pid.File.Bytes) == 0 {
+		return nil, ErrNotPresent
+	}
+
+	// pid.File contains an SpcLink, which is a CHOICE. Page hashes are only
+	// present when it is the moniker alternative, which is tagged [1].
+	var link asn1.RawValue
+	if _, err := asn1.Unmarshal(pid.File.Bytes, &link); err != nil {
+		return nil, err
+	}
+	if link.Class != asn1.ClassContextSpecific || link.Tag != 1 {
+		return nil, ErrNotPresent
+	}
+
+	var obj spcSerializedObject
+	if _, err := asn1.UnmarshalWithParams(link.FullBytes, &obj, "tag:1"); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(obj.ClassID, spcSerializedObjectClassID) {
+		return nil, ErrNotPresent
+	}
+
+	var attrs []spcPageHashes
+	if _, err := asn1.UnmarshalWithParams(obj.SerializedData, &attrs, "set"); err != nil {
+		return nil, err
+	}
+
+	var result []PageHash
+	for _, attr := range attrs {
+		var digestLen int
+		switch {
+		case attr.Type.Equal(oidSPCPageHashesV1):
+			digestLen = sha1.Size
+		case attr.Type.Equal(oidSPCPageHashesV2):
+			digestLen = sha256.Size
+		default:
+			continue
+		}
+
+		for rest := attr.Hashes.Bytes; len(rest) > 0; {
+			var blob []byte
+			if rest, err = asn1.Unmarshal(rest, &blob); err != nil {
+				return nil, err
+			}
+
+			hashes, err := parsePageHashes(blob, digestLen)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, hashes...)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotPresent
+	}
+
+	return result, nil
+}
+
+// parsePageHashes parses blob, which is an array of entries that each consist
+// of a little-endian 32-bit file offset followed by a digest of digestLen bytes.
+func parsePageHashes(blob []byte, digestLen int) ([]PageHash, error) {
+	entryLen := 4 + digestLen
+	if len(blob)%entryLen != 0 {
+		return nil, fmt.Errorf("%w: page hash blob length %d is not a multiple of %d", ErrBadLength, len(blob), entryLen)
+	}
+
+	result := make([]PageHash, 0, len(blob)/entryLen)
+	for ; len(blob) > 0; blob = blob[entryLen:] {
+		result = append(result, PageHash{
+			Offset: binary.LittleEndian.Uint32(blob),
+			Digest: bytes.Clone(blob[4:entryLen]),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// ParseOptions specifies options for ParseFiles.
+type ParseOptions struct {
+	// Concurrency is the maximum number of files that ParseFiles parses
+	// simultaneously. When zero or negative, runtime.GOMAXPROCS(0) is used.
+	Concurrency int
+	// Directories lists the data directory entries that ParseFiles parses
+	// eagerly. Their results are retained by each resulting PEHeaders, so
+	// subsequent calls to its DataDirectoryEntry method for any of these
+	// indices do not read the file again. Entries that are not present in a
+	// file are not considered to be errors.
+	Directories []DataDirectoryIndex
+}
+
+// ParseFiles concurrently parses the headers of each file in paths, as if by
+// NewPEFromFileName. It returns two slices that parallel paths: for each file,
+// either its element of the first slice contains its *PEHeaders, or its element
+// of the second slice contains the error that prevented it from being parsed.
+// An error encountered while eagerly parsing any of opts.Directories also
+// causes the file to fail. The caller must Close every non-nil *PEHeaders.
+func ParseFiles(paths []string, opts ParseOptions) ([]*PEHeaders, []error) {
+	results := make([]*PEHeaders, len(paths))
+	errs := make([]error, len(paths))
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	concurrency = min(concurrency, len(paths))
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				results[idx], errs[idx] = parseFile(paths[idx], opts.Directories)
+			}
+		}()
+	}
+
+	for i := range paths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return results, errs
+}
+
+func parseFile(path string, dirs []DataDirectoryIndex) (*PEHeaders, error) {
+	peh, err := NewPEFromFileName(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return peh, nil
+	}
+
+	cache := make(map[DataDirectoryIndex]dataDirectoryResult, len(dirs))
+	for _, idx := range dirs {
+		v, err := peh.dataDirectoryEntry(idx)
+		if err != nil && err != ErrNotPresent {
+			peh.Close()
+			return nil, err
+		}
+		cache[idx] = dataDirectoryResult{value: v, err: err}
+	}
+
+	peh.dirCache = cache
+	return peh, nil
+}
+
+// cloneDataDirectoryValue returns a deep copy of v, which must be a value
+// returned by dataDirectoryEntry, so that callers of DataDirectoryEntry never
+// share any mutable state with dirCache.
+func cloneDataDirectoryValue(v any) any {
+	switch tv := v.(type) {
+	case []AuthenticodeCert:
+		certs := slices.Clone(tv)
+		for i := range certs {
+			certs[i].data = bytes.Clone(certs[i].data)
+		}
+		return certs
+	case []IMAGE_DEBUG_DIRECTORY:
+		return slices.Clone(tv)
+	case *IATInfo:
+		iat := *tv
+		return &iat
+	default:
+		// Everything else is returned by value.
+		return v
+	}
+}
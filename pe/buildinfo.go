@@ -0,0 +1,84 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	dpe "debug/pe"
+	"io"
+	"runtime/debug"
+)
+
+// goBuildInfoMagic marks the beginning of the build information that the Go
+// linker embeds in every binary that it produces.
+var goBuildInfoMagic = []byte("\xff Go buildinf:")
+
+const (
+	// goBuildInfoAlign is the alignment of goBuildInfoMagic.
+	goBuildInfoAlign = 16
+	// goBuildInfoMaxOffset bounds the distance from the beginning of the data
+	// section within which we search for goBuildInfoMagic, matching the
+	// behavior of debug/buildinfo.
+	goBuildInfoMaxOffset = 64 * 1024
+	// imageSCNAlign32Bytes is IMAGE_SCN_ALIGN_32BYTES.
+	imageSCNAlign32Bytes = 0x00600000
+)
+
+// hasGoBuildInfo returns true when nfo's data section begins with Go build
+// information. As with debug/buildinfo, the data section is considered to be
+// the first section that contains writable, initialized data.
+func (nfo *PEHeaders) hasGoBuildInfo() (bool, error) {
+	const wantCharacteristics = dpe.IMAGE_SCN_CNT_INITIALIZED_DATA | dpe.IMAGE_SCN_MEM_READ | dpe.IMAGE_SCN_MEM_WRITE
+
+	for i, s := range nfo.sections {
+		if s.VirtualAddress == 0 || s.SizeOfRawData == 0 || s.Characteristics&^imageSCNAlign32Bytes != wantCharacteristics {
+			continue
+		}
+
+		sr, err := nfo.SectionReader(i)
+		if err != nil {
+			return false, err
+		}
+
+		buf := make([]byte, min(sr.Size(), goBuildInfoMaxOffset))
+		n, err := readFull(sr, buf)
+		if err != nil && err != ErrBadLength {
+			return false, err
+		}
+		buf = buf[:n]
+
+		for off := 0; off+len(goBuildInfoMagic) <= len(buf); off += goBuildInfoAlign {
+			if bytes.Equal(buf[off:off+len(goBuildInfoMagic)], goBuildInfoMagic) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	return false, nil
+}
+
+// GoBuildInfo returns the build information that the Go toolchain embeds in
+// binaries that it produces, including the Go version, main module path and
+// version, dependencies, and build settings. It returns ErrNotPresent if nfo
+// was not built by Go, and ErrUnavailableInModule if nfo was not created from
+// a file. (To obtain build information for the current process, use
+// runtime/debug.ReadBuildInfo.)
+func (nfo *PEHeaders) GoBuildInfo() (*debug.BuildInfo, error) {
+	if _, ok := nfo.r.(*peFile); !ok {
+		return nil, ErrUnavailableInModule
+	}
+
+	ok, err := nfo.hasGoBuildInfo()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotPresent
+	}
+
+	return buildinfo.Read(io.NewSectionReader(nfo.r, 0, int64(nfo.r.Limit())))
+}
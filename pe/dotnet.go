@@ -0,0 +1,253 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotStrongNamed is returned by StrongNameSignature when a managed binary
+// does not carry a strong name signature.
+var ErrNotStrongNamed = errors.New("assembly is not strong-name signed")
+
+// comImageFlagsStrongNameSigned is COMIMAGE_FLAGS_STRONGNAMESIGNED, which is
+// set in the CLR header of assemblies whose strong name signature has been
+// computed (as opposed to merely reserved, as with delay-signed assemblies).
+const comImageFlagsStrongNameSigned = 0x00000008
+
+// The following constants are from ECMA-335, Partition II, section 24.2.1
+const (
+	metadataSignature        = uint32(0x424A5342) // "BSJB", little-endian
+	maxMetadataVersionLen    = 255
+	maxMetadataStreamNameLen = 32
+)
+
+// _IMAGE_COR20_HEADER is the CLR runtime header referenced by the
+// IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR data directory entry.
+type _IMAGE_COR20_HEADER struct {
+	Cb                      uint32
+	MajorRuntimeVersion     uint16
+	MinorRuntimeVersion     uint16
+	MetaData                DataDirectoryEntry
+	Flags                   uint32
+	EntryPointTokenOrRVA    uint32
+	Resources               DataDirectoryEntry
+	StrongNameSignature     DataDirectoryEntry
+	CodeManagerTable        DataDirectoryEntry
+	VTableFixups            DataDirectoryEntry
+	ExportAddressTableJumps DataDirectoryEntry
+	ManagedNativeHeader     DataDirectoryEntry
+}
+
+// _METADATA_ROOT_HEADER is the fixed-size portion of the metadata root that
+// precedes its variable-length version string.
+type _METADATA_ROOT_HEADER struct {
+	Signature     uint32
+	MajorVersion  uint16
+	MinorVersion  uint16
+	Reserved      uint32
+	VersionLength uint32
+}
+
+// metadataStream describes one of the streams listed in the metadata root.
+type metadataStream struct {
+	name   string
+	offset uint32
+	size   uint32
+}
+
+// metadataRoot contains the parsed metadata root of a managed binary.
+type metadataRoot struct {
+	// r covers the entire metadata directory. Stream offsets are relative to
+	// the beginning of r.
+	r       *io.SectionReader
+	version string
+	streams []metadataStream
+}
+
+// rvaReader returns a reader over the size bytes located at rva.
+func (nfo *PEHeaders) rvaReader(rva, size uint32) (*io.SectionReader, error) {
+	off, ok := resolveRVA(nfo, rva)
+	if !ok {
+		return nil, ErrResolvingFileRVA
+	}
+	if !rangeFits(nfo.r, off, size) {
+		return nil, ErrInvalidBinary
+	}
+
+	return io.NewSectionReader(nfo.r, int64(off), int64(size)), nil
+}
+
+// cor20Header returns nfo's CLR runtime header. It returns ErrNotPresent if nfo
+// is not a managed binary.
+func (nfo *PEHeaders) cor20Header() (*_IMAGE_COR20_HEADER, error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR)
+	if err != nil {
+		return nil, err
+	}
+
+	rva, ok := resolveRVA(nfo, dde.VirtualAddress)
+	if !ok {
+		return nil, ErrResolvingFileRVA
+	}
+
+	return readStruct[_IMAGE_COR20_HEADER](nfo.r, rva)
+}
+
+// metadataRoot locates and parses nfo's metadata root. It returns ErrNotPresent
+// if nfo is not a managed binary.
+func (nfo *PEHeaders) metadataRoot() (*metadataRoot, error) {
+	cor20, err := nfo.cor20Header()
+	if err != nil {
+		return nil, err
+	}
+	if cor20.MetaData.VirtualAddress == 0 || cor20.MetaData.Size == 0 {
+		return nil, fmt.Errorf("%w: CLR header does not reference any metadata", ErrInvalidBinary)
+	}
+
+	sr, err := nfo.rvaReader(cor20.MetaData.VirtualAddress, cor20.MetaData.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr _METADATA_ROOT_HEADER
+	if err := binaryRead(sr, &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Signature != metadataSignature {
+		return nil, fmt.Errorf("%w: bad metadata signature 0x%08X", ErrInvalidBinary, hdr.Signature)
+	}
+	if hdr.VersionLength > maxMetadataVersionLen+1 {
+		return nil, fmt.Errorf("%w: metadata version string length %d is too long", ErrInvalidBinary, hdr.VersionLength)
+	}
+
+	// The version string is NUL-padded out to VersionLength bytes.
+	versionBytes := make([]byte, hdr.VersionLength)
+	if _, err := readFull(sr, versionBytes); err != nil {
+		return nil, err
+	}
+	if i := bytes.IndexByte(versionBytes, 0); i >= 0 {
+		versionBytes = versionBytes[:i]
+	}
+
+	var flagsAndCount struct {
+		Flags   uint16
+		Streams uint16
+	}
+	if err := binaryRead(sr, &flagsAndCount); err != nil {
+		return nil, err
+	}
+
+	result := &metadataRoot{
+		r:       sr,
+		version: string(versionBytes),
+		streams: make([]metadataStream, 0, flagsAndCount.Streams),
+	}
+
+	br := bufio.NewReader(sr)
+	for i := 0; i < int(flagsAndCount.Streams); i++ {
+		var sh struct {
+			Offset uint32
+			Size   uint32
+		}
+		if err := binaryRead(br, &sh); err != nil {
+			return nil, err
+		}
+
+		name, err := readMetadataStreamName(br)
+		if err != nil {
+			return nil, err
+		}
+
+		if uint64(sh.Offset)+uint64(sh.Size) > uint64(cor20.MetaData.Size) {
+			return nil, fmt.Errorf("%w: metadata stream %q lies outside of the metadata directory", ErrInvalidBinary, name)
+		}
+
+		result.streams = append(result.streams, metadataStream{name: name, offset: sh.Offset, size: sh.Size})
+	}
+
+	return result, nil
+}
+
+// readMetadataStreamName reads a stream name from a metadata stream header.
+// Names are NUL-terminated and padded to the next 4-byte boundary.
+func readMetadataStreamName(br *bufio.Reader) (string, error) {
+	var name []byte
+	for {
+		var chunk [4]byte
+		if _, err := readFull(br, chunk[:]); err != nil {
+			if err == io.EOF {
+				err = ErrBadLength
+			}
+			return "", err
+		}
+
+		if i := bytes.IndexByte(chunk[:], 0); i >= 0 {
+			name = append(name, chunk[:i]...)
+			return string(name), nil
+		}
+
+		name = append(name, chunk[:]...)
+		if len(name) >= maxMetadataStreamNameLen {
+			return "", fmt.Errorf("%w: metadata stream name is too long", ErrInvalidBinary)
+		}
+	}
+}
+
+// stream returns a reader over the contents of the metadata stream named name.
+// It returns ErrNotPresent if no such stream exists.
+func (mr *metadataRoot) stream(name string) (*io.SectionReader, error) {
+	for _, s := range mr.streams {
+		if s.name == name {
+			return io.NewSectionReader(mr.r, int64(s.offset), int64(s.size)), nil
+		}
+	}
+
+	return nil, ErrNotPresent
+}
+
+// DotNetRuntimeVersion returns the version string recorded in the metadata
+// root of a managed (.NET) binary, such as "v4.0.30319". This string identifies
+// the version of the runtime that the binary targets. It returns ErrNotPresent
+// if nfo is a native binary.
+func (nfo *PEHeaders) DotNetRuntimeVersion() (string, error) {
+	mr, err := nfo.metadataRoot()
+	if err != nil {
+		return "", err
+	}
+
+	return mr.version, nil
+}
+
+// StrongNameSignature returns the strong name signature of a managed (.NET)
+// binary, as referenced by its CLR header. It returns ErrNotPresent if nfo is a
+// native binary, and ErrNotStrongNamed if nfo is a managed binary that is
+// either unsigned or delay-signed.
+func (nfo *PEHeaders) StrongNameSignature() ([]byte, error) {
+	cor20, err := nfo.cor20Header()
+	if err != nil {
+		return nil, err
+	}
+
+	sig := cor20.StrongNameSignature
+	if cor20.Flags&comImageFlagsStrongNameSigned == 0 || sig.VirtualAddress == 0 || sig.Size == 0 {
+		return nil, ErrNotStrongNamed
+	}
+
+	sr, err := nfo.rvaReader(sig.VirtualAddress, sig.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, sig.Size)
+	if _, err := readFull(sr, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
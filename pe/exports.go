@@ -0,0 +1,229 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+// ErrNotForwarder is returned by (*PEHeaders).ResolveForwarder when the
+// requested export is implemented by the binary itself.
+var ErrNotForwarder = errors.New("export is not a forwarder")
+
+// maxExportNameLen bounds the length of strings that we are willing to read
+// from the export directory, in case the binary is corrupt or malicious.
+const maxExportNameLen = 4096
+
+type _IMAGE_EXPORT_DIRECTORY struct {
+	Characteristics       uint32
+	TimeDateStamp         uint32
+	MajorVersion          uint16
+	MinorVersion          uint16
+	Name                  uint32
+	Base                  uint32
+	NumberOfFunctions     uint32
+	NumberOfNames         uint32
+	AddressOfFunctions    uint32
+	AddressOfNames        uint32
+	AddressOfNameOrdinals uint32
+}
+
+// exportDirectory returns nfo's export directory, as well as the data directory
+// entry that references it.
+func (nfo *PEHeaders) exportDirectory() (*_IMAGE_EXPORT_DIRECTORY, DataDirectoryEntry, error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_EXPORT)
+	if err != nil {
+		return nil, dde, err
+	}
+
+	rva, ok := resolveRVA(nfo, dde.VirtualAddress)
+	if !ok {
+		return nil, dde, ErrResolvingFileRVA
+	}
+
+	ed, err := readStruct[_IMAGE_EXPORT_DIRECTORY](nfo.r, rva)
+	if err != nil {
+		return nil, dde, err
+	}
+
+	return ed, dde, nil
+}
+
+// readArrayElement reads the idx'th element of the array of T located at rva.
+func readArrayElement[T any](nfo *PEHeaders, rva uint32, idx uint32) (T, error) {
+	var zero T
+	elemRVA, ok := resolveRVA(nfo, rva+idx*uint32(unsafe.Sizeof(zero)))
+	if !ok {
+		return zero, ErrResolvingFileRVA
+	}
+
+	return readStructCopy[T](nfo.r, elemRVA)
+}
+
+// readCString reads a NUL-terminated string located at rva.
+func (nfo *PEHeaders) readCString(rva uint32) (string, error) {
+	off, ok := resolveRVA(nfo, rva)
+	if !ok {
+		return "", ErrResolvingFileRVA
+	}
+
+	size := int64(nfo.r.Limit() - nfo.r.Base())
+	if int64(off) >= size {
+		return "", ErrInvalidBinary
+	}
+
+	sr := io.NewSectionReader(nfo.r, int64(off), min(size-int64(off), maxExportNameLen))
+	b, err := bufio.NewReader(sr).ReadBytes(0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadLength
+		}
+		return "", err
+	}
+
+	return string(bytes.TrimSuffix(b, []byte{0})), nil
+}
+
+// ExportByOrdinal looks up the function exported by nfo at ordinal. It returns
+// the function's name (or the empty string if it is only exported by ordinal),
+// and its RVA. It returns ErrNotPresent if nfo does not export ordinal.
+func (nfo *PEHeaders) ExportByOrdinal(ordinal uint16) (name string, rva uint32, err error) {
+	ed, _, err := nfo.exportDirectory()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if uint32(ordinal) < ed.Base {
+		return "", 0, ErrNotPresent
+	}
+	idx := uint32(ordinal) - ed.Base
+	if idx >= ed.NumberOfFunctions {
+		return "", 0, ErrNotPresent
+	}
+
+	rva, err = readArrayElement[uint32](nfo, ed.AddressOfFunctions, idx)
+	if err != nil {
+		return "", 0, err
+	}
+	if rva == 0 {
+		// Gaps in the ordinal range have zero RVAs.
+		return "", 0, ErrNotPresent
+	}
+
+	for i := uint32(0); i < ed.NumberOfNames; i++ {
+		nameOrdinal, err := readArrayElement[uint16](nfo, ed.AddressOfNameOrdinals, i)
+		if err != nil {
+			return "", 0, err
+		}
+		if uint32(nameOrdinal) != idx {
+			continue
+		}
+
+		nameRVA, err := readArrayElement[uint32](nfo, ed.AddressOfNames, i)
+		if err != nil {
+			return "", 0, err
+		}
+
+		name, err = nfo.readCString(nameRVA)
+		if err != nil {
+			return "", 0, err
+		}
+		break
+	}
+
+	return name, rva, nil
+}
+
+// ExportByName looks up the function exported by nfo as name. It returns the
+// function's ordinal and its RVA. It returns ErrNotPresent if nfo does not
+// export name. Since the export name table is sorted, this lookup only reads
+// O(log n) names from the binary.
+//
+// Note that when the export is a forwarder, the RVA references the forwarder
+// string instead of code; use ResolveForwarder to interpret it.
+func (nfo *PEHeaders) ExportByName(name string) (ordinal uint16, rva uint32, err error) {
+	ed, _, err := nfo.exportDirectory()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lo, hi := uint32(0), ed.NumberOfNames
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		nameRVA, err := readArrayElement[uint32](nfo, ed.AddressOfNames, mid)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		cur, err := nfo.readCString(nameRVA)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		switch {
+		case cur < name:
+			lo = mid + 1
+		case cur > name:
+			hi = mid
+		default:
+			idx, err := readArrayElement[uint16](nfo, ed.AddressOfNameOrdinals, mid)
+			if err != nil {
+				return 0, 0, err
+			}
+			if uint32(idx) >= ed.NumberOfFunctions {
+				return 0, 0, ErrInvalidBinary
+			}
+
+			rva, err := readArrayElement[uint32](nfo, ed.AddressOfFunctions, uint32(idx))
+			if err != nil {
+				return 0, 0, err
+			}
+
+			return uint16(ed.Base + uint32(idx)), rva, nil
+		}
+	}
+
+	return 0, 0, ErrNotPresent
+}
+
+// ResolveForwarder determines whether the function exported by nfo as name is
+// forwarded to another module. If so, it returns the name of the target module
+// (without a file extension) and the name of the target function. When the
+// target is referenced by ordinal, targetSymbol is of the form "#123". It
+// returns ErrNotForwarder when name is not a forwarder, and ErrNotPresent if
+// nfo does not export name.
+func (nfo *PEHeaders) ResolveForwarder(name string) (targetDLL, targetSymbol string, err error) {
+	_, rva, err := nfo.ExportByName(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, dde, err := nfo.exportDirectory()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Forwarders are distinguished by RVAs that lie within the export directory.
+	if rva < dde.VirtualAddress || rva-dde.VirtualAddress >= dde.Size {
+		return "", "", ErrNotForwarder
+	}
+
+	fwd, err := nfo.readCString(rva)
+	if err != nil {
+		return "", "", err
+	}
+
+	dot := strings.LastIndexByte(fwd, '.')
+	if dot <= 0 || dot == len(fwd)-1 {
+		return "", "", ErrInvalidBinary
+	}
+
+	return fwd[:dot], fwd[dot+1:], nil
+}
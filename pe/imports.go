@@ -0,0 +1,248 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// The following constants are from the PE spec
+const (
+	imageOrdinalFlag32       = uint64(0x80000000)
+	imageOrdinalFlag64       = uint64(0x8000000000000000)
+	dliRVABasedAttributeFlag = 1
+	maxImportThunksPerDLL    = 0x10000
+	maxImportDescriptors     = 0x10000
+)
+
+type _IMAGE_IMPORT_DESCRIPTOR struct {
+	OriginalFirstThunk uint32
+	TimeDateStamp      uint32
+	ForwarderChain     uint32
+	Name               uint32
+	FirstThunk         uint32
+}
+
+type _IMAGE_DELAYLOAD_DESCRIPTOR struct {
+	Attributes                 uint32
+	DllNameRVA                 uint32
+	ModuleHandleRVA            uint32
+	ImportAddressTableRVA      uint32
+	ImportNameTableRVA         uint32
+	BoundImportAddressTableRVA uint32
+	UnloadInformationTableRVA  uint32
+	TimeDateStamp              uint32
+}
+
+// ImportedSymbol describes a single function imported by a PE binary.
+type ImportedSymbol struct {
+	// Name is the name of the imported function. It is empty when the function
+	// is imported by ordinal.
+	Name string
+	// Hint is the loader's hint for the index of Name in the exporting DLL's
+	// export name table. It is only meaningful when the function is imported
+	// by name.
+	Hint uint16
+	// Ordinal is the ordinal of the imported function. It is only meaningful
+	// when ByOrdinal is true.
+	Ordinal uint16
+	// ByOrdinal is true when the function is imported by ordinal instead of by
+	// name.
+	ByOrdinal bool
+	// Delayed is true when the function is imported via the delay-load import
+	// table instead of the regular import table.
+	Delayed bool
+}
+
+// readImportNameTable reads the symbols listed in the import name table located
+// at rva, which is terminated by a zero thunk.
+func (nfo *PEHeaders) readImportNameTable(rva uint32, delayed bool) ([]ImportedSymbol, error) {
+	is64 := nfo.optionalHeader.GetMagic() != 0x010B
+	ordinalFlag := imageOrdinalFlag32
+	if is64 {
+		ordinalFlag = imageOrdinalFlag64
+	}
+
+	var result []ImportedSymbol
+	for i := uint32(0); ; i++ {
+		if i >= maxImportThunksPerDLL {
+			return nil, ErrInvalidBinary
+		}
+
+		var thunk uint64
+		if is64 {
+			t, err := readArrayElement[uint64](nfo, rva, i)
+			if err != nil {
+				return nil, err
+			}
+			thunk = t
+		} else {
+			t, err := readArrayElement[uint32](nfo, rva, i)
+			if err != nil {
+				return nil, err
+			}
+			thunk = uint64(t)
+		}
+
+		if thunk == 0 {
+			break
+		}
+
+		sym := ImportedSymbol{Delayed: delayed}
+		if thunk&ordinalFlag != 0 {
+			sym.ByOrdinal = true
+			sym.Ordinal = uint16(thunk)
+		} else {
+			// The thunk is the RVA of an IMAGE_IMPORT_BY_NAME.
+			nameRVA := uint32(thunk)
+			hint, err := readArrayElement[uint16](nfo, nameRVA, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := nfo.readCString(nameRVA + uint32(unsafe.Sizeof(hint)))
+			if err != nil {
+				return nil, err
+			}
+
+			sym.Hint = hint
+			sym.Name = name
+		}
+
+		result = append(result, sym)
+	}
+
+	return result, nil
+}
+
+// importsFromRegular returns the symbols that nfo imports from dllName via its
+// regular import table. found is false when dllName is not listed there.
+func (nfo *PEHeaders) importsFromRegular(dllName string) (syms []ImportedSymbol, found bool, err error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IMPORT)
+	if err != nil {
+		if err == ErrNotPresent {
+			err = nil
+		}
+		return nil, false, err
+	}
+
+	for i := uint32(0); ; i++ {
+		if i >= maxImportDescriptors {
+			return nil, false, ErrInvalidBinary
+		}
+
+		desc, err := readArrayElement[_IMAGE_IMPORT_DESCRIPTOR](nfo, dde.VirtualAddress, i)
+		if err != nil {
+			return nil, false, err
+		}
+		if desc == (_IMAGE_IMPORT_DESCRIPTOR{}) {
+			break
+		}
+
+		name, err := nfo.readCString(desc.Name)
+		if err != nil {
+			return nil, false, err
+		}
+		if !strings.EqualFold(name, dllName) {
+			continue
+		}
+
+		// The loader overwrites FirstThunk with resolved addresses, so we prefer
+		// OriginalFirstThunk. Very old linkers did not emit OriginalFirstThunk, in
+		// which case FirstThunk is only meaningful prior to binding.
+		intRVA := desc.OriginalFirstThunk
+		if intRVA == 0 {
+			intRVA = desc.FirstThunk
+		}
+
+		cur, err := nfo.readImportNameTable(intRVA, false)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// A DLL may be listed by more than one descriptor.
+		syms = append(syms, cur...)
+		found = true
+	}
+
+	return syms, found, nil
+}
+
+// importsFromDelayed returns the symbols that nfo imports from dllName via its
+// delay-load import table. found is false when dllName is not listed there.
+func (nfo *PEHeaders) importsFromDelayed(dllName string) (syms []ImportedSymbol, found bool, err error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DELAY_IMPORT)
+	if err != nil {
+		if err == ErrNotPresent {
+			err = nil
+		}
+		return nil, false, err
+	}
+
+	for i := uint32(0); ; i++ {
+		if i >= maxImportDescriptors {
+			return nil, false, ErrInvalidBinary
+		}
+
+		desc, err := readArrayElement[_IMAGE_DELAYLOAD_DESCRIPTOR](nfo, dde.VirtualAddress, i)
+		if err != nil {
+			return nil, false, err
+		}
+		if desc.DllNameRVA == 0 {
+			break
+		}
+
+		nameRVA, intRVA := desc.DllNameRVA, desc.ImportNameTableRVA
+		if desc.Attributes&dliRVABasedAttributeFlag == 0 {
+			// Legacy delay-load descriptors contain VAs instead of RVAs.
+			if nameRVA, err = nfo.vaToRVA(uint64(nameRVA)); err != nil {
+				return nil, false, err
+			}
+			if intRVA, err = nfo.vaToRVA(uint64(intRVA)); err != nil {
+				return nil, false, err
+			}
+		}
+
+		name, err := nfo.readCString(nameRVA)
+		if err != nil {
+			return nil, false, err
+		}
+		if !strings.EqualFold(name, dllName) {
+			continue
+		}
+
+		cur, err := nfo.readImportNameTable(intRVA, true)
+		if err != nil {
+			return nil, false, err
+		}
+
+		syms = append(syms, cur...)
+		found = true
+	}
+
+	return syms, found, nil
+}
+
+// ImportsFrom returns the functions that nfo imports from the DLL named
+// dllName (such as "kernel32.dll"), including both regular and delay-load
+// imports. dllName is matched case-insensitively. It returns ErrNotPresent if
+// nfo does not import anything from dllName.
+func (nfo *PEHeaders) ImportsFrom(dllName string) ([]ImportedSymbol, error) {
+	regular, foundRegular, err := nfo.importsFromRegular(dllName)
+	if err != nil {
+		return nil, err
+	}
+
+	delayed, foundDelayed, err := nfo.importsFromDelayed(dllName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !foundRegular && !foundDelayed {
+		return nil, ErrNotPresent
+	}
+
+	return append(regular, delayed...), nil
+}
@@ -0,0 +1,263 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bytes"
+	dpe "debug/pe"
+	"encoding/binary"
+	"fmt"
+)
+
+// The following constants are from the PE spec
+const (
+	imageGuardCFFunctionTableSizeMask  = 0xF0000000
+	imageGuardCFFunctionTableSizeShift = 28
+)
+
+// _IMAGE_LOAD_CONFIG_DIRECTORY32 is the prefix of the PE32 load configuration
+// directory that this package understands. The directory has grown over time;
+// its Size field indicates how many of these fields are actually present.
+type _IMAGE_LOAD_CONFIG_DIRECTORY32 struct {
+	Size                           uint32
+	TimeDateStamp                  uint32
+	MajorVersion                   uint16
+	MinorVersion                   uint16
+	GlobalFlagsClear               uint32
+	GlobalFlagsSet                 uint32
+	CriticalSectionDefaultTimeout  uint32
+	DeCommitFreeBlockThreshold     uint32
+	DeCommitTotalFreeThreshold     uint32
+	LockPrefixTable                uint32
+	MaximumAllocationSize          uint32
+	VirtualMemoryThreshold         uint32
+	ProcessHeapFlags               uint32
+	ProcessAffinityMask            uint32
+	CSDVersion                     uint16
+	DependentLoadFlags             uint16
+	EditList                       uint32
+	SecurityCookie                 uint32
+	SEHandlerTable                 uint32
+	SEHandlerCount                 uint32
+	GuardCFCheckFunctionPointer    uint32
+	GuardCFDispatchFunctionPointer uint32
+	GuardCFFunctionTable           uint32
+	GuardCFFunctionCount           uint32
+	GuardFlags                     uint32
+}
+
+// _IMAGE_LOAD_CONFIG_DIRECTORY64 is the PE32+ counterpart of
+// _IMAGE_LOAD_CONFIG_DIRECTORY32. Note that ProcessAffinityMask and
+// ProcessHeapFlags appear in the opposite order.
+type _IMAGE_LOAD_CONFIG_DIRECTORY64 struct {
+	Size                           uint32
+	TimeDateStamp                  uint32
+	MajorVersion                   uint16
+	MinorVersion                   uint16
+	GlobalFlagsClear               uint32
+	GlobalFlagsSet                 uint32
+	CriticalSectionDefaultTimeout  uint32
+	DeCommitFreeBlockThreshold     uint64
+	DeCommitTotalFreeThreshold     uint64
+	LockPrefixTable                uint64
+	MaximumAllocationSize          uint64
+	VirtualMemoryThreshold         uint64
+	ProcessAffinityMask            uint64
+	ProcessHeapFlags               uint32
+	CSDVersion                     uint16
+	DependentLoadFlags             uint16
+	EditList                       uint64
+	SecurityCookie                 uint64
+	SEHandlerTable                 uint64
+	SEHandlerCount                 uint64
+	GuardCFCheckFunctionPointer    uint64
+	GuardCFDispatchFunctionPointer uint64
+	GuardCFFunctionTable           uint64
+	GuardCFFunctionCount           uint64
+	GuardFlags                     uint32
+}
+
+// loadConfig contains the fields of the load configuration directory that are
+// consumed by this package, normalized to be independent of the binary's
+// bitness. Fields that lie beyond the directory's Size are zero.
+type loadConfig struct {
+	seHandlerTable       uint64
+	seHandlerCount       uint64
+	guardCFFunctionTable uint64
+	guardCFFunctionCount uint64
+	guardFlags           uint32
+}
+
+// readLoadConfigDirectory reads a T from the beginning of the load
+// configuration directory described by dde. Since binaries may contain older,
+// smaller versions of the directory, any fields of T that lie beyond the
+// directory's own Size field are left zeroed.
+func readLoadConfigDirectory[T any](nfo *PEHeaders, dde DataDirectoryEntry) (*T, error) {
+	result := new(T)
+	szT := uint32(binary.Size(result))
+
+	var size uint32
+	sr, err := nfo.rvaReader(dde.VirtualAddress, uint32(binary.Size(size)))
+	if err != nil {
+		return nil, err
+	}
+	if err := binaryRead(sr, &size); err != nil {
+		return nil, err
+	}
+	size = min(size, szT)
+
+	sr, err = nfo.rvaReader(dde.VirtualAddress, size)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, szT)
+	if _, err := readFull(sr, buf[:size]); err != nil {
+		return nil, err
+	}
+
+	if err := binaryRead(bytes.NewReader(buf), result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// loadConfig reads nfo's load configuration directory. It returns ErrNotPresent
+// if nfo does not contain one.
+func (nfo *PEHeaders) loadConfig() (*loadConfig, error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_LOAD_CONFIG)
+	if err != nil {
+		return nil, err
+	}
+
+	switch nfo.optionalHeader.(type) {
+	case *optionalHeader32:
+		lc, err := readLoadConfigDirectory[_IMAGE_LOAD_CONFIG_DIRECTORY32](nfo, dde)
+		if err != nil {
+			return nil, err
+		}
+		return &loadConfig{
+			seHandlerTable:       uint64(lc.SEHandlerTable),
+			seHandlerCount:       uint64(lc.SEHandlerCount),
+			guardCFFunctionTable: uint64(lc.GuardCFFunctionTable),
+			guardCFFunctionCount: uint64(lc.GuardCFFunctionCount),
+			guardFlags:           lc.GuardFlags,
+		}, nil
+	case *optionalHeader64:
+		lc, err := readLoadConfigDirectory[_IMAGE_LOAD_CONFIG_DIRECTORY64](nfo, dde)
+		if err != nil {
+			return nil, err
+		}
+		return &loadConfig{
+			seHandlerTable:       lc.SEHandlerTable,
+			seHandlerCount:       lc.SEHandlerCount,
+			guardCFFunctionTable: lc.GuardCFFunctionTable,
+			guardCFFunctionCount: lc.GuardCFFunctionCount,
+			guardFlags:           lc.GuardFlags,
+		}, nil
+	default:
+		return nil, ErrInvalidBinary
+	}
+}
+
+// vaToRVA converts va, a virtual address relative to nfo's preferred image
+// base, into an RVA.
+func (nfo *PEHeaders) vaToRVA(va uint64) (uint32, error) {
+	imageBase := nfo.optionalHeader.GetImageBase()
+	if va < imageBase || va-imageBase >= uint64(nfo.optionalHeader.GetSizeOfImage()) {
+		return 0, fmt.Errorf("%w: virtual address 0x%X lies outside of the image", ErrInvalidBinary, va)
+	}
+
+	return uint32(va - imageBase), nil
+}
+
+// readLoadConfigTable reads count entries of size entrySize from the table
+// located at virtual address va. It returns the raw bytes of the table, whose
+// length is always a multiple of entrySize.
+func (nfo *PEHeaders) readLoadConfigTable(va, count uint64, entrySize uint32) ([]byte, error) {
+	rva, err := nfo.vaToRVA(va)
+	if err != nil {
+		return nil, err
+	}
+
+	// The entire table must fit within the image. count comes straight from
+	// the binary, so we must check it before multiplying lest we overflow.
+	if count > uint64(nfo.optionalHeader.GetSizeOfImage())/uint64(entrySize) {
+		return nil, fmt.Errorf("%w: load config table at 0x%X is too large", ErrInvalidBinary, va)
+	}
+	tableSize := count * uint64(entrySize)
+
+	sr, err := nfo.rvaReader(rva, uint32(tableSize))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, tableSize)
+	if _, err := readFull(sr, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SafeSEHHandlers returns the RVAs of the exception handlers registered in the
+// SafeSEH handler table of an x86 binary. Binaries that were linked with
+// /SAFESEH may only dispatch exceptions to these handlers. It returns
+// ErrUnsupportedMachine if nfo is not an x86 binary, and ErrNotPresent if nfo
+// does not contain a SafeSEH handler table.
+func (nfo *PEHeaders) SafeSEHHandlers() ([]uint32, error) {
+	if nfo.fileHeader.Machine != dpe.IMAGE_FILE_MACHINE_I386 {
+		return nil, ErrUnsupportedMachine
+	}
+
+	lc, err := nfo.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if lc.seHandlerTable == 0 {
+		return nil, ErrNotPresent
+	}
+
+	table, err := nfo.readLoadConfigTable(lc.seHandlerTable, lc.seHandlerCount, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uint32, len(table)/4)
+	for i := range result {
+		result[i] = binary.LittleEndian.Uint32(table[i*4:])
+	}
+
+	return result, nil
+}
+
+// CFGFunctions returns the RVAs of the valid indirect call targets listed in
+// nfo's Control Flow Guard function table. It returns ErrNotPresent if nfo
+// does not contain a CFG function table.
+func (nfo *PEHeaders) CFGFunctions() ([]uint32, error) {
+	lc, err := nfo.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if lc.guardCFFunctionTable == 0 {
+		return nil, ErrNotPresent
+	}
+
+	// Each entry consists of an RVA, followed by a variable number of bytes of
+	// metadata. The number of metadata bytes is encoded in GuardFlags.
+	stride := 4 + (lc.guardFlags&imageGuardCFFunctionTableSizeMask)>>imageGuardCFFunctionTableSizeShift
+
+	table, err := nfo.readLoadConfigTable(lc.guardCFFunctionTable, lc.guardCFFunctionCount, stride)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uint32, len(table)/int(stride))
+	for i := range result {
+		result[i] = binary.LittleEndian.Uint32(table[i*int(stride):])
+	}
+
+	return result, nil
+}
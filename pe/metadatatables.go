@@ -0,0 +1,522 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// maxMetadataStringLen bounds the length of strings that we are willing to
+// read from the #Strings heap, in case the binary is corrupt or malicious.
+const maxMetadataStringLen = 4096
+
+// mdTable identifies a metadata table. The values are from ECMA-335,
+// Partition II, section 22.
+type mdTable uint8
+
+const (
+	mdtModule                 mdTable = 0x00
+	mdtTypeRef                mdTable = 0x01
+	mdtTypeDef                mdTable = 0x02
+	mdtFieldPtr               mdTable = 0x03
+	mdtField                  mdTable = 0x04
+	mdtMethodPtr              mdTable = 0x05
+	mdtMethodDef              mdTable = 0x06
+	mdtParamPtr               mdTable = 0x07
+	mdtParam                  mdTable = 0x08
+	mdtInterfaceImpl          mdTable = 0x09
+	mdtMemberRef              mdTable = 0x0A
+	mdtConstant               mdTable = 0x0B
+	mdtCustomAttribute        mdTable = 0x0C
+	mdtFieldMarshal           mdTable = 0x0D
+	mdtDeclSecurity           mdTable = 0x0E
+	mdtClassLayout            mdTable = 0x0F
+	mdtFieldLayout            mdTable = 0x10
+	mdtStandAloneSig          mdTable = 0x11
+	mdtEventMap               mdTable = 0x12
+	mdtEventPtr               mdTable = 0x13
+	mdtEvent                  mdTable = 0x14
+	mdtPropertyMap            mdTable = 0x15
+	mdtPropertyPtr            mdTable = 0x16
+	mdtProperty               mdTable = 0x17
+	mdtMethodSemantics        mdTable = 0x18
+	mdtMethodImpl             mdTable = 0x19
+	mdtModuleRef              mdTable = 0x1A
+	mdtTypeSpec               mdTable = 0x1B
+	mdtImplMap                mdTable = 0x1C
+	mdtFieldRVA               mdTable = 0x1D
+	mdtEncLog                 mdTable = 0x1E
+	mdtEncMap                 mdTable = 0x1F
+	mdtAssembly               mdTable = 0x20
+	mdtAssemblyProcessor      mdTable = 0x21
+	mdtAssemblyOS             mdTable = 0x22
+	mdtAssemblyRef            mdTable = 0x23
+	mdtFile                   mdTable = 0x26
+	mdtExportedType           mdTable = 0x27
+	mdtManifestResource       mdTable = 0x28
+	mdtGenericParam           mdTable = 0x2A
+	mdtMethodSpec             mdTable = 0x2B
+	mdtGenericParamConstraint mdTable = 0x2C
+
+	// mdtUnused marks tags in coded indices that do not correspond to any table.
+	mdtUnused mdTable = 0xFF
+)
+
+// mdColumn describes the type of a column in a metadata table.
+type mdColumn int
+
+const (
+	mdcU16 mdColumn = iota
+	mdcU32
+	mdcString
+	mdcGUID
+	mdcBlob
+	// mdcTable is added to an mdTable to describe a simple index into that table.
+	mdcTable
+)
+
+// Columns containing coded indices are numbered from mdcCoded onward, in the
+// order in which they appear in mdCodedIndices. A coded index is an index into
+// one of several tables; the low-order bits of its value are a tag that
+// selects the table.
+const mdcCoded = mdcTable + 0x100
+
+const (
+	mdcTypeDefOrRef mdColumn = mdcCoded + iota
+	mdcHasConstant
+	mdcHasCustomAttribute
+	mdcHasFieldMarshal
+	mdcHasDeclSecurity
+	mdcMemberRefParent
+	mdcHasSemantics
+	mdcMethodDefOrRef
+	mdcMemberForwarded
+	mdcImplementation
+	mdcCustomAttributeType
+	mdcResolutionScope
+)
+
+// mdCodedIndices lists the tables that may be referenced by each kind of coded
+// index, in tag order. From ECMA-335, Partition II, section 24.2.6.
+var mdCodedIndices = [][]mdTable{
+	{mdtTypeDef, mdtTypeRef, mdtTypeSpec},
+	{mdtField, mdtParam, mdtProperty},
+	{mdtMethodDef, mdtField, mdtTypeRef, mdtTypeDef, mdtParam, mdtInterfaceImpl, mdtMemberRef, mdtModule, mdtDeclSecurity, mdtProperty, mdtEvent, mdtStandAloneSig, mdtModuleRef, mdtTypeSpec, mdtAssembly, mdtAssemblyRef, mdtFile, mdtExportedType, mdtManifestResource, mdtGenericParam, mdtGenericParamConstraint, mdtMethodSpec},
+	{mdtField, mdtParam},
+	{mdtTypeDef, mdtMethodDef, mdtAssembly},
+	{mdtTypeDef, mdtTypeRef, mdtModuleRef, mdtMethodDef, mdtTypeSpec},
+	{mdtEvent, mdtProperty},
+	{mdtMethodDef, mdtMemberRef},
+	{mdtField, mdtMethodDef},
+	{mdtFile, mdtAssemblyRef, mdtExportedType},
+	{mdtUnused, mdtUnused, mdtMethodDef, mdtMemberRef, mdtUnused},
+	{mdtModule, mdtModuleRef, mdtAssemblyRef, mdtTypeRef},
+}
+
+func mdcIndex(t mdTable) mdColumn {
+	return mdcTable + mdColumn(t)
+}
+
+// mdTableSchemas contains the column layout of each metadata table up to and
+// including AssemblyRef, from ECMA-335, Partition II, section 22.
+var mdTableSchemas = map[mdTable][]mdColumn{
+	mdtModule:            {mdcU16, mdcString, mdcGUID, mdcGUID, mdcGUID},
+	mdtTypeRef:           {mdcResolutionScope, mdcString, mdcString},
+	mdtTypeDef:           {mdcU32, mdcString, mdcString, mdcTypeDefOrRef, mdcIndex(mdtField), mdcIndex(mdtMethodDef)},
+	mdtFieldPtr:          {mdcIndex(mdtField)},
+	mdtField:             {mdcU16, mdcString, mdcBlob},
+	mdtMethodPtr:         {mdcIndex(mdtMethodDef)},
+	mdtMethodDef:         {mdcU32, mdcU16, mdcU16, mdcString, mdcBlob, mdcIndex(mdtParam)},
+	mdtParamPtr:          {mdcIndex(mdtParam)},
+	mdtParam:             {mdcU16, mdcU16, mdcString},
+	mdtInterfaceImpl:     {mdcIndex(mdtTypeDef), mdcTypeDefOrRef},
+	mdtMemberRef:         {mdcMemberRefParent, mdcString, mdcBlob},
+	mdtConstant:          {mdcU16, mdcHasConstant, mdcBlob},
+	mdtCustomAttribute:   {mdcHasCustomAttribute, mdcCustomAttributeType, mdcBlob},
+	mdtFieldMarshal:      {mdcHasFieldMarshal, mdcBlob},
+	mdtDeclSecurity:      {mdcU16, mdcHasDeclSecurity, mdcBlob},
+	mdtClassLayout:       {mdcU16, mdcU32, mdcIndex(mdtTypeDef)},
+	mdtFieldLayout:       {mdcU32, mdcIndex(mdtField)},
+	mdtStandAloneSig:     {mdcBlob},
+	mdtEventMap:          {mdcIndex(mdtTypeDef), mdcIndex(mdtEvent)},
+	mdtEventPtr:          {mdcIndex(mdtEvent)},
+	mdtEvent:             {mdcU16, mdcString, mdcTypeDefOrRef},
+	mdtPropertyMap:       {mdcIndex(mdtTypeDef), mdcIndex(mdtProperty)},
+	mdtPropertyPtr:       {mdcIndex(mdtProperty)},
+	mdtProperty:          {mdcU16, mdcString, mdcBlob},
+	mdtMethodSemantics:   {mdcU16, mdcIndex(mdtMethodDef), mdcHasSemantics},
+	mdtMethodImpl:        {mdcIndex(mdtTypeDef), mdcMethodDefOrRef, mdcMethodDefOrRef},
+	mdtModuleRef:         {mdcString},
+	mdtTypeSpec:          {mdcBlob},
+	mdtImplMap:           {mdcU16, mdcMemberForwarded, mdcString, mdcIndex(mdtModuleRef)},
+	mdtFieldRVA:          {mdcU32, mdcIndex(mdtField)},
+	mdtEncLog:            {mdcU32, mdcU32},
+	mdtEncMap:            {mdcU32},
+	mdtAssembly:          {mdcU32, mdcU16, mdcU16, mdcU16, mdcU16, mdcU32, mdcBlob, mdcString, mdcString},
+	mdtAssemblyProcessor: {mdcU32},
+	mdtAssemblyOS:        {mdcU32, mdcU32, mdcU32},
+	mdtAssemblyRef:       {mdcU16, mdcU16, mdcU16, mdcU16, mdcU32, mdcBlob, mdcString, mdcString, mdcBlob},
+}
+
+// Flags for the HeapSizes field of the tables stream header
+const (
+	mdHeapSizeStrings4 = 0x01
+	mdHeapSizeGUID4    = 0x02
+	mdHeapSizeBlob4    = 0x04
+	// mdHeapExtraData indicates that an additional uint32 follows the row
+	// counts. It is undocumented but emitted by some uncompressed (#-) streams.
+	mdHeapExtraData = 0x40
+)
+
+// _METADATA_TABLES_HEADER is the fixed-size header of the #~ stream, from
+// ECMA-335, Partition II, section 24.2.6.
+type _METADATA_TABLES_HEADER struct {
+	Reserved     uint32
+	MajorVersion uint8
+	MinorVersion uint8
+	HeapSizes    uint8
+	Reserved2    uint8
+	Valid        uint64
+	Sorted       uint64
+}
+
+// metadataTables contains the information necessary to locate and decode rows
+// within the metadata tables stream.
+type metadataTables struct {
+	r         *io.SectionReader
+	heapSizes uint8
+	rowCounts [64]uint32
+	// tablesOffset is the offset of the first table's first row within r.
+	tablesOffset int64
+}
+
+func (mt *metadataTables) columnSize(c mdColumn) int {
+	switch {
+	case c == mdcU16:
+		return 2
+	case c == mdcU32:
+		return 4
+	case c == mdcString:
+		return mt.heapIndexSize(mdHeapSizeStrings4)
+	case c == mdcGUID:
+		return mt.heapIndexSize(mdHeapSizeGUID4)
+	case c == mdcBlob:
+		return mt.heapIndexSize(mdHeapSizeBlob4)
+	case c >= mdcCoded:
+		tables := mdCodedIndices[c-mdcCoded]
+		tagBits := bits.Len(uint(len(tables) - 1))
+		for _, t := range tables {
+			if t != mdtUnused && mt.rowCounts[t] >= (1<<(16-tagBits)) {
+				return 4
+			}
+		}
+		return 2
+	default:
+		if mt.rowCounts[c-mdcTable] >= (1 << 16) {
+			return 4
+		}
+		return 2
+	}
+}
+
+func (mt *metadataTables) heapIndexSize(flag uint8) int {
+	if mt.heapSizes&flag != 0 {
+		return 4
+	}
+	return 2
+}
+
+func (mt *metadataTables) rowSize(t mdTable) (int, error) {
+	schema, ok := mdTableSchemas[t]
+	if !ok {
+		return 0, fmt.Errorf("%w: metadata table 0x%02X has an unknown layout", ErrInvalidBinary, uint8(t))
+	}
+
+	var result int
+	for _, c := range schema {
+		result += mt.columnSize(c)
+	}
+	return result, nil
+}
+
+// table returns a reader over the rows of table t.
+func (mt *metadataTables) table(t mdTable) (*io.SectionReader, error) {
+	offset := uint64(mt.tablesOffset)
+	for prev := mdTable(0); prev < t; prev++ {
+		if mt.rowCounts[prev] == 0 {
+			continue
+		}
+		rs, err := mt.rowSize(prev)
+		if err != nil {
+			return nil, err
+		}
+		offset += uint64(rs) * uint64(mt.rowCounts[prev])
+	}
+
+	rs, err := mt.rowSize(t)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint64(rs) * uint64(mt.rowCounts[t])
+	if offset+size > uint64(mt.r.Size()) {
+		return nil, fmt.Errorf("%w: metadata table 0x%02X lies outside of the tables stream", ErrInvalidBinary, uint8(t))
+	}
+
+	return io.NewSectionReader(mt.r, int64(offset), int64(size)), nil
+}
+
+// readRow decodes a single row of table t from r. Each column is zero-extended
+// to a uint32.
+func (mt *metadataTables) readRow(r io.Reader, t mdTable) ([]uint32, error) {
+	schema := mdTableSchemas[t]
+	result := make([]uint32, len(schema))
+	for i, c := range schema {
+		switch mt.columnSize(c) {
+		case 2:
+			var v uint16
+			if err := binaryRead(r, &v); err != nil {
+				return nil, err
+			}
+			result[i] = uint32(v)
+		case 4:
+			if err := binaryRead(r, &result[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// metadataTables parses the header of mr's tables stream.
+func (mr *metadataRoot) metadataTables() (*metadataTables, error) {
+	sr, err := mr.stream("#~")
+	if err == ErrNotPresent {
+		// Uncompressed metadata uses a different stream name but is otherwise
+		// laid out identically, apart from its use of *Ptr tables.
+		sr, err = mr.stream("#-")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr _METADATA_TABLES_HEADER
+	if err := binaryRead(sr, &hdr); err != nil {
+		return nil, err
+	}
+
+	result := &metadataTables{r: sr, heapSizes: hdr.HeapSizes}
+	for i := range result.rowCounts {
+		if hdr.Valid&(1<<i) == 0 {
+			continue
+		}
+		if err := binaryRead(sr, &result.rowCounts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if hdr.HeapSizes&mdHeapExtraData != 0 {
+		var extra uint32
+		if err := binaryRead(sr, &extra); err != nil {
+			return nil, err
+		}
+	}
+
+	pos, err := sr.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	result.tablesOffset = pos
+
+	return result, nil
+}
+
+// readHeapString reads the NUL-terminated string located at offset within the
+// #Strings heap r.
+func readHeapString(r *io.SectionReader, offset uint32) (string, error) {
+	if int64(offset) >= r.Size() {
+		return "", fmt.Errorf("%w: #Strings heap index 0x%X is out of range", ErrInvalidBinary, offset)
+	}
+
+	sr := io.NewSectionReader(r, int64(offset), min(r.Size()-int64(offset), maxMetadataStringLen))
+	b, err := bufio.NewReader(sr).ReadBytes(0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadLength
+		}
+		return "", err
+	}
+
+	return string(bytes.TrimSuffix(b, []byte{0})), nil
+}
+
+// readHeapBlob reads the blob located at offset within the #Blob heap r. Each
+// blob is prefixed by its length, which is encoded as described in ECMA-335,
+// Partition II, section 24.2.4.
+func readHeapBlob(r *io.SectionReader, offset uint32) ([]byte, error) {
+	if int64(offset) >= r.Size() {
+		return nil, fmt.Errorf("%w: #Blob heap index 0x%X is out of range", ErrInvalidBinary, offset)
+	}
+
+	var prefix [4]byte
+	n, err := r.ReadAt(prefix[:], int64(offset))
+	if n == 0 && err != nil {
+		return nil, err
+	}
+
+	var length, lenSize uint32
+	switch b := prefix[0]; {
+	case b&0x80 == 0:
+		length, lenSize = uint32(b), 1
+	case b&0xC0 == 0x80 && n >= 2:
+		length, lenSize = uint32(binary.BigEndian.Uint16(prefix[:2])&0x3FFF), 2
+	case b&0xE0 == 0xC0 && n >= 4:
+		length, lenSize = binary.BigEndian.Uint32(prefix[:])&0x1FFFFFFF, 4
+	default:
+		return nil, fmt.Errorf("%w: #Blob heap index 0x%X has an invalid length", ErrInvalidBinary, offset)
+	}
+
+	start := int64(offset) + int64(lenSize)
+	if start+int64(length) > r.Size() {
+		return nil, ErrBadLength
+	}
+
+	result := make([]byte, length)
+	if _, err := readFull(io.NewSectionReader(r, start, int64(length)), result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AssemblyVersion is the four-part version number of a .NET assembly.
+type AssemblyVersion struct {
+	Major    uint16
+	Minor    uint16
+	Build    uint16
+	Revision uint16
+}
+
+func (v AssemblyVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Build, v.Revision)
+}
+
+// AssemblyRefFlags contains the flags from an AssemblyRef metadata table row.
+type AssemblyRefFlags uint32
+
+const (
+	// AssemblyRefPublicKey indicates that the reference contains the
+	// referenced assembly's full public key instead of its token.
+	AssemblyRefPublicKey = AssemblyRefFlags(0x0001)
+	// AssemblyRefRetargetable indicates that the referenced assembly may be
+	// substituted by the runtime with one from a different publisher.
+	AssemblyRefRetargetable = AssemblyRefFlags(0x0100)
+)
+
+// AssemblyRef describes an assembly that is referenced by a managed binary.
+type AssemblyRef struct {
+	Name    string
+	Version AssemblyVersion
+	// Culture is empty for culture-neutral assemblies.
+	Culture string
+	Flags   AssemblyRefFlags
+	// PublicKeyToken is the 8-byte token that identifies the public key of
+	// a strong-named assembly. It is empty when the referenced assembly is not
+	// strong-named. When the reference contains the full public key, the token
+	// is derived from that key.
+	PublicKeyToken []byte
+}
+
+// publicKeyToken derives the public key token from a full public key: it is
+// the last 8 bytes of the key's SHA-1 hash, in reverse order.
+func publicKeyToken(publicKey []byte) []byte {
+	hash := sha1.Sum(publicKey)
+	result := make([]byte, 8)
+	for i := range result {
+		result[i] = hash[len(hash)-1-i]
+	}
+	return result
+}
+
+// AssemblyReferences parses the AssemblyRef metadata table of a managed (.NET)
+// binary and returns the assemblies that it references, in table order. This
+// is the managed counterpart of the native import table. It returns
+// ErrNotPresent if nfo is a native binary.
+func (nfo *PEHeaders) AssemblyReferences() ([]AssemblyRef, error) {
+	mr, err := nfo.metadataRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	mt, err := mr.metadataTables()
+	if err != nil {
+		return nil, err
+	}
+
+	count := mt.rowCounts[mdtAssemblyRef]
+	if count == 0 {
+		return nil, nil
+	}
+
+	strs, err := mr.stream("#Strings")
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing #Strings heap", ErrInvalidBinary)
+	}
+	blobs, err := mr.stream("#Blob")
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing #Blob heap", ErrInvalidBinary)
+	}
+
+	tr, err := mt.table(mdtAssemblyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(tr)
+	result := make([]AssemblyRef, 0, count)
+	for i := uint32(0); i < count; i++ {
+		row, err := mt.readRow(br, mdtAssemblyRef)
+		if err != nil {
+			return nil, err
+		}
+
+		ref := AssemblyRef{
+			Version: AssemblyVersion{
+				Major:    uint16(row[0]),
+				Minor:    uint16(row[1]),
+				Build:    uint16(row[2]),
+				Revision: uint16(row[3]),
+			},
+			Flags: AssemblyRefFlags(row[4]),
+		}
+
+		if ref.Name, err = readHeapString(strs, row[6]); err != nil {
+			return nil, err
+		}
+		if ref.Culture, err = readHeapString(strs, row[7]); err != nil {
+			return nil, err
+		}
+
+		if row[5] != 0 {
+			key, err := readHeapBlob(blobs, row[5])
+			if err != nil {
+				return nil, err
+			}
+			if ref.Flags&AssemblyRefPublicKey != 0 && len(key) > 0 {
+				key = publicKeyToken(key)
+			}
+			ref.PublicKeyToken = key
+		}
+
+		result = append(result, ref)
+	}
+
+	return result, nil
+}
@@ -17,7 +17,11 @@ import (
 	"math/bits"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/dblohm7/wingoes"
@@ -29,6 +33,7 @@ const (
 	maxNumSections                 = 96
 	mzSignature                    = uint16(0x5A4D) // little-endian
 	offsetIMAGE_DOS_HEADERe_lfanew = 0x3C
+	sizeofIMAGE_DOS_HEADER         = 0x40
 	peSignature                    = uint32(0x00004550) // little-endian
 )
 
@@ -37,7 +42,8 @@ var (
 	// binary is shorter than the expected length of that field.
 	ErrBadLength = errors.New("effective length did not match expected length")
 	// ErrBadCodeView is returned by (*PEHeaders).ExtractCodeViewInfo if the data
-	// at the requested address does not appear to contain valid CodeView information.
+	// at the requested address does not appear to contain valid CodeView information,
+	// or contains CodeView information in an unsupported format.
 	ErrBadCodeView = errors.New("invalid CodeView debug info")
 	// ErrIndexOutOfRange is returned by (*PEHeaders).DataDirectoryEntry if the
 	// specified index is greater than the maximum allowable index.
@@ -52,6 +58,15 @@ var (
 	// ErrIndexOutOfRange is returned by (*PEHeaders).DataDirectoryEntry if the
 	// corresponding entry is not populated in the PE image.
 	ErrNotPresent = errors.New("not present in this PE image")
+	// ErrNotPOGO is returned by (*PEHeaders).ExtractPOGO if the debug directory
+	// entry does not contain POGO information.
+	ErrNotPOGO = errors.New("debug info is not POGO")
+	// ErrNotRepro is returned by (*PEHeaders).ExtractReproHash if the debug
+	// directory entry does not describe a reproducible build.
+	ErrNotRepro = errors.New("debug info is not REPRO")
+	// ErrRequiresWindows is returned by functionality that relies upon Windows
+	// APIs, such as NewVersionInfo, when it is invoked on other platforms.
+	ErrRequiresWindows = errors.New("this functionality requires Windows")
 	// ErrResolvingFileRVA is returned when the result of arithmetic on a relative
 	// virtual address did not resolve to a valid RVA.
 	ErrResolvingFileRVA = errors.New("could not resolve file RVA")
@@ -68,10 +83,73 @@ var (
 // FileHeader is the PE/COFF IMAGE_FILE_HEADER structure.
 type FileHeader dpe.FileHeader
 
+// ImageCharacteristics is the set of flags contained in the Characteristics
+// field of FileHeader.
+type ImageCharacteristics uint16
+
+const (
+	IMAGE_FILE_RELOCS_STRIPPED         ImageCharacteristics = 0x0001
+	IMAGE_FILE_EXECUTABLE_IMAGE        ImageCharacteristics = 0x0002
+	IMAGE_FILE_LINE_NUMS_STRIPPED      ImageCharacteristics = 0x0004
+	IMAGE_FILE_LOCAL_SYMS_STRIPPED     ImageCharacteristics = 0x0008
+	IMAGE_FILE_AGGRESIVE_WS_TRIM       ImageCharacteristics = 0x0010
+	IMAGE_FILE_LARGE_ADDRESS_AWARE     ImageCharacteristics = 0x0020
+	IMAGE_FILE_BYTES_REVERSED_LO       ImageCharacteristics = 0x0080
+	IMAGE_FILE_32BIT_MACHINE           ImageCharacteristics = 0x0100
+	IMAGE_FILE_DEBUG_STRIPPED          ImageCharacteristics = 0x0200
+	IMAGE_FILE_REMOVABLE_RUN_FROM_SWAP ImageCharacteristics = 0x0400
+	IMAGE_FILE_NET_RUN_FROM_SWAP       ImageCharacteristics = 0x0800
+	IMAGE_FILE_SYSTEM                  ImageCharacteristics = 0x1000
+	IMAGE_FILE_DLL                     ImageCharacteristics = 0x2000
+	IMAGE_FILE_UP_SYSTEM_ONLY          ImageCharacteristics = 0x4000
+	IMAGE_FILE_BYTES_REVERSED_HI       ImageCharacteristics = 0x8000
+)
+
+var imageCharacteristicsNames = []struct {
+	flag ImageCharacteristics
+	name string
+}{
+	{IMAGE_FILE_RELOCS_STRIPPED, "IMAGE_FILE_RELOCS_STRIPPED"},
+	{IMAGE_FILE_EXECUTABLE_IMAGE, "IMAGE_FILE_EXECUTABLE_IMAGE"},
+	{IMAGE_FILE_LINE_NUMS_STRIPPED, "IMAGE_FILE_LINE_NUMS_STRIPPED"},
+	{IMAGE_FILE_LOCAL_SYMS_STRIPPED, "IMAGE_FILE_LOCAL_SYMS_STRIPPED"},
+	{IMAGE_FILE_AGGRESIVE_WS_TRIM, "IMAGE_FILE_AGGRESIVE_WS_TRIM"},
+	{IMAGE_FILE_LARGE_ADDRESS_AWARE, "IMAGE_FILE_LARGE_ADDRESS_AWARE"},
+	{IMAGE_FILE_BYTES_REVERSED_LO, "IMAGE_FILE_BYTES_REVERSED_LO"},
+	{IMAGE_FILE_32BIT_MACHINE, "IMAGE_FILE_32BIT_MACHINE"},
+	{IMAGE_FILE_DEBUG_STRIPPED, "IMAGE_FILE_DEBUG_STRIPPED"},
+	{IMAGE_FILE_REMOVABLE_RUN_FROM_SWAP, "IMAGE_FILE_REMOVABLE_RUN_FROM_SWAP"},
+	{IMAGE_FILE_NET_RUN_FROM_SWAP, "IMAGE_FILE_NET_RUN_FROM_SWAP"},
+	{IMAGE_FILE_SYSTEM, "IMAGE_FILE_SYSTEM"},
+	{IMAGE_FILE_DLL, "IMAGE_FILE_DLL"},
+	{IMAGE_FILE_UP_SYSTEM_ONLY, "IMAGE_FILE_UP_SYSTEM_ONLY"},
+	{IMAGE_FILE_BYTES_REVERSED_HI, "IMAGE_FILE_BYTES_REVERSED_HI"},
+}
+
+// String renders the flags set in c, separated by "|". Any unknown flags are
+// rendered in hexadecimal.
+func (c ImageCharacteristics) String() string {
+	var names []string
+	for _, e := range imageCharacteristicsNames {
+		if c&e.flag != 0 {
+			names = append(names, e.name)
+			c &^= e.flag
+		}
+	}
+	if c != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("0x%04X", uint16(c)))
+	}
+	return strings.Join(names, "|")
+}
+
 // SectionHeader is the PE/COFF IMAGE_SECTION_HEADER structure.
 type SectionHeader dpe.SectionHeader32
 
-// NameString returns the name of s as a Go string.
+// NameString returns the name of s as a Go string, without any trailing NUL
+// padding, exactly as it is stored in the section header. Section names are
+// limited to 8 bytes; some toolchains (notably GCC) store longer names in the
+// COFF string table, in which case NameString returns an indirection of the
+// form "/N". Use (*PEHeaders).SectionName to resolve such names.
 func (s *SectionHeader) NameString() string {
 	// s.Name is UTF-8. When the string's length is < len(s.Name), the remaining
 	// bytes are padded with zeros.
@@ -84,37 +162,338 @@ func (s *SectionHeader) NameString() string {
 	return string(s.Name[:])
 }
 
+// peReader provides access to the contents of a PE binary. Implementations
+// must only be read via ReadAt so that concurrent reads do not interfere with
+// one another.
 type peReader interface {
 	io.Closer
 	io.ReaderAt
-	io.ReadSeeker
 	Base() uintptr
 	Limit() uintptr
 }
 
 // PEHeaders represents the partially-parsed headers from a PE binary.
+// Its methods may be called concurrently from multiple goroutines.
 type PEHeaders struct {
 	r              peReader
+	e_lfanew       int32
 	fileHeader     *FileHeader
 	optionalHeader OptionalHeader
 	sections       []SectionHeader
+	closeOnce      sync.Once
+	// dirCache holds the data directory entries that were parsed eagerly by
+	// ParseFiles. It is populated before the PEHeaders is returned to the
+	// caller and is read-only thereafter, so it requires no locking.
+	dirCache map[DataDirectoryIndex]dataDirectoryResult
+}
+
+// dataDirectoryResult is the cached result of a call to DataDirectoryEntry.
+type dataDirectoryResult struct {
+	value any
+	err   error
 }
 
-// FileHeader returns the FileHeader that was parsed from peh.
+// FileHeader returns the FileHeader that was parsed from peh. When peh was
+// created from a loaded module, the result references the module's memory
+// in-place; callers must treat it as read-only.
 func (peh *PEHeaders) FileHeader() *FileHeader {
 	return peh.fileHeader
 }
 
-// FileHeader returns the OptionalHeader that was parsed from peh.
+var machineNames = map[uint16]string{
+	dpe.IMAGE_FILE_MACHINE_UNKNOWN: "UNKNOWN",
+	dpe.IMAGE_FILE_MACHINE_I386:    "I386",
+	dpe.IMAGE_FILE_MACHINE_AMD64:   "x64",
+	dpe.IMAGE_FILE_MACHINE_ARM:     "ARM",
+	dpe.IMAGE_FILE_MACHINE_ARMNT:   "ARMNT",
+	dpe.IMAGE_FILE_MACHINE_ARM64:   "ARM64",
+	0xA641:                         "ARM64EC", // IMAGE_FILE_MACHINE_ARM64EC
+	0xA64E:                         "ARM64X",  // IMAGE_FILE_MACHINE_ARM64X
+	dpe.IMAGE_FILE_MACHINE_IA64:    "IA64",
+	dpe.IMAGE_FILE_MACHINE_THUMB:   "THUMB",
+	dpe.IMAGE_FILE_MACHINE_EBC:     "EBC",
+}
+
+// Machine returns the raw value of the Machine field of peh's FileHeader,
+// along with a human-readable name for that value. When the value is not
+// recognized, the name contains the value in hexadecimal.
+func (peh *PEHeaders) Machine() (uint16, string) {
+	machine := peh.fileHeader.Machine
+	if name, ok := machineNames[machine]; ok {
+		return machine, name
+	}
+	return machine, fmt.Sprintf("0x%04X", machine)
+}
+
+var subsystemNames = map[uint16]string{
+	dpe.IMAGE_SUBSYSTEM_UNKNOWN:                  "UNKNOWN",
+	dpe.IMAGE_SUBSYSTEM_NATIVE:                   "NATIVE",
+	dpe.IMAGE_SUBSYSTEM_WINDOWS_GUI:              "WINDOWS_GUI",
+	dpe.IMAGE_SUBSYSTEM_WINDOWS_CUI:              "WINDOWS_CUI",
+	dpe.IMAGE_SUBSYSTEM_OS2_CUI:                  "OS2_CUI",
+	dpe.IMAGE_SUBSYSTEM_POSIX_CUI:                "POSIX_CUI",
+	dpe.IMAGE_SUBSYSTEM_NATIVE_WINDOWS:           "NATIVE_WINDOWS",
+	dpe.IMAGE_SUBSYSTEM_WINDOWS_CE_GUI:           "WINDOWS_CE_GUI",
+	dpe.IMAGE_SUBSYSTEM_EFI_APPLICATION:          "EFI_APPLICATION",
+	dpe.IMAGE_SUBSYSTEM_EFI_BOOT_SERVICE_DRIVER:  "EFI_BOOT_SERVICE_DRIVER",
+	dpe.IMAGE_SUBSYSTEM_EFI_RUNTIME_DRIVER:       "EFI_RUNTIME_DRIVER",
+	dpe.IMAGE_SUBSYSTEM_EFI_ROM:                  "EFI_ROM",
+	dpe.IMAGE_SUBSYSTEM_XBOX:                     "XBOX",
+	dpe.IMAGE_SUBSYSTEM_WINDOWS_BOOT_APPLICATION: "WINDOWS_BOOT_APPLICATION",
+}
+
+// SubsystemInfo returns the raw value of the Subsystem field of peh's
+// OptionalHeader, along with a human-readable name for that value and the
+// minimum subsystem version that the binary requires. When the value is not
+// recognized, the name contains the value in hexadecimal.
+func (peh *PEHeaders) SubsystemInfo() (subsystem uint16, name string, majorVer, minorVer uint16) {
+	subsystem = peh.optionalHeader.GetSubsystem()
+	majorVer, minorVer = peh.optionalHeader.GetSubsystemVersion()
+	name, ok := subsystemNames[subsystem]
+	if !ok {
+		name = fmt.Sprintf("0x%04X", subsystem)
+	}
+	return subsystem, name, majorVer, minorVer
+}
+
+// LinkerVersion returns the version of the linker that produced peh, as
+// recorded in its OptionalHeader.
+func (peh *PEHeaders) LinkerVersion() (major, minor uint8) {
+	return peh.optionalHeader.GetLinkerVersion()
+}
+
+// SizeOfHeaders returns the combined size, in bytes, of peh's headers and
+// section table, as recorded in its OptionalHeader. This value is rounded up to
+// a multiple of the file alignment, and thus is also the file offset at which
+// section data may begin.
+func (peh *PEHeaders) SizeOfHeaders() uint32 {
+	return peh.optionalHeader.GetSizeOfHeaders()
+}
+
+// TimeDateStamp returns the time at which peh was linked, as recorded in its
+// FileHeader. It returns the zero time.Time if no timestamp is recorded. Note
+// that reproducible builds store a hash in this field instead of a time; see
+// IMAGE_DEBUG_TYPE_REPRO.
+func (peh *PEHeaders) TimeDateStamp() time.Time {
+	return wingoes.TimeFromUnixStamp(peh.fileHeader.TimeDateStamp)
+}
+
+// Characteristics returns the flags from the Characteristics field of peh's
+// FileHeader.
+func (peh *PEHeaders) Characteristics() ImageCharacteristics {
+	return ImageCharacteristics(peh.fileHeader.Characteristics)
+}
+
+// IsDLL returns true when peh describes a DLL.
+func (peh *PEHeaders) IsDLL() bool {
+	return peh.Characteristics()&IMAGE_FILE_DLL != 0
+}
+
+// IsExecutable returns true when peh describes a valid image that may be
+// executed or loaded; that is, it has no unresolved external references. Note
+// that this is true for DLLs as well as EXEs; use IsDLL to differentiate them.
+func (peh *PEHeaders) IsExecutable() bool {
+	return peh.Characteristics()&IMAGE_FILE_EXECUTABLE_IMAGE != 0
+}
+
+// IsMappedAsImage returns true when peh was created from a module that has
+// been loaded into memory by the OS loader, in which case its data is laid out
+// at RVAs relative to the module's base address. It returns false when peh was
+// created from a file (including a memory-mapped file), in which case its data
+// is laid out at file offsets.
+func (peh *PEHeaders) IsMappedAsImage() bool {
+	_, ok := peh.r.(*peModule)
+	return ok
+}
+
+// OptionalHeader returns the OptionalHeader that was parsed from peh.
 func (peh *PEHeaders) OptionalHeader() OptionalHeader {
 	return peh.optionalHeader
 }
 
-// Sections returns a slice containing all section headers parsed from peh.
+// OptionalHeaderBytes returns a copy of the raw bytes of peh's optional header,
+// laid out as they appear in the binary. The result only contains the fixed
+// portion of the optional header that precedes the data directory, whose
+// entries are adjusted by the loader in modules; use RawDataDirectoryEntry to
+// examine the data directory.
+func (peh *PEHeaders) OptionalHeaderBytes() []byte {
+	var buf bytes.Buffer
+	// Writes to a bytes.Buffer cannot fail, and optional headers always have
+	// a fixed size.
+	binary.Write(&buf, binary.LittleEndian, peh.optionalHeader)
+	dataDirectorySize := binary.Size([_IMAGE_NUMBEROF_DIRECTORY_ENTRIES]DataDirectoryEntry{})
+	return buf.Bytes()[:buf.Len()-dataDirectorySize]
+}
+
+// Sections returns a slice containing all section headers parsed from peh, in
+// the order in which they appear in the section table. When peh was created
+// from a loaded module, the result references the module's memory in-place;
+// callers must treat it as read-only.
 func (peh *PEHeaders) Sections() []SectionHeader {
 	return peh.sections
 }
 
+// SectionReader returns a reader over the contents of the section at index
+// in peh's section table. When peh was created from a file, the reader covers
+// the section's raw data within the file. When peh was created from a loaded
+// module, the reader covers the section's mapped memory. It returns
+// ErrIndexOutOfRange if index is not a valid index into Sections().
+func (peh *PEHeaders) SectionReader(index int) (*io.SectionReader, error) {
+	if index < 0 || index >= len(peh.sections) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	s := &peh.sections[index]
+	switch v := peh.r.(type) {
+	case *peFile:
+		return io.NewSectionReader(v, int64(s.PointerToRawData), int64(s.SizeOfRawData)), nil
+	case *peModule:
+		size := s.VirtualSize
+		if size == 0 {
+			// Some linkers leave VirtualSize unset.
+			size = s.SizeOfRawData
+		}
+		return io.NewSectionReader(v, int64(s.VirtualAddress), int64(size)), nil
+	default:
+		return nil, ErrInvalidBinary
+	}
+}
+
+// SectionName returns the name of the section at index in peh's section table.
+// Unlike (*SectionHeader).NameString, it resolves names of the form "/N" by
+// reading the name at offset N within the COFF string table. It returns
+// ErrIndexOutOfRange if index is not a valid index into Sections(), and
+// ErrUnavailableInModule when peh was created from a loaded module and the
+// name must be resolved, as the loader does not map the string table.
+func (peh *PEHeaders) SectionName(index int) (string, error) {
+	if index < 0 || index >= len(peh.sections) {
+		return "", ErrIndexOutOfRange
+	}
+
+	name := peh.sections[index].NameString()
+	offStr, ok := strings.CutPrefix(name, "/")
+	if !ok {
+		return name, nil
+	}
+	off, err := strconv.ParseUint(offStr, 10, 32)
+	if err != nil {
+		// Not an indirection, just a name that happens to begin with a slash.
+		return name, nil
+	}
+
+	if _, ok := peh.r.(*peFile); !ok {
+		return "", ErrUnavailableInModule
+	}
+
+	return peh.readCOFFString(uint32(off))
+}
+
+// coffSymbolSize is the size of an IMAGE_SYMBOL record.
+const coffSymbolSize = 18
+
+// readCOFFString reads the NUL-terminated string located at off within the
+// COFF string table, which immediately follows the COFF symbol table.
+func (peh *PEHeaders) readCOFFString(off uint32) (string, error) {
+	if peh.fileHeader.PointerToSymbolTable == 0 {
+		return "", fmt.Errorf("%w: long section name without a COFF string table", ErrInvalidBinary)
+	}
+
+	tableOff := int64(peh.fileHeader.PointerToSymbolTable) + int64(peh.fileHeader.NumberOfSymbols)*coffSymbolSize
+	var tableSize uint32
+	if err := binaryReadAt(peh.r, tableOff, &tableSize); err != nil {
+		return "", err
+	}
+	// Offsets include the 4-byte size field at the beginning of the table.
+	if off < uint32(unsafe.Sizeof(tableSize)) || off >= tableSize {
+		return "", fmt.Errorf("%w: COFF string table offset 0x%X is out of range", ErrInvalidBinary, off)
+	}
+
+	sr := io.NewSectionReader(peh.r, tableOff+int64(off), int64(tableSize-off))
+	b, err := bufio.NewReader(sr).ReadBytes(0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadLength
+		}
+		return "", err
+	}
+
+	return string(bytes.TrimSuffix(b, []byte{0})), nil
+}
+
+// ValidateSections performs strict validation of peh's section table, beyond
+// the checks that are performed while parsing the headers. It verifies that
+// each section's virtual range lies within SizeOfImage. When peh was created
+// from a file, it also verifies that each section's raw data lies within the
+// file, and that no two sections' raw data overlap. Malformed or crafted
+// binaries sometimes use these tricks to confuse parsers.
+//
+// Upon failure, the returned error wraps ErrInvalidBinary and names the
+// offending section.
+func (peh *PEHeaders) ValidateSections() error {
+	sizeOfImage := uint64(peh.optionalHeader.GetSizeOfImage())
+	for i, s := range peh.sections {
+		if uint64(s.VirtualAddress)+uint64(s.VirtualSize) > sizeOfImage {
+			return fmt.Errorf("%w: section %d (%q) virtual range exceeds SizeOfImage", ErrInvalidBinary, i, s.NameString())
+		}
+	}
+
+	if _, ok := peh.r.(*peFile); !ok {
+		// Raw data is not meaningful for loaded modules.
+		return nil
+	}
+
+	type rawRange struct {
+		index int
+		start uint64
+		end   uint64
+	}
+
+	fileSize := uint64(peh.r.Limit())
+	ranges := make([]rawRange, 0, len(peh.sections))
+	for i, s := range peh.sections {
+		if s.SizeOfRawData == 0 {
+			// Uninitialized data occupies no space in the file.
+			continue
+		}
+
+		r := rawRange{index: i, start: uint64(s.PointerToRawData), end: uint64(s.PointerToRawData) + uint64(s.SizeOfRawData)}
+		if r.end > fileSize {
+			return fmt.Errorf("%w: section %d (%q) raw data exceeds file size", ErrInvalidBinary, i, s.NameString())
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	for i := 1; i < len(ranges); i++ {
+		prev, cur := ranges[i-1], ranges[i]
+		if cur.start < prev.end {
+			return fmt.Errorf("%w: section %d (%q) raw data overlaps section %d (%q)", ErrInvalidBinary,
+				cur.index, peh.sections[cur.index].NameString(), prev.index, peh.sections[prev.index].NameString())
+		}
+	}
+
+	return nil
+}
+
+// DOSStub returns a copy of the DOS stub in peh: the bytes between the end of
+// the IMAGE_DOS_HEADER and the beginning of the PE headers. The result is empty
+// when the PE headers immediately follow (or overlap) the IMAGE_DOS_HEADER.
+func (peh *PEHeaders) DOSStub() ([]byte, error) {
+	if peh.e_lfanew <= sizeofIMAGE_DOS_HEADER {
+		return []byte{}, nil
+	}
+
+	result := make([]byte, peh.e_lfanew-sizeofIMAGE_DOS_HEADER)
+	if _, err := peh.r.ReadAt(result, sizeofIMAGE_DOS_HEADER); err != nil {
+		if err == io.EOF {
+			err = ErrBadLength
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // DataDirectoryEntry is a PE/COFF IMAGE_DATA_DIRECTORY structure.
 type DataDirectoryEntry = dpe.DataDirectory
 
@@ -129,7 +508,10 @@ func resolveOptionalHeader(machine uint16, r peReader, offset uint32) (OptionalH
 	}
 }
 
-func checkMagic(oh OptionalHeader, machine uint16) bool {
+// checkMagic verifies that magic, the optional header's Magic field, is
+// consistent with machine: PE32 headers may only accompany 32-bit machines, and
+// PE32+ headers may only accompany 64-bit machines.
+func checkMagic(magic, machine uint16) error {
 	var expectedMagic uint16
 	switch machine {
 	case dpe.IMAGE_FILE_MACHINE_I386:
@@ -137,10 +519,14 @@ func checkMagic(oh OptionalHeader, machine uint16) bool {
 	case dpe.IMAGE_FILE_MACHINE_AMD64, dpe.IMAGE_FILE_MACHINE_ARM64:
 		expectedMagic = 0x020B
 	default:
-		panic(fmt.Sprintf("unsupported machine 0x%04X", machine))
+		return ErrUnsupportedMachine
+	}
+
+	if magic != expectedMagic {
+		return fmt.Errorf("%w: optional header magic 0x%04X is inconsistent with machine 0x%04X", ErrInvalidBinary, magic, machine)
 	}
 
-	return oh.GetMagic() == expectedMagic
+	return nil
 }
 
 type peBounds struct {
@@ -148,8 +534,18 @@ type peBounds struct {
 	limit uintptr
 }
 
+// fileReader provides access to the raw contents of a PE file. It is
+// implemented by *os.File, as well as by views of files that have been mapped
+// into memory.
+type fileReader interface {
+	io.Closer
+	io.ReaderAt
+}
+
+// peFile is a peReader whose offsets are file offsets, regardless of whether
+// its contents are being read from disk or from a memory-mapped view.
 type peFile struct {
-	*os.File
+	fileReader
 	peBounds
 }
 
@@ -158,11 +554,6 @@ func (pef *peFile) Base() uintptr {
 }
 
 func (pef *peFile) Limit() uintptr {
-	if pef.limit == 0 {
-		if fi, err := pef.Stat(); err == nil {
-			pef.limit = uintptr(fi.Size())
-		}
-	}
 	return pef.limit
 }
 
@@ -194,8 +585,14 @@ func NewPEFromFileName(filename string) (*PEHeaders, error) {
 }
 
 func newPEFromFile(f *os.File) (*PEHeaders, error) {
-	// peBounds base is 0, limit is loaded lazily
-	pef := &peFile{File: f}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// peBounds base is 0, limit is the file's size
+	pef := &peFile{fileReader: f, peBounds: peBounds{limit: uintptr(fi.Size())}}
 	peh, err := loadHeaders(pef)
 	if err != nil {
 		pef.Close()
@@ -205,9 +602,24 @@ func newPEFromFile(f *os.File) (*PEHeaders, error) {
 	return peh, nil
 }
 
-// Close frees any resources that were opened when peh was created.
-func (peh *PEHeaders) Close() error {
-	return peh.r.Close()
+// Close frees any resources that were opened when peh was created. For
+// PEHeaders created from a file, it closes that file. For PEHeaders created
+// from a loaded module, it releases the reference to the module that was
+// acquired by the constructor; the module itself is only unloaded once all
+// other references to it have also been released.
+//
+// Close is idempotent: only the first call frees resources and reports any
+// error encountered while doing so. Subsequent calls return nil, as does
+// calling Close on a nil *PEHeaders.
+func (peh *PEHeaders) Close() (err error) {
+	if peh == nil {
+		return nil
+	}
+
+	peh.closeOnce.Do(func() {
+		err = peh.r.Close()
+	})
+	return err
 }
 
 type rvaType interface {
@@ -230,6 +642,17 @@ func addOffset[O rvaType](base uintptr, off O) (addr uintptr, ok bool) {
 	return base - negation, true
 }
 
+// rangeFits returns true when the size bytes located at offset off lie entirely
+// within r's bounds.
+func rangeFits[O rvaType](r peReader, off O, size uint32) bool {
+	addr, ok := addOffset(r.Base(), off)
+	if !ok {
+		return false
+	}
+	end, ok := addOffset(addr, size)
+	return ok && end <= r.Limit()
+}
+
 func binaryRead(r io.Reader, data any) (err error) {
 	// Supported Windows archs are now always LittleEndian
 	err = binary.Read(r, binary.LittleEndian, data)
@@ -239,20 +662,25 @@ func binaryRead(r io.Reader, data any) (err error) {
 	return err
 }
 
-// readStruct reads a T from offset rva. If r is a *peModule, the returned *T
-// points to the data in-place.
+// binaryReadAt is like binaryRead, but reads from offset off in r. Since it
+// does not depend on r's current position, it is safe for concurrent use.
+func binaryReadAt(r io.ReaderAt, off int64, data any) error {
+	return binaryRead(io.NewSectionReader(r, off, math.MaxInt64-off), data)
+}
+
+// readStruct reads a T from offset rva. If r is a *peFile, the returned *T
+// is a freshly-allocated copy that belongs to the caller. If r is a *peModule,
+// the returned *T points to the data in-place: it must be treated as read-only,
+// and it is only valid for as long as the module remains loaded. Use
+// readStructCopy when the result may outlive the PEHeaders that produced it.
 // Note that currently this function will fail if rva references memory beyond
 // the bounds of the binary; in the case of modules, this may need to be relaxed
 // in some cases due to tampering by third-party crapware.
 func readStruct[T any, R rvaType](r peReader, rva R) (*T, error) {
 	switch v := r.(type) {
 	case *peFile:
-		if _, err := r.Seek(int64(rva), io.SeekStart); err != nil {
-			return nil, err
-		}
-
 		result := new(T)
-		if err := binaryRead(r, result); err != nil {
+		if err := binaryReadAt(r, int64(rva), result); err != nil {
 			return nil, err
 		}
 
@@ -274,19 +702,17 @@ func readStruct[T any, R rvaType](r peReader, rva R) (*T, error) {
 }
 
 // readStructArray reads a []T with length count from offset rva. If r is a
-// *peModule, the returned []T references the data in-place.
+// *peModule, the returned []T references the data in-place, subject to the
+// same lifetime restrictions as readStruct. Use readStructArrayCopy when the
+// result may outlive the PEHeaders that produced it.
 // Note that currently this function will fail if rva references memory beyond
 // the bounds of the binary; in the case of modules, this may need to be relaxed
 // in some cases due to tampering by third-party crapware.
 func readStructArray[T any, R rvaType](r peReader, rva R, count int) ([]T, error) {
 	switch v := r.(type) {
 	case *peFile:
-		if _, err := r.Seek(int64(rva), io.SeekStart); err != nil {
-			return nil, err
-		}
-
 		result := make([]T, count)
-		if err := binaryRead(r, result); err != nil {
+		if err := binaryReadAt(r, int64(rva), result); err != nil {
 			return nil, err
 		}
 
@@ -307,14 +733,35 @@ func readStructArray[T any, R rvaType](r peReader, rva R, count int) ([]T, error
 	}
 }
 
-func loadHeaders(r peReader) (*PEHeaders, error) {
-	// Check the signature of the DOS stub header
-	if _, err := r.Seek(0, io.SeekStart); err != nil {
+// readStructCopy reads a T from offset rva and returns it by value, so that
+// the result never references r's underlying memory.
+func readStructCopy[T any, R rvaType](r peReader, rva R) (T, error) {
+	var zero T
+	result, err := readStruct[T](r, rva)
+	if err != nil {
+		return zero, err
+	}
+	return *result, nil
+}
+
+// readStructArrayCopy reads a []T with length count from offset rva. The result
+// never references r's underlying memory.
+func readStructArrayCopy[T any, R rvaType](r peReader, rva R, count int) ([]T, error) {
+	result, err := readStructArray[T](r, rva, count)
+	if err != nil {
 		return nil, err
 	}
+	if _, ok := r.(*peFile); ok {
+		// readStructArray already allocated a fresh slice.
+		return result, nil
+	}
+	return append([]T(nil), result...), nil
+}
 
+func loadHeaders(r peReader) (*PEHeaders, error) {
+	// Check the signature of the DOS stub header
 	var mz uint16
-	if err := binaryRead(r, &mz); err != nil {
+	if err := binaryReadAt(r, 0, &mz); err != nil {
 		if err == ErrBadLength {
 			err = ErrInvalidBinary
 		}
@@ -324,14 +771,9 @@ func loadHeaders(r peReader) (*PEHeaders, error) {
 		return nil, ErrInvalidBinary
 	}
 
-	// Seek to the offset of the value that points to the beginning of the PE headers
-	if _, err := r.Seek(offsetIMAGE_DOS_HEADERe_lfanew, io.SeekStart); err != nil {
-		return nil, ErrInvalidBinary
-	}
-
 	// Load the offset to the beginning of the PE headers
 	var e_lfanew int32
-	if err := binaryRead(r, &e_lfanew); err != nil {
+	if err := binaryReadAt(r, offsetIMAGE_DOS_HEADERe_lfanew, &e_lfanew); err != nil {
 		if err == ErrBadLength {
 			err = ErrInvalidBinary
 		}
@@ -340,17 +782,15 @@ func loadHeaders(r peReader) (*PEHeaders, error) {
 	if e_lfanew <= 0 {
 		return nil, ErrInvalidBinary
 	}
-	if addr, ok := addOffset(r.Base(), e_lfanew); !ok || addr >= r.Limit() {
+	// The PE signature and file header must both fit within r before we may
+	// read any of them.
+	if !rangeFits(r, e_lfanew, uint32(unsafe.Sizeof(uint32(0))+unsafe.Sizeof(FileHeader{}))) {
 		return nil, ErrInvalidBinary
 	}
 
 	// Check the PE signature
-	if _, err := r.Seek(int64(e_lfanew), io.SeekStart); err != nil {
-		return nil, ErrInvalidBinary
-	}
-
 	var pe uint32
-	if err := binaryRead(r, &pe); err != nil {
+	if err := binaryReadAt(r, int64(e_lfanew), &pe); err != nil {
 		if err == ErrBadLength {
 			err = ErrInvalidBinary
 		}
@@ -368,6 +808,9 @@ func loadHeaders(r peReader) (*PEHeaders, error) {
 
 	fileHeader, err := readStruct[FileHeader](r, fileHeaderOffset)
 	if err != nil {
+		if err == ErrBadLength {
+			err = ErrInvalidBinary
+		}
 		return nil, err
 	}
 
@@ -376,19 +819,31 @@ func loadHeaders(r peReader) (*PEHeaders, error) {
 		return nil, ErrUnsupportedMachine
 	}
 
-	// Read the optional header
+	// Read the optional header, which must fit within r in its entirety
 	optionalHeaderOffset := uint32(fileHeaderOffset) + uint32(unsafe.Sizeof(*fileHeader))
-	if addr, ok := addOffset(r.Base(), optionalHeaderOffset); !ok || addr >= r.Limit() {
+	if !rangeFits(r, optionalHeaderOffset, uint32(fileHeader.SizeOfOptionalHeader)) {
 		return nil, ErrInvalidBinary
 	}
 
-	optionalHeader, err := resolveOptionalHeader(machine, r, optionalHeaderOffset)
-	if err != nil {
+	// The layout of the optional header depends on its magic, so we must ensure
+	// that the magic agrees with the machine before interpreting the rest of it.
+	var magic uint16
+	if err := binaryReadAt(r, int64(optionalHeaderOffset), &magic); err != nil {
+		if err == ErrBadLength {
+			err = ErrInvalidBinary
+		}
+		return nil, err
+	}
+	if err := checkMagic(magic, machine); err != nil {
 		return nil, err
 	}
 
-	if !checkMagic(optionalHeader, machine) {
-		return nil, ErrInvalidBinary
+	optionalHeader, err := resolveOptionalHeader(machine, r, optionalHeaderOffset)
+	if err != nil {
+		if err == ErrBadLength {
+			err = ErrInvalidBinary
+		}
+		return nil, err
 	}
 
 	if fileHeader.SizeOfOptionalHeader < optionalHeader.SizeOf() {
@@ -412,54 +867,75 @@ func loadHeaders(r peReader) (*PEHeaders, error) {
 
 	// Read in the section table
 	sectionTableOffset := optionalHeaderOffset + uint32(fileHeader.SizeOfOptionalHeader)
-	if addr, ok := addOffset(r.Base(), sectionTableOffset); !ok || addr >= r.Limit() {
-		return nil, ErrInvalidBinary
+	sectionTableSize := uint32(numSections) * uint32(unsafe.Sizeof(SectionHeader{}))
+	if !rangeFits(r, sectionTableOffset, sectionTableSize) {
+		return nil, fmt.Errorf("%w: section table containing %d sections is truncated", ErrInvalidBinary, numSections)
 	}
 
 	sections, err := readStructArray[SectionHeader](r, sectionTableOffset, int(numSections))
 	if err != nil {
+		if err == ErrBadLength {
+			err = ErrInvalidBinary
+		}
 		return nil, err
 	}
 
-	return &PEHeaders{r: r, fileHeader: fileHeader, optionalHeader: optionalHeader, sections: sections}, nil
+	return &PEHeaders{r: r, e_lfanew: e_lfanew, fileHeader: fileHeader, optionalHeader: optionalHeader, sections: sections}, nil
 }
 
 type rva32 interface {
 	~int32 | ~uint32
 }
 
-// resolveRVA resolves rva, or returns 0 if unavailable.
-func resolveRVA[R rva32](nfo *PEHeaders, rva R) R {
-	if _, ok := nfo.r.(*peFile); !ok {
-		// Just the identity function in this case.
-		return rva
+// sectionContainingRVA returns the section whose virtual address range
+// contains rva, or nil if no such section exists.
+func (nfo *PEHeaders) sectionContainingRVA(rva uint32) *SectionHeader {
+	for i := range nfo.sections {
+		s := &nfo.sections[i]
+		if rva >= s.VirtualAddress && uint64(rva) < uint64(s.VirtualAddress)+uint64(s.VirtualSize) {
+			return s
+		}
 	}
 
+	return nil
+}
+
+// IsValidRVA returns true when rva falls within the virtual address range of
+// any of nfo's sections. Use it to distinguish genuine RVAs from zero or
+// otherwise bogus values before following them.
+func (nfo *PEHeaders) IsValidRVA(rva uint32) bool {
+	return nfo.sectionContainingRVA(rva) != nil
+}
+
+// resolveRVA resolves rva to an offset suitable for reading from nfo.r. ok is
+// false when rva is not positive, or (for files) when no section contains rva's
+// raw data. Callers must check ok instead of comparing the result against zero,
+// since a zero offset does not by itself indicate failure.
+func resolveRVA[R rva32](nfo *PEHeaders, rva R) (_ R, ok bool) {
 	if rva <= 0 {
-		return 0
+		return 0, false
 	}
 
-	// We walk the section table, locating the section that would contain rva if
-	// we were mapped into memory. We then calculate the offset of rva from the
-	// starting virtual address of the section, and then add that offset to the
-	// section's starting file pointer.
-	urva := uint32(rva)
-	for _, s := range nfo.sections {
-		if urva < s.VirtualAddress {
-			continue
-		}
-		if urva >= (s.VirtualAddress + s.VirtualSize) {
-			continue
-		}
-		voff := urva - s.VirtualAddress
-		foff := s.PointerToRawData + voff
-		if foff >= s.PointerToRawData+s.SizeOfRawData {
-			return 0
-		}
-		return R(foff)
+	if _, isFile := nfo.r.(*peFile); !isFile {
+		// Just the identity function in this case.
+		return rva, true
+	}
+
+	// We locate the section that would contain rva if we were mapped into
+	// memory. We then calculate the offset of rva from the starting virtual
+	// address of the section, and then add that offset to the section's
+	// starting file pointer.
+	s := nfo.sectionContainingRVA(uint32(rva))
+	if s == nil {
+		return 0, false
+	}
+
+	voff := uint32(rva) - s.VirtualAddress
+	if voff >= s.SizeOfRawData {
+		return 0, false
 	}
 
-	return 0
+	return R(s.PointerToRawData + voff), true
 }
 
 // DataDirectoryIndex is an enumeration specifying a particular entry in the
@@ -486,6 +962,37 @@ const (
 
 const _IMAGE_NUMBEROF_DIRECTORY_ENTRIES = 16
 
+// NumDataDirectories returns the number of entries in nfo's data directory.
+// This is the value of the optional header's NumberOfRvaAndSizes field, clamped
+// to the maximum number of entries that the optional header can hold. Indices
+// in the range [0, nfo.NumDataDirectories()) may be passed to
+// DataDirectoryEntry without encountering ErrIndexOutOfRange.
+func (nfo *PEHeaders) NumDataDirectories() int {
+	return len(nfo.optionalHeader.GetDataDirectory())
+}
+
+// RawDataDirectoryEntry returns the unparsed entry in nfo's data directory at
+// index idx. Unlike DataDirectoryEntry, its return type does not depend on idx.
+// It returns ErrIndexOutOfRange if idx is not a valid data directory index, and
+// ErrNotPresent if the entry is not populated in the PE image.
+func (nfo *PEHeaders) RawDataDirectoryEntry(idx DataDirectoryIndex) (DataDirectoryEntry, error) {
+	if idx < 0 || int(idx) >= _IMAGE_NUMBEROF_DIRECTORY_ENTRIES {
+		return DataDirectoryEntry{}, ErrIndexOutOfRange
+	}
+
+	dd := nfo.optionalHeader.GetDataDirectory()
+	if int(idx) >= len(dd) {
+		return DataDirectoryEntry{}, ErrNotPresent
+	}
+
+	dde := dd[idx]
+	if dde.VirtualAddress == 0 || dde.Size == 0 {
+		return DataDirectoryEntry{}, ErrNotPresent
+	}
+
+	return dde, nil
+}
+
 // DataDirectoryEntry returns information from nfo's data directory at index idx.
 // The type of the return value depends on the value of idx. Most values for idx
 // currently return the DataDirectoryEntry itself, however it will return more
@@ -493,23 +1000,27 @@ const _IMAGE_NUMBEROF_DIRECTORY_ENTRIES = 16
 //
 // * IMAGE_DIRECTORY_ENTRY_SECURITY returns []AuthenticodeCert
 // * IMAGE_DIRECTORY_ENTRY_DEBUG returns []IMAGE_DEBUG_DIRECTORY
+// * IMAGE_DIRECTORY_ENTRY_IAT returns *IATInfo
 //
 // Note that other idx values _will_ be modified in the future to support more
 // sophisticated return values, so be careful to structure your type assertions
-// accordingly.
+// accordingly. Use RawDataDirectoryEntry to unconditionally obtain the
+// unparsed DataDirectoryEntry.
+//
+// When idx was parsed eagerly by ParseFiles, every call returns its own copy
+// of the cached result.
 func (nfo *PEHeaders) DataDirectoryEntry(idx DataDirectoryIndex) (any, error) {
-	if int(idx) >= _IMAGE_NUMBEROF_DIRECTORY_ENTRIES {
-		return nil, ErrIndexOutOfRange
+	if cached, ok := nfo.dirCache[idx]; ok {
+		return cloneDataDirectoryValue(cached.value), cached.err
 	}
 
-	dd := nfo.optionalHeader.GetDataDirectory()
-	if int(idx) >= len(dd) {
-		return nil, ErrNotPresent
-	}
+	return nfo.dataDirectoryEntry(idx)
+}
 
-	dde := dd[idx]
-	if dde.VirtualAddress == 0 || dde.Size == 0 {
-		return nil, ErrNotPresent
+func (nfo *PEHeaders) dataDirectoryEntry(idx DataDirectoryIndex) (any, error) {
+	dde, err := nfo.RawDataDirectoryEntry(idx)
+	if err != nil {
+		return nil, err
 	}
 
 	switch idx {
@@ -522,12 +1033,154 @@ func (nfo *PEHeaders) DataDirectoryEntry(idx DataDirectoryIndex) (any, error) {
 		return nfo.extractAuthenticode(dde)
 	case IMAGE_DIRECTORY_ENTRY_DEBUG:
 		return nfo.extractDebugInfo(dde)
+	case IMAGE_DIRECTORY_ENTRY_IAT:
+		return &IATInfo{nfo: nfo, DataDirectoryEntry: dde}, nil
 	// case IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR:
 	default:
 		return dde, nil
 	}
 }
 
+// DataDirectoryEntryStrict is like DataDirectoryEntry, except that it first
+// verifies that the data referenced by the entry at idx is entirely present in
+// nfo. Whereas DataDirectoryEntry may silently produce truncated results, this
+// method returns an error wrapping ErrInvalidBinary when:
+//
+// * the optional header declares more data directory entries than it can hold;
+// * the entry's data extends beyond the end of the section (or headers)
+// containing it; or
+// * when nfo was created from a file, the entry's data extends beyond the end of
+// the file.
+func (nfo *PEHeaders) DataDirectoryEntryStrict(idx DataDirectoryIndex) (any, error) {
+	dde, err := nfo.RawDataDirectoryEntry(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	if declared := nfo.declaredNumDataDirectories(); declared > _IMAGE_NUMBEROF_DIRECTORY_ENTRIES {
+		return nil, fmt.Errorf("%w: optional header declares %d data directory entries", ErrInvalidBinary, declared)
+	}
+
+	if err := nfo.checkDataDirectoryEntryBounds(idx, dde); err != nil {
+		return nil, err
+	}
+
+	return nfo.DataDirectoryEntry(idx)
+}
+
+// declaredNumDataDirectories returns the unclamped value of the optional
+// header's NumberOfRvaAndSizes field.
+func (nfo *PEHeaders) declaredNumDataDirectories() uint32 {
+	switch oh := nfo.optionalHeader.(type) {
+	case *optionalHeader32:
+		return oh.NumberOfRvaAndSizes
+	case *optionalHeader64:
+		return oh.NumberOfRvaAndSizes
+	default:
+		return 0
+	}
+}
+
+func (nfo *PEHeaders) checkDataDirectoryEntryBounds(idx DataDirectoryIndex, dde DataDirectoryEntry) error {
+	_, isFile := nfo.r.(*peFile)
+	start := uint64(dde.VirtualAddress)
+	end := start + uint64(dde.Size)
+
+	if idx == IMAGE_DIRECTORY_ENTRY_SECURITY {
+		// This entry's VirtualAddress is a file offset, and its data is not
+		// mapped into modules.
+		if isFile && end > uint64(nfo.r.Limit()) {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the end of the file", ErrInvalidBinary, idx)
+		}
+		return nil
+	}
+
+	// Some entries (such as bound imports) reside within the headers.
+	if sizeOfHeaders := uint64(nfo.optionalHeader.GetSizeOfHeaders()); start < sizeOfHeaders {
+		if end > sizeOfHeaders {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the end of the headers", ErrInvalidBinary, idx)
+		}
+		if isFile && end > uint64(nfo.r.Limit()) {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the end of the file", ErrInvalidBinary, idx)
+		}
+		return nil
+	}
+
+	for i, s := range nfo.sections {
+		secStart := uint64(s.VirtualAddress)
+		if start < secStart || start >= secStart+uint64(s.VirtualSize) {
+			continue
+		}
+
+		if end > secStart+uint64(s.VirtualSize) {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the end of section %d (%q)", ErrInvalidBinary, idx, i, s.NameString())
+		}
+		if !isFile {
+			return nil
+		}
+
+		// The data must also be present in the file.
+		if end-secStart > uint64(s.SizeOfRawData) {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the raw data of section %d (%q)", ErrInvalidBinary, idx, i, s.NameString())
+		}
+		if rawEnd := uint64(s.PointerToRawData) + (end - secStart); rawEnd > uint64(nfo.r.Limit()) {
+			return fmt.Errorf("%w: data directory entry %d extends beyond the end of the file", ErrInvalidBinary, idx)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: data directory entry %d does not reside within any section", ErrInvalidBinary, idx)
+}
+
+// IATInfo describes the bounds of the import address table (IAT). The IAT is
+// an array of thunks, one per imported function, that the loader overwrites
+// with the addresses of those functions. Thunks are 32 bits wide in PE32
+// binaries and 64 bits wide in PE32+ binaries.
+type IATInfo struct {
+	nfo *PEHeaders
+	// DataDirectoryEntry contains the RVA and size, in bytes, of the IAT.
+	DataDirectoryEntry
+}
+
+// ThunkSize returns the size, in bytes, of each thunk in the IAT.
+func (iat *IATInfo) ThunkSize() uint32 {
+	if iat.nfo.optionalHeader.GetMagic() == 0x010B {
+		return 4
+	}
+	return 8
+}
+
+// Thunks returns the raw contents of each slot in the IAT. Thunks from PE32
+// binaries are zero-extended to 64 bits. The RVA of the thunk at index i is
+// iat.VirtualAddress + i * iat.ThunkSize().
+//
+// When iat was obtained from a file, the thunks contain their values prior to
+// binding. When obtained from a loaded module, they contain the addresses that
+// were resolved by the loader.
+func (iat *IATInfo) Thunks() ([]uint64, error) {
+	rva, ok := resolveRVA(iat.nfo, iat.VirtualAddress)
+	if !ok {
+		return nil, ErrResolvingFileRVA
+	}
+
+	count := int(iat.Size / iat.ThunkSize())
+	if iat.ThunkSize() == 8 {
+		return readStructArrayCopy[uint64](iat.nfo.r, rva, count)
+	}
+
+	thunks32, err := readStructArray[uint32](iat.nfo.r, rva, count)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uint64, len(thunks32))
+	for i, t := range thunks32 {
+		result[i] = uint64(t)
+	}
+
+	return result, nil
+}
+
 // WIN_CERT_REVISION is an enumeration from the Windows SDK.
 type WIN_CERT_REVISION uint16
 
@@ -607,6 +1260,35 @@ const (
 	IMAGE_DEBUG_TYPE_EX_DLLCHARACTERISTICS IMAGE_DEBUG_TYPE = 20
 )
 
+var debugTypeNames = map[IMAGE_DEBUG_TYPE]string{
+	IMAGE_DEBUG_TYPE_UNKNOWN:               "IMAGE_DEBUG_TYPE_UNKNOWN",
+	IMAGE_DEBUG_TYPE_COFF:                  "IMAGE_DEBUG_TYPE_COFF",
+	IMAGE_DEBUG_TYPE_CODEVIEW:              "IMAGE_DEBUG_TYPE_CODEVIEW",
+	IMAGE_DEBUG_TYPE_FPO:                   "IMAGE_DEBUG_TYPE_FPO",
+	IMAGE_DEBUG_TYPE_MISC:                  "IMAGE_DEBUG_TYPE_MISC",
+	IMAGE_DEBUG_TYPE_EXCEPTION:             "IMAGE_DEBUG_TYPE_EXCEPTION",
+	IMAGE_DEBUG_TYPE_FIXUP:                 "IMAGE_DEBUG_TYPE_FIXUP",
+	IMAGE_DEBUG_TYPE_OMAP_TO_SRC:           "IMAGE_DEBUG_TYPE_OMAP_TO_SRC",
+	IMAGE_DEBUG_TYPE_OMAP_FROM_SRC:         "IMAGE_DEBUG_TYPE_OMAP_FROM_SRC",
+	IMAGE_DEBUG_TYPE_BORLAND:               "IMAGE_DEBUG_TYPE_BORLAND",
+	IMAGE_DEBUG_TYPE_RESERVED10:            "IMAGE_DEBUG_TYPE_RESERVED10",
+	IMAGE_DEBUG_TYPE_CLSID:                 "IMAGE_DEBUG_TYPE_CLSID",
+	IMAGE_DEBUG_TYPE_VC_FEATURE:            "IMAGE_DEBUG_TYPE_VC_FEATURE",
+	IMAGE_DEBUG_TYPE_POGO:                  "IMAGE_DEBUG_TYPE_POGO",
+	IMAGE_DEBUG_TYPE_ILTCG:                 "IMAGE_DEBUG_TYPE_ILTCG",
+	IMAGE_DEBUG_TYPE_MPX:                   "IMAGE_DEBUG_TYPE_MPX",
+	IMAGE_DEBUG_TYPE_REPRO:                 "IMAGE_DEBUG_TYPE_REPRO",
+	IMAGE_DEBUG_TYPE_SPGO:                  "IMAGE_DEBUG_TYPE_SPGO",
+	IMAGE_DEBUG_TYPE_EX_DLLCHARACTERISTICS: "IMAGE_DEBUG_TYPE_EX_DLLCHARACTERISTICS",
+}
+
+func (t IMAGE_DEBUG_TYPE) String() string {
+	if name, ok := debugTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("IMAGE_DEBUG_TYPE(%d)", uint32(t))
+}
+
 // IMAGE_DEBUG_DIRECTORY describes debug information embedded in the binary.
 type IMAGE_DEBUG_DIRECTORY struct {
 	Characteristics  uint32
@@ -619,24 +1301,77 @@ type IMAGE_DEBUG_DIRECTORY struct {
 	PointerToRawData uint32
 }
 
+// Time returns de's TimeDateStamp as a time.Time. It returns the zero
+// time.Time if no timestamp is recorded.
+func (de *IMAGE_DEBUG_DIRECTORY) Time() time.Time {
+	return wingoes.TimeFromUnixStamp(de.TimeDateStamp)
+}
+
 func (nfo *PEHeaders) extractDebugInfo(dde DataDirectoryEntry) (any, error) {
-	rva := resolveRVA(nfo, dde.VirtualAddress)
-	if rva == 0 {
+	rva, ok := resolveRVA(nfo, dde.VirtualAddress)
+	if !ok {
 		return nil, ErrResolvingFileRVA
 	}
 
 	count := dde.Size / uint32(unsafe.Sizeof(IMAGE_DEBUG_DIRECTORY{}))
-	return readStructArray[IMAGE_DEBUG_DIRECTORY](nfo.r, rva, int(count))
+	return readStructArrayCopy[IMAGE_DEBUG_DIRECTORY](nfo.r, rva, int(count))
+}
+
+// DebugEntry pairs an entry from the debug directory with the name of its
+// type, for display purposes.
+type DebugEntry struct {
+	IMAGE_DEBUG_DIRECTORY
+	// TypeName is the name of the entry's Type, such as
+	// "IMAGE_DEBUG_TYPE_CODEVIEW". Unrecognized types are named numerically.
+	TypeName string
+}
+
+// DebugEntries returns every entry in nfo's debug directory, in the order in
+// which they appear. Pass each entry's IMAGE_DEBUG_DIRECTORY to the
+// appropriate Extract method (such as ExtractCodeViewInfo) to decode its data.
+// It returns ErrNotPresent if nfo has no debug directory.
+func (nfo *PEHeaders) DebugEntries() ([]DebugEntry, error) {
+	dirsAny, err := nfo.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := dirsAny.([]IMAGE_DEBUG_DIRECTORY)
+	result := make([]DebugEntry, 0, len(dirs))
+	for _, de := range dirs {
+		result = append(result, DebugEntry{IMAGE_DEBUG_DIRECTORY: de, TypeName: de.Type.String()})
+	}
+
+	return result, nil
 }
 
+// CodeViewFormat identifies the layout of a CodeView debug record, as
+// indicated by the record's leading signature.
+type CodeViewFormat uint32
+
+const (
+	// CodeViewFormatPDB70 identifies "RSDS" records, which reference PDB 7.0
+	// files by GUID.
+	CodeViewFormatPDB70 = CodeViewFormat(0x53445352)
+	// CodeViewFormatPDB20 identifies "NB10" records, which reference PDB 2.0
+	// files by a 32-bit signature (typically a timestamp). These are produced
+	// by older toolchains.
+	CodeViewFormatPDB20 = CodeViewFormat(0x3031424E)
+)
+
 // IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED contains CodeView debug information
 // embedded in the PE file. Note that this structure's ABI does not match its C
-// counterpart because the former uses a Go string and the latter is packed and
-// also includes a signature field.
+// counterparts because the former uses a Go string and the latter are packed
+// and differ in layout between PDB 2.0 and PDB 7.0.
 type IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED struct {
-	GUID    wingoes.GUID
-	Age     uint32
-	PDBPath string
+	// Format indicates which of GUID or Signature identifies the PDB file.
+	Format CodeViewFormat
+	// GUID identifies the PDB file when Format is CodeViewFormatPDB70.
+	GUID wingoes.GUID
+	// Signature identifies the PDB file when Format is CodeViewFormatPDB20.
+	Signature uint32
+	Age       uint32
+	PDBPath   string
 }
 
 // String returns the data from u formatted in the same way that Microsoft
@@ -644,27 +1379,41 @@ type IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED struct {
 // to a specific binary.
 func (u *IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED) String() string {
 	var b strings.Builder
-	fmt.Fprintf(&b, "%08X%04X%04X", u.GUID.Data1, u.GUID.Data2, u.GUID.Data3)
-	for _, v := range u.GUID.Data4 {
-		fmt.Fprintf(&b, "%02X", v)
+	if u.Format == CodeViewFormatPDB20 {
+		fmt.Fprintf(&b, "%08X", u.Signature)
+	} else {
+		fmt.Fprintf(&b, "%08X%04X%04X", u.GUID.Data1, u.GUID.Data2, u.GUID.Data3)
+		for _, v := range u.GUID.Data4 {
+			fmt.Fprintf(&b, "%02X", v)
+		}
 	}
 	fmt.Fprintf(&b, "%X", u.Age)
 	return b.String()
 }
 
-const codeViewSignature = 0x53445352
-
 func (u *IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED) unpack(r *bufio.Reader) error {
-	var signature uint32
-	if err := binaryRead(r, &signature); err != nil {
+	if err := binaryRead(r, &u.Format); err != nil {
 		return err
 	}
-	if signature != codeViewSignature {
-		return ErrBadCodeView
-	}
 
-	if err := binaryRead(r, &u.GUID); err != nil {
-		return err
+	switch u.Format {
+	case CodeViewFormatPDB70:
+		if err := binaryRead(r, &u.GUID); err != nil {
+			return err
+		}
+	case CodeViewFormatPDB20:
+		// The signature is preceded by an offset that is always zero for records
+		// that reference external PDB files.
+		var pdb20 struct {
+			Offset    uint32
+			Signature uint32
+		}
+		if err := binaryRead(r, &pdb20); err != nil {
+			return err
+		}
+		u.Signature = pdb20.Signature
+	default:
+		return fmt.Errorf("%w: unsupported signature 0x%08X", ErrBadCodeView, uint32(u.Format))
 	}
 
 	if err := binaryRead(r, &u.Age); err != nil {
@@ -681,6 +1430,18 @@ func (u *IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED) unpack(r *bufio.Reader) error {
 	return nil
 }
 
+// debugDataReader returns a reader over the data referenced by de.
+func (nfo *PEHeaders) debugDataReader(de IMAGE_DEBUG_DIRECTORY) (*io.SectionReader, error) {
+	switch v := nfo.r.(type) {
+	case *peFile:
+		return io.NewSectionReader(v, int64(de.PointerToRawData), int64(de.SizeOfData)), nil
+	case *peModule:
+		return io.NewSectionReader(v, int64(de.AddressOfRawData), int64(de.SizeOfData)), nil
+	default:
+		return nil, ErrInvalidBinary
+	}
+}
+
 // ExtractCodeViewInfo obtains CodeView debug information from de, assuming that
 // de represents CodeView debug info.
 func (nfo *PEHeaders) ExtractCodeViewInfo(de IMAGE_DEBUG_DIRECTORY) (*IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED, error) {
@@ -688,14 +1449,9 @@ func (nfo *PEHeaders) ExtractCodeViewInfo(de IMAGE_DEBUG_DIRECTORY) (*IMAGE_DEBU
 		return nil, ErrNotCodeView
 	}
 
-	var sr *io.SectionReader
-	switch v := nfo.r.(type) {
-	case *peFile:
-		sr = io.NewSectionReader(v, int64(de.PointerToRawData), int64(de.SizeOfData))
-	case *peModule:
-		sr = io.NewSectionReader(v, int64(de.AddressOfRawData), int64(de.SizeOfData))
-	default:
-		return nil, ErrInvalidBinary
+	sr, err := nfo.debugDataReader(de)
+	if err != nil {
+		return nil, err
 	}
 
 	cv := new(IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED)
@@ -706,6 +1462,113 @@ func (nfo *PEHeaders) ExtractCodeViewInfo(de IMAGE_DEBUG_DIRECTORY) (*IMAGE_DEBU
 	return cv, nil
 }
 
+// ExtractReproHash obtains the hash that identifies a reproducible build from
+// de, assuming that de represents IMAGE_DEBUG_TYPE_REPRO debug info. The hash
+// may be empty, as older toolchains do not emit one.
+func (nfo *PEHeaders) ExtractReproHash(de IMAGE_DEBUG_DIRECTORY) ([]byte, error) {
+	if de.Type != IMAGE_DEBUG_TYPE_REPRO {
+		return nil, ErrNotRepro
+	}
+	if de.SizeOfData == 0 {
+		return []byte{}, nil
+	}
+
+	sr, err := nfo.debugDataReader(de)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashLen uint32
+	if err := binaryRead(sr, &hashLen); err != nil {
+		return nil, err
+	}
+	if uint64(hashLen) > uint64(de.SizeOfData)-uint64(unsafe.Sizeof(hashLen)) {
+		return nil, ErrBadLength
+	}
+
+	hash := make([]byte, hashLen)
+	if _, err := readFull(sr, hash); err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// POGOEntry describes one contribution to the binary's layout, as recorded by
+// the linker in IMAGE_DEBUG_TYPE_POGO debug info. Each entry typically
+// corresponds to a COFF section grouping such as ".text$mn".
+type POGOEntry struct {
+	RVA  uint32
+	Size uint32
+	Name string
+}
+
+// maxPOGONameLen bounds the length of names that we are willing to read from
+// POGO debug info, in case the binary is corrupt or malicious.
+const maxPOGONameLen = 1024
+
+// ExtractPOGO obtains the POGO (profile-guided optimization) section layout
+// from de, assuming that de represents IMAGE_DEBUG_TYPE_POGO debug info.
+func (nfo *PEHeaders) ExtractPOGO(de IMAGE_DEBUG_DIRECTORY) ([]POGOEntry, error) {
+	if de.Type != IMAGE_DEBUG_TYPE_POGO {
+		return nil, ErrNotPOGO
+	}
+
+	sr, err := nfo.debugDataReader(de)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(sr)
+
+	// The signature identifies the flavour of PGO that was used (if any), but
+	// the layout of the entries that follow it is identical in all cases.
+	var signature uint32
+	if err := binaryRead(r, &signature); err != nil {
+		return nil, err
+	}
+
+	var result []POGOEntry
+	for {
+		var hdr struct {
+			RVA  uint32
+			Size uint32
+		}
+		if err := binaryRead(r, &hdr); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		name, err := r.ReadBytes(0)
+		if err != nil {
+			if err == io.EOF {
+				err = ErrBadLength
+			}
+			return nil, err
+		}
+		if len(name) > maxPOGONameLen {
+			return nil, ErrBadLength
+		}
+
+		// Names (including their NUL terminators) are padded to 4-byte boundaries.
+		if pad := alignUp(len(name), 4) - len(name); pad > 0 {
+			if _, err := r.Discard(pad); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+
+		result = append(result, POGOEntry{
+			RVA:  hdr.RVA,
+			Size: hdr.Size,
+			Name: string(name[:len(name)-1]),
+		})
+	}
+
+	return result, nil
+}
+
 func readFull(r io.Reader, buf []byte) (n int, err error) {
 	n, err = io.ReadFull(r, buf)
 	if err == io.ErrUnexpectedEOF {
@@ -714,6 +1577,39 @@ func readFull(r io.Reader, buf []byte) (n int, err error) {
 	return n, err
 }
 
+// SignatureCoverage reports how much of nfo's file is covered by its
+// Authenticode signature. signedBytes is the number of bytes that contribute to
+// the Authenticode hash, while totalBytes is the size of the file. The hash
+// covers everything that precedes the certificate table, excluding the optional
+// header's CheckSum field and the IMAGE_DIRECTORY_ENTRY_SECURITY data directory
+// entry (12 bytes in total). Therefore, when totalBytes exceeds the sum of
+// signedBytes, those 12 bytes, and the size of the certificate table, the file
+// contains data that was not covered by the signature, such as data appended
+// after signing.
+//
+// SignatureCoverage returns ErrNotPresent if nfo does not have an embedded
+// signature, and ErrUnavailableInModule if nfo was not created from a file.
+func (nfo *PEHeaders) SignatureCoverage() (signedBytes int64, totalBytes int64, err error) {
+	if _, ok := nfo.r.(*peFile); !ok {
+		return 0, 0, ErrUnavailableInModule
+	}
+
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_SECURITY)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	totalBytes = int64(nfo.r.Limit())
+	// The VirtualAddress is a file offset.
+	certStart := int64(dde.VirtualAddress)
+	if certStart < int64(nfo.SizeOfHeaders()) || certStart+int64(dde.Size) > totalBytes {
+		return 0, 0, fmt.Errorf("%w: certificate table at 0x%X of size 0x%X lies outside of the file's contents", ErrInvalidBinary, certStart, dde.Size)
+	}
+
+	const excludedBytes = int64(unsafe.Sizeof(uint32(0)) + unsafe.Sizeof(DataDirectoryEntry{}))
+	return certStart - excludedBytes, totalBytes, nil
+}
+
 func (nfo *PEHeaders) extractAuthenticode(dde DataDirectoryEntry) (any, error) {
 	if _, ok := nfo.r.(*peFile); !ok {
 		// Authenticode; only available in file, not loaded at runtime.
@@ -16,3 +16,12 @@ func testAuthenticodeAgainstSystemAPI(t *testing.T, filename string, certs []Aut
 func testDebugInfoAgainstSystemAPI(t *testing.T, filename string, cv *IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED) {
 	t.Skipf("This test requires Windows")
 }
+
+func TestVersionInfoRequiresWindows(t *testing.T) {
+	if _, err := NewVersionInfo("foo.exe"); err != ErrRequiresWindows {
+		t.Errorf("NewVersionInfo got error %v, want %v", err, ErrRequiresWindows)
+	}
+	if _, err := NewVersionInfoFromBytes(nil); err != ErrRequiresWindows {
+		t.Errorf("NewVersionInfoFromBytes got error %v, want %v", err, ErrRequiresWindows)
+	}
+}
@@ -6,11 +6,28 @@
 package pe
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	dpe "debug/pe"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/dblohm7/wingoes"
 )
 
 // TODO(aaron): separate into cross-platform and windows-specific bits
@@ -37,16 +54,96 @@ func testFile(t *testing.T, fname string) {
 	defer pei.Close()
 
 	t.Logf("Limit: 0x%08X (%d)\n", pei.r.Limit(), pei.r.Limit())
+	t.Logf("Characteristics: %v\n", pei.Characteristics())
+	t.Logf("TimeDateStamp: %v\n", pei.TimeDateStamp())
+
+	if machine, name := pei.Machine(); machine != expectedMachineForGOARCH || name != machineNames[machine] {
+		t.Errorf("Machine got (0x%04X, %q), want (0x%04X, %q)", machine, name, expectedMachineForGOARCH, machineNames[expectedMachineForGOARCH])
+	}
+
+	// Both kernel32 and Go test binaries are console-subsystem images.
+	subsystem, name, major, minor := pei.SubsystemInfo()
+	if subsystem != dpe.IMAGE_SUBSYSTEM_WINDOWS_CUI || name != "WINDOWS_CUI" {
+		t.Errorf("SubsystemInfo got (%d, %q), want (%d, %q)", subsystem, name, dpe.IMAGE_SUBSYSTEM_WINDOWS_CUI, "WINDOWS_CUI")
+	}
+	t.Logf("Subsystem: %s %d.%d\n", name, major, minor)
+
+	linkerMajor, linkerMinor := pei.LinkerVersion()
+	t.Logf("Linker version: %d.%d\n", linkerMajor, linkerMinor)
+	// cmd/link always identifies itself as version 3.0.
+	if fname == os.Args[0] && (linkerMajor != 3 || linkerMinor != 0) {
+		t.Errorf("LinkerVersion for Go binary got %d.%d, want 3.0", linkerMajor, linkerMinor)
+	}
+
+	sizeOfHeaders := pei.SizeOfHeaders()
+	t.Logf("SizeOfHeaders: 0x%08X\n", sizeOfHeaders)
+	if fileAlignment := pei.optionalHeader.GetFileAlignment(); sizeOfHeaders%fileAlignment != 0 {
+		t.Errorf("SizeOfHeaders 0x%X is not a multiple of FileAlignment 0x%X", sizeOfHeaders, fileAlignment)
+	}
+	for _, s := range pei.sections {
+		if s.SizeOfRawData > 0 && s.PointerToRawData < sizeOfHeaders {
+			t.Errorf("section %q raw data at 0x%X overlaps headers ending at 0x%X", s.NameString(), s.PointerToRawData, sizeOfHeaders)
+		}
+	}
+
+	if !pei.IsExecutable() {
+		t.Errorf("IsExecutable returned false")
+	}
+	if wantDLL := filepath.Ext(fname) == ".dll"; pei.IsDLL() != wantDLL {
+		t.Errorf("IsDLL got %v, want %v", pei.IsDLL(), wantDLL)
+	}
 
 	dd := pei.optionalHeader.GetDataDirectory()
 	for i, e := range dd {
-		t.Logf("%02d: V: 0x%08X, FOff: 0x%08X", i, e.VirtualAddress, resolveRVA(pei, e.VirtualAddress))
+		foff, ok := resolveRVA(pei, e.VirtualAddress)
+		t.Logf("%02d: V: 0x%08X, FOff: 0x%08X (resolved: %v)", i, e.VirtualAddress, foff, ok)
+		if e.VirtualAddress == 0 && ok {
+			t.Errorf("resolveRVA(0) unexpectedly succeeded")
+		}
 	}
 
 	t.Logf("\n")
 
+	if err := pei.ValidateSections(); err != nil {
+		t.Errorf("ValidateSections: %v", err)
+	}
+
+	if got, want := len(pei.Sections()), int(pei.FileHeader().NumberOfSections); got != want {
+		t.Errorf("len(Sections()) got %d, want %d", got, want)
+	}
+
 	for i, s := range pei.sections {
 		t.Logf("%02d: %q F: 0x%08X, FS: 0x%08X, V: 0x%08X, VS: 0x%08X", i, s.NameString(), s.PointerToRawData, s.SizeOfRawData, s.VirtualAddress, s.VirtualSize)
+		if s.VirtualSize == 0 {
+			continue
+		}
+		if !pei.IsValidRVA(s.VirtualAddress) {
+			t.Errorf("IsValidRVA(0x%08X) returned false for the start of section %q", s.VirtualAddress, s.NameString())
+		}
+		if last := s.VirtualAddress + s.VirtualSize - 1; !pei.IsValidRVA(last) {
+			t.Errorf("IsValidRVA(0x%08X) returned false for the end of section %q", last, s.NameString())
+		}
+	}
+
+	var maxRVA uint32
+	for _, s := range pei.sections {
+		maxRVA = max(maxRVA, s.VirtualAddress+s.VirtualSize)
+		if s.SizeOfRawData == 0 {
+			continue
+		}
+		if foff, ok := resolveRVA(pei, s.VirtualAddress); !ok || foff != s.PointerToRawData {
+			t.Errorf("resolveRVA(0x%08X) got (0x%08X, %v), want (0x%08X, true)", s.VirtualAddress, foff, ok, s.PointerToRawData)
+		}
+	}
+	if foff, ok := resolveRVA(pei, maxRVA); ok {
+		t.Errorf("resolveRVA(0x%08X) beyond the last section unexpectedly resolved to 0x%08X", maxRVA, foff)
+	}
+
+	if pei.IsValidRVA(0) {
+		t.Errorf("IsValidRVA(0) returned true")
+	}
+	if pei.IsValidRVA(0xFFFFFFFF) {
+		t.Errorf("IsValidRVA(0xFFFFFFFF) returned true")
 	}
 
 	dbgDirAny, err := pei.DataDirectoryEntry(dpe.IMAGE_DIRECTORY_ENTRY_DEBUG)
@@ -66,17 +163,51 @@ func testFile(t *testing.T, fname string) {
 		t.Logf("Debug Info:")
 	}
 
+	entries, err := pei.DebugEntries()
+	if err != nil && err != ErrNotPresent {
+		t.Fatalf("DebugEntries error %v", err)
+	}
+	if len(entries) != len(dbgDir) {
+		t.Errorf("DebugEntries got %d entries, want %d", len(entries), len(dbgDir))
+	}
+	for i, e := range entries {
+		if i < len(dbgDir) && e.IMAGE_DEBUG_DIRECTORY != dbgDir[i] {
+			t.Errorf("DebugEntries()[%d] got %+v, want %+v", i, e.IMAGE_DEBUG_DIRECTORY, dbgDir[i])
+		}
+	}
+
 	var cv *IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED
 	for _, de := range dbgDir {
-		t.Logf("Type: %d", de.Type)
-		if de.Type == IMAGE_DEBUG_TYPE_CODEVIEW {
+		t.Logf("Type: %v, Time: %v", de.Type, de.Time())
+		switch de.Type {
+		case IMAGE_DEBUG_TYPE_CODEVIEW:
 			cv, err = pei.ExtractCodeViewInfo(de)
 			if err != nil {
 				t.Errorf("ExtractCodeViewInfo: %v", err)
 				continue
 			}
 			t.Logf("CodeView %q: %q", cv.String(), cv.PDBPath)
-			break
+		case IMAGE_DEBUG_TYPE_REPRO:
+			hash, err := pei.ExtractReproHash(de)
+			if err != nil {
+				t.Errorf("ExtractReproHash: %v", err)
+				continue
+			}
+			t.Logf("REPRO hash: %x", hash)
+		case IMAGE_DEBUG_TYPE_POGO:
+			entries, err := pei.ExtractPOGO(de)
+			if err != nil {
+				t.Errorf("ExtractPOGO: %v", err)
+				continue
+			}
+			t.Logf("POGO: %d entries", len(entries))
+			for _, e := range entries {
+				t.Logf("  %q RVA: 0x%08X, Size: 0x%08X", e.Name, e.RVA, e.Size)
+			}
+		default:
+			if _, err := pei.ExtractPOGO(de); err != ErrNotPOGO {
+				t.Errorf("ExtractPOGO on %v got error %v, want %v", de.Type, err, ErrNotPOGO)
+			}
 		}
 	}
 
@@ -94,6 +225,22 @@ func testFile(t *testing.T, fname string) {
 	t.Logf("%d certs embedded in binary", len(certs))
 	for i, cert := range certs {
 		t.Logf("%02d: Rev 0x%04X, Type %d, %d bytes", i, cert.Revision(), cert.Type(), len(cert.Data()))
+		if cert.Type() != WIN_CERT_TYPE_PKCS_SIGNED_DATA {
+			continue
+		}
+		signers, err := cert.Signers()
+		if err != nil {
+			t.Errorf("(*AuthenticodeCert).Signers error %v", err)
+			continue
+		}
+		for _, signer := range signers {
+			t.Logf("Signer %q, timestamped %v", signer.Subject(), signer.HasTimestamp)
+		}
+		pageHashes, err := cert.PageHashes()
+		if err != nil && err != ErrNotPresent {
+			t.Errorf("(*AuthenticodeCert).PageHashes error %v", err)
+		}
+		t.Logf("%d page hashes", len(pageHashes))
 	}
 
 	t.Run("SystemAuthenticode", func(t *testing.T) { testAuthenticodeAgainstSystemAPI(t, fname, certs) })
@@ -102,3 +249,412 @@ func testFile(t *testing.T, fname string) {
 		t.Run("SystemDebugInfo", func(t *testing.T) { testDebugInfoAgainstSystemAPI(t, fname, cv) })
 	}
 }
+
+func TestImageCharacteristicsString(t *testing.T) {
+	testCases := []struct {
+		c    ImageCharacteristics
+		want string
+	}{
+		{0, "0x0000"},
+		{IMAGE_FILE_DLL, "IMAGE_FILE_DLL"},
+		{IMAGE_FILE_EXECUTABLE_IMAGE | IMAGE_FILE_DLL, "IMAGE_FILE_EXECUTABLE_IMAGE|IMAGE_FILE_DLL"},
+		{IMAGE_FILE_LARGE_ADDRESS_AWARE | 0x0040, "IMAGE_FILE_LARGE_ADDRESS_AWARE|0x0040"},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.c.String(); got != tc.want {
+			t.Errorf("ImageCharacteristics(0x%04X).String() got %q, want %q", uint16(tc.c), got, tc.want)
+		}
+	}
+}
+
+func TestDebugEntriesTypeName(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	pei, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pei.Close()
+
+	entries, err := pei.DebugEntries()
+	if err != nil {
+		t.Fatalf("DebugEntries error: %v", err)
+	}
+
+	foundCodeView := false
+	for i, e := range entries {
+		if e.Type != IMAGE_DEBUG_TYPE_CODEVIEW {
+			continue
+		}
+		foundCodeView = true
+		if want := "IMAGE_DEBUG_TYPE_CODEVIEW"; e.TypeName != want {
+			t.Errorf("DebugEntries()[%d].TypeName got %q, want %q", i, e.TypeName, want)
+		}
+	}
+	if !foundCodeView {
+		t.Errorf("%q has no CodeView debug entry", fname)
+	}
+
+	// Rewrite the type of the first debug entry in a copy of the file to a value
+	// that is not defined by the PE specification.
+	dde, err := pei.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		t.Fatalf("RawDataDirectoryEntry error: %v", err)
+	}
+	offset, ok := resolveRVA(pei, dde.VirtualAddress)
+	if !ok {
+		t.Fatalf("resolveRVA(0x%08X) failed", dde.VirtualAddress)
+	}
+
+	full, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	const unknownType = IMAGE_DEBUG_TYPE(99)
+	typeOffset := int(offset) + int(unsafe.Offsetof(IMAGE_DEBUG_DIRECTORY{}.Type))
+	binary.LittleEndian.PutUint32(full[typeOffset:], uint32(unknownType))
+
+	tampered := filepath.Join(t.TempDir(), "tampered.dll")
+	if err := os.WriteFile(tampered, full, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	peiTampered, err := NewPEFromFileName(tampered)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName(tampered) error: %v", err)
+	}
+	defer peiTampered.Close()
+
+	entries, err = peiTampered.DebugEntries()
+	if err != nil {
+		t.Fatalf("DebugEntries(tampered) error: %v", err)
+	}
+	if len(entries) == 0 || entries[0].Type != unknownType {
+		t.Fatalf("DebugEntries(tampered) did not pick up the rewritten type")
+	}
+	if want := "IMAGE_DEBUG_TYPE(99)"; entries[0].TypeName != want {
+		t.Errorf("DebugEntries(tampered)[0].TypeName got %q, want %q", entries[0].TypeName, want)
+	}
+}
+
+func TestCodeViewFormats(t *testing.T) {
+	var pdb70 bytes.Buffer
+	binary.Write(&pdb70, binary.LittleEndian, CodeViewFormatPDB70)
+	pdb70.Write([]byte{0x78, 0x56, 0x34, 0x12, 0xBC, 0x9A, 0xF0, 0xDE, 0, 1, 2, 3, 4, 5, 6, 7})
+	binary.Write(&pdb70, binary.LittleEndian, uint32(2))
+	pdb70.WriteString("new.pdb\x00")
+
+	var pdb20 bytes.Buffer
+	binary.Write(&pdb20, binary.LittleEndian, CodeViewFormatPDB20)
+	binary.Write(&pdb20, binary.LittleEndian, [3]uint32{0, 0x3A2B1C0D, 0xA})
+	pdb20.WriteString("old.pdb\x00")
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		want    IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED
+		wantStr string
+	}{
+		{
+			"PDB70",
+			pdb70.Bytes(),
+			IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED{
+				Format:  CodeViewFormatPDB70,
+				GUID:    wingoes.GUID{Data1: 0x12345678, Data2: 0x9ABC, Data3: 0xDEF0, Data4: [8]byte{0, 1, 2, 3, 4, 5, 6, 7}},
+				Age:     2,
+				PDBPath: "new.pdb",
+			},
+			"123456789ABCDEF000010203040506072",
+		},
+		{
+			"PDB20",
+			pdb20.Bytes(),
+			IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED{
+				Format:    CodeViewFormatPDB20,
+				Signature: 0x3A2B1C0D,
+				Age:       0xA,
+				PDBPath:   "old.pdb",
+			},
+			"3A2B1C0DA",
+		},
+	}
+
+	for _, tc := range testCases {
+		var cv IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED
+		if err := cv.unpack(bufio.NewReader(bytes.NewReader(tc.data))); err != nil {
+			t.Errorf("%s: unpack error: %v", tc.name, err)
+			continue
+		}
+		if cv != tc.want {
+			t.Errorf("%s: unpack got %+v, want %+v", tc.name, cv, tc.want)
+		}
+		if got := cv.String(); got != tc.wantStr {
+			t.Errorf("%s: String got %q, want %q", tc.name, got, tc.wantStr)
+		}
+	}
+
+	// Other CodeView formats (such as NB09, which embeds its debug info) are
+	// unsupported and must not be misread as PDB references.
+	var cv IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED
+	if err := cv.unpack(bufio.NewReader(bytes.NewReader([]byte("NB09\x00\x00\x00\x00")))); !errors.Is(err, ErrBadCodeView) {
+		t.Errorf("unpack of NB09 record got error %v, want %v", err, ErrBadCodeView)
+	}
+}
+
+// makeTestAuthenticodeCert constructs a minimal Authenticode signature whose
+// SpcPeImageData references file, which must be a DER-encoded SpcLink.
+func makeTestAuthenticodeCert(t *testing.T, file []byte) AuthenticodeCert {
+	t.Helper()
+
+	marshal := func(v any, params string) []byte {
+		b, err := asn1.MarshalWithParams(v, params)
+		if err != nil {
+			t.Fatalf("asn1.MarshalWithParams error: %v", err)
+		}
+		return b
+	}
+	explicit0 := func(b []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: b}
+	}
+
+	oidSHA256 := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	peImageData := marshal(spcPEImageData{File: explicit0(file)}, "")
+	indirect := marshal(spcIndirectDataContent{
+		Data: spcAttributeTypeAndOptionalValue{
+			Type:  oidSPCPEImageData,
+			Value: asn1.RawValue{FullBytes: peImageData},
+		},
+		MessageDigest: spcDigestInfo{
+			DigestAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			Digest:          make([]byte, sha256.Size),
+		},
+	}, "")
+	return makeTestSignedDataCert(t, pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidSPCIndirectData, Content: explicit0(indirect)},
+	})
+}
+
+// makeTestSignedDataCert wraps sd in a PKCS#7 ContentInfo and returns it as
+// the data of an AuthenticodeCert.
+func makeTestSignedDataCert(t *testing.T, sd pkcs7SignedData) AuthenticodeCert {
+	t.Helper()
+
+	sdBytes, err := asn1.Marshal(sd)
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+	ci, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBytes},
+	})
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+
+	return AuthenticodeCert{
+		header: _WIN_CERTIFICATE_HEADER{Revision: WIN_CERT_REVISION_2_0, CertificateType: WIN_CERT_TYPE_PKCS_SIGNED_DATA},
+		data:   ci,
+	}
+}
+
+func TestSigners(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey error: %v", err)
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Wingoes Test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate(CA) error: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(CA) error: %v", err)
+	}
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Wingoes Test Signer"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate(leaf) error: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(leaf) error: %v", err)
+	}
+
+	rawCerts, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSet,
+		IsCompound: true,
+		Bytes:      append(bytes.Clone(leafDER), caDER...),
+	})
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+	timestamp, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSet,
+		IsCompound: true,
+		Bytes:      asn1.NullBytes,
+	})
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+
+	oidSHA256 := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA256 := asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	ac := makeTestSignedDataCert(t, pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidSPCIndirectData},
+		Certificates:     pkcs7RawCertificates{Raw: rawCerts},
+		SignerInfos: []pkcs7SignerInfo{
+			{
+				Version: 1,
+				IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+					IssuerName:   asn1.RawValue{FullBytes: leaf.RawIssuer},
+					SerialNumber: leaf.SerialNumber,
+				},
+				DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+				DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+				EncryptedDigest:           []byte{0x01},
+				UnauthenticatedAttributes: []pkcs7Attribute{
+					{Type: oidMSRFC3161Timestamp, Value: asn1.RawValue{FullBytes: timestamp}},
+				},
+			},
+			{
+				// This signer's certificate is not embedded in the signature.
+				Version: 1,
+				IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+					IssuerName:   asn1.RawValue{FullBytes: leaf.RawIssuer},
+					SerialNumber: big.NewInt(3),
+				},
+				DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+				DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+				EncryptedDigest:           []byte{0x02},
+			},
+		},
+	})
+
+	certs, err := ac.Certificates()
+	if err != nil {
+		t.Fatalf("Certificates error: %v", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(leaf) || !certs[1].Equal(ca) {
+		t.Errorf("Certificates did not return the embedded leaf and CA certificates")
+	}
+
+	signers, err := ac.Signers()
+	if err != nil {
+		t.Fatalf("Signers error: %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("Signers got %d signers, want 2", len(signers))
+	}
+
+	// DER sorts the elements of a SET OF, so the order of signers does not
+	// necessarily match the order in which we specified them.
+	signed, unsigned := signers[0], signers[1]
+	if signed.Certificate == nil {
+		signed, unsigned = unsigned, signed
+	}
+
+	if got, want := signed.Subject(), "CN=Wingoes Test Signer"; got != want {
+		t.Errorf("Subject() got %q, want %q", got, want)
+	}
+	if got, want := signed.Issuer(), "CN=Wingoes Test CA"; got != want {
+		t.Errorf("Issuer() got %q, want %q", got, want)
+	}
+	if !signed.HasTimestamp {
+		t.Errorf("HasTimestamp on timestamped signer got false, want true")
+	}
+
+	if unsigned.Certificate != nil || unsigned.Subject() != "" || unsigned.Issuer() != "" {
+		t.Errorf("signer without embedded certificate got subject %q, want none", unsigned.Subject())
+	}
+	if unsigned.HasTimestamp {
+		t.Errorf("HasTimestamp on signer without timestamp got true, want false")
+	}
+
+	ac = AuthenticodeCert{header: _WIN_CERTIFICATE_HEADER{CertificateType: WIN_CERT_TYPE_X509}}
+	if _, err := ac.Signers(); err != ErrNotPKCS7 {
+		t.Errorf("Signers on X.509 cert got error %v, want %v", err, ErrNotPKCS7)
+	}
+}
+
+func TestPageHashes(t *testing.T) {
+	want := []PageHash{
+		{Offset: 0, Digest: bytes.Repeat([]byte{0x11}, sha256.Size)},
+		{Offset: 0x1000, Digest: bytes.Repeat([]byte{0x22}, sha256.Size)},
+		{Offset: 0x1600, Digest: make([]byte, sha256.Size)},
+	}
+
+	var blob []byte
+	for _, ph := range want {
+		blob = binary.LittleEndian.AppendUint32(blob, ph.Offset)
+		blob = append(blob, ph.Digest...)
+	}
+
+	octets, err := asn1.Marshal(blob)
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+	serialized, err := asn1.MarshalWithParams([]spcPageHashes{
+		{
+			Type:   oidSPCPageHashesV2,
+			Hashes: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: octets},
+		},
+	}, "set")
+	if err != nil {
+		t.Fatalf("asn1.MarshalWithParams error: %v", err)
+	}
+	moniker, err := asn1.MarshalWithParams(spcSerializedObject{
+		ClassID:        spcSerializedObjectClassID,
+		SerializedData: serialized,
+	}, "tag:1")
+	if err != nil {
+		t.Fatalf("asn1.MarshalWithParams error: %v", err)
+	}
+
+	ac := makeTestAuthenticodeCert(t, moniker)
+	got, err := ac.PageHashes()
+	if err != nil {
+		t.Fatalf("PageHashes error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PageHashes got %v, want %v", got, want)
+	}
+
+	// A signature whose SpcLink is an (empty) file name contains no page hashes.
+	file, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true})
+	if err != nil {
+		t.Fatalf("asn1.Marshal error: %v", err)
+	}
+	ac = makeTestAuthenticodeCert(t, file)
+	if _, err := ac.PageHashes(); err != ErrNotPresent {
+		t.Errorf("PageHashes without page hashes got error %v, want %v", err, ErrNotPresent)
+	}
+
+	ac = AuthenticodeCert{header: _WIN_CERTIFICATE_HEADER{CertificateType: WIN_CERT_TYPE_X509}}
+	if _, err := ac.PageHashes(); err != ErrNotPKCS7 {
+		t.Errorf("PageHashes on X.509 cert got error %v, want %v", err, ErrNotPKCS7)
+	}
+}
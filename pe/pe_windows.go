@@ -85,9 +85,15 @@ func NewPEFromHMODULE(hmodule windows.Handle) (*PEHeaders, error) {
 }
 
 // NewPEFromDLL parses the headers in a PE binary identified by dll that
-// is currently loaded into the current process's address space.
+// is currently loaded into the current process's address space. It is a
+// convenience wrapper around NewPEFromHMODULE for callers that already hold a
+// *windows.DLL, such as one obtained from windows.LoadDLL.
 // Upon success it returns a non-nil *PEHeaders, otherwise it returns a nil
-// *PEHeaders and a non-nil error.
+// *PEHeaders and a non-nil error. In particular, it returns os.ErrInvalid when
+// dll is nil or has not been loaded.
+// The returned *PEHeaders holds its own reference to the module, so it remains
+// valid even if dll is subsequently released. Call Close() on the returned
+// *PEHeaders when it is no longer needed.
 func NewPEFromDLL(dll *windows.DLL) (*PEHeaders, error) {
 	if dll == nil || dll.Handle == 0 {
 		return nil, os.ErrInvalid
@@ -139,6 +145,77 @@ func NewPEFromFileHandle(hfile windows.Handle) (*PEHeaders, error) {
 	return newPEFromFile(os.NewFile(uintptr(hfileDup), "PEFromFileHandle"))
 }
 
+// mappedFile is a fileReader over a read-only view of a file that has been
+// mapped into memory as data (as opposed to being mapped as an image).
+type mappedFile struct {
+	*bytes.Reader
+	view uintptr
+}
+
+func (mf *mappedFile) Close() error {
+	return windows.UnmapViewOfFile(mf.view)
+}
+
+// NewPEFromFileNameMapped opens a PE binary located at filename and parses its
+// PE headers. Unlike NewPEFromFileName, it maps the entire file into memory
+// and subsequently reads from that mapping, which avoids issuing a system call
+// for every field read from the file. This is advantageous when parsing large
+// numbers of files. The file is mapped as data, so its contents are laid out
+// exactly as they are on disk.
+// Upon success it returns a non-nil *PEHeaders, otherwise it returns a nil
+// *PEHeaders and a non-nil error.
+// Call Close() on the returned *PEHeaders when it is no longer needed; doing so
+// unmaps the file.
+func NewPEFromFileNameMapped(filename string) (*PEHeaders, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 || uint64(size) > uint64(^uintptr(0)) {
+		// Empty files cannot be mapped, and files that do not fit in our address
+		// space are not valid PE binaries anyway.
+		return nil, ErrInvalidBinary
+	}
+
+	mapping, err := windows.CreateFileMapping(windows.Handle(f.Fd()), nil, windows.PAGE_READONLY, 0, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating file mapping: %w", err)
+	}
+	// The view holds its own reference to the mapping.
+	defer windows.CloseHandle(mapping)
+
+	view, err := windows.MapViewOfFile(mapping, windows.FILE_MAP_READ, 0, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("mapping view of file: %w", err)
+	}
+
+	slc := unsafe.Slice((*byte)(unsafe.Pointer(view)), uintptr(size))
+	pef := &peFile{
+		fileReader: &mappedFile{
+			Reader: bytes.NewReader(slc),
+			view:   view,
+		},
+		peBounds: peBounds{
+			limit: uintptr(size),
+		},
+	}
+
+	peh, err := loadHeaders(pef)
+	if err != nil {
+		pef.Close()
+		return nil, err
+	}
+
+	return peh, nil
+}
+
 func checkMachine(r peReader, machine uint16) bool {
 	// In-memory modules should always have a machine type that matches our own.
 	// (okay, so that's kinda sorta untrue with respect to WOW64, but that's
@@ -7,11 +7,22 @@ package pe
 
 import (
 	"bytes"
+	dpe "debug/pe"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"testing"
 	"unsafe"
 
+	"github.com/dblohm7/wingoes"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sys/windows"
 )
 
@@ -70,10 +81,16 @@ func getCodeViewInfoViaSystem(filename string) (result *IMAGE_DEBUG_INFO_CODEVIE
 	}
 
 	result = &IMAGE_DEBUG_INFO_CODEVIEW_UNPACKED{
+		Format:  CodeViewFormatPDB70,
 		GUID:    info.GUID,
 		Age:     info.Age,
 		PDBPath: windows.UTF16ToString(info.PDBFile[:]),
 	}
+	// dbghelp leaves the GUID zeroed when the binary references a PDB 2.0 file.
+	if result.GUID == (wingoes.GUID{}) {
+		result.Format = CodeViewFormatPDB20
+		result.Signature = info.Sig
+	}
 	return result, nil
 }
 
@@ -144,10 +161,64 @@ func testFileVsModule(t *testing.T, fname string) {
 	}
 	defer pem.Close()
 
+	if pef.IsMappedAsImage() {
+		t.Errorf("IsMappedAsImage got true for file, want false")
+	}
+	if !pem.IsMappedAsImage() {
+		t.Errorf("IsMappedAsImage got false for module, want true")
+	}
+
 	if !reflect.DeepEqual(pef.fileHeader, pem.fileHeader) {
 		t.Errorf("DeepEqual failed on fileHeader")
 	}
 
+	// Code sections are not modified by the loader unless relocations apply to
+	// them, so we only compare the initial bytes of the first code section.
+	for i, sec := range pef.Sections() {
+		if sec.Characteristics&dpe.IMAGE_SCN_CNT_CODE == 0 {
+			continue
+		}
+
+		srf, err := pef.SectionReader(i)
+		if err != nil {
+			t.Fatalf("SectionReader(%d) from file: %v", i, err)
+		}
+		srm, err := pem.SectionReader(i)
+		if err != nil {
+			t.Fatalf("SectionReader(%d) from module: %v", i, err)
+		}
+
+		bf := make([]byte, 16)
+		bm := make([]byte, 16)
+		if _, err := io.ReadFull(srf, bf); err != nil {
+			t.Fatalf("reading section %d from file: %v", i, err)
+		}
+		if _, err := io.ReadFull(srm, bm); err != nil {
+			t.Fatalf("reading section %d from module: %v", i, err)
+		}
+		// On 386, code contains absolute addresses that are subject to relocation.
+		if runtime.GOARCH != "386" && !bytes.Equal(bf, bm) {
+			t.Errorf("section %d contents differ between file and module", i)
+		}
+		break
+	}
+
+	if _, err := pef.SectionReader(len(pef.Sections())); err != ErrIndexOutOfRange {
+		t.Errorf("SectionReader out of range got error %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	stubf, err := pef.DOSStub()
+	if err != nil {
+		t.Errorf("DOSStub from file: %v", err)
+	}
+	stubm, err := pem.DOSStub()
+	if err != nil {
+		t.Errorf("DOSStub from module: %v", err)
+	}
+	if !bytes.Equal(stubf, stubm) {
+		t.Errorf("bytes.Equal failed on DOS stub")
+	}
+
 	// The optional header's DataDirectory will be modified by loader relocations,
 	// so we need to exclude that from the comparison.
 	pefOH := pef.optionalHeader.(*optionalHeaderForGOARCH)
@@ -163,6 +234,10 @@ func testFileVsModule(t *testing.T, fname string) {
 		t.Errorf("bytes.Equal failed on optionalHeader:\n\n%#v\n\nvs\n\n%#v\n\n", pefOHBytes, pemOHBytes)
 	}
 
+	if ohBytes := pef.OptionalHeaderBytes(); !bytes.Equal(ohBytes, pefOHBytes) {
+		t.Errorf("OptionalHeaderBytes mismatch:\n\n%#v\n\nvs\n\n%#v\n\n", ohBytes, pefOHBytes)
+	}
+
 	// TODO(aaron): flesh out this test as (*PEInfo).DataDirectoryEntry is fleshed out
 	// Compare some DataDirectory stuff between file and module. Note that
 	// IMAGE_DIRECTORY_ENTRY_SECURITY is unavailable in modules.
@@ -246,6 +321,77 @@ func testVersionInfo(t *testing.T, fname string) {
 	} else {
 		t.Logf("CompanyName: %q", companyName)
 	}
+
+	accessors := []struct {
+		key string
+		fn  func() (string, error)
+	}{
+		{"CompanyName", vi.CompanyName},
+		{"ProductName", vi.ProductName},
+		{"FileDescription", vi.FileDescription},
+		{"OriginalFilename", vi.OriginalFilename},
+		{"InternalName", vi.InternalName},
+		{"LegalCopyright", vi.LegalCopyright},
+		{"FileVersion", vi.FileVersionString},
+		{"ProductVersion", vi.ProductVersionString},
+	}
+	for _, a := range accessors {
+		got, gotErr := a.fn()
+		want, wantErr := vi.Field(a.key)
+		if got != want || gotErr != wantErr {
+			t.Errorf("accessor for %s got (%q, %v), want (%q, %v)", a.key, got, gotErr, want, wantErr)
+			continue
+		}
+		if gotErr == nil {
+			t.Logf("%s: %q", a.key, got)
+		}
+	}
+}
+
+func TestNewVersionInfoFromBytes(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	viFile, err := NewVersionInfo(fname)
+	if err != nil {
+		t.Fatalf("NewVersionInfo failed: %v", err)
+	}
+
+	viBytes, err := NewVersionInfoFromBytes(viFile.buf)
+	if err != nil {
+		t.Fatalf("NewVersionInfoFromBytes failed: %v", err)
+	}
+
+	if got, want := viBytes.VersionNumber(), viFile.VersionNumber(); got != want {
+		t.Errorf("VersionNumber got %v, want %v", got, want)
+	}
+
+	got, gotErr := viBytes.CompanyName()
+	want, wantErr := viFile.CompanyName()
+	if got != want || gotErr != wantErr {
+		t.Errorf("CompanyName got (%q, %v), want (%q, %v)", got, gotErr, want, wantErr)
+	}
+
+	// buf need not include the slack that GetFileVersionInfo reserves.
+	exact := viFile.buf[:binary.LittleEndian.Uint16(viFile.buf)]
+	if viExact, err := NewVersionInfoFromBytes(exact); err != nil {
+		t.Errorf("NewVersionInfoFromBytes on exact-length buffer failed: %v", err)
+	} else if got, want := viExact.VersionNumber(), viFile.VersionNumber(); got != want {
+		t.Errorf("VersionNumber on exact-length buffer got %v, want %v", got, want)
+	}
+
+	// A 16-bit VERSIONINFO has the same header layout but an ANSI key.
+	ansi := append([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, "VS_VERSION_INFO\x00"...)
+	ansi = append(ansi, make([]byte, 64)...)
+	binary.LittleEndian.PutUint16(ansi, uint16(len(ansi)))
+	if _, err := NewVersionInfoFromBytes(ansi); err != errVersionInfoNotUnicode {
+		t.Errorf("NewVersionInfoFromBytes on 16-bit resource got error %v, want %v", err, errVersionInfoNotUnicode)
+	}
+
+	truncated := exact[:len(exact)-1]
+	for _, buf := range [][]byte{nil, {0x04, 0x00, 0x00, 0x00, 0x00, 0x00}, truncated} {
+		if _, err := NewVersionInfoFromBytes(buf); err != ErrBadLength {
+			t.Errorf("NewVersionInfoFromBytes on %d-byte buffer got error %v, want %v", len(buf), err, ErrBadLength)
+		}
+	}
 }
 
 func TestModuleVsSystem(t *testing.T) {
@@ -267,6 +413,9 @@ func TestModuleVsSystem(t *testing.T) {
 
 	var ddeZero DataDirectoryEntry
 	dd := pem.optionalHeader.GetDataDirectory()
+	if n := pem.NumDataDirectories(); n != len(dd) {
+		t.Errorf("NumDataDirectories got %d, want %d", n, len(dd))
+	}
 	for i, dde := range dd {
 		ddeSys, err := getDataDirectoryEntryViaSystem(uintptr(k32.Handle), DataDirectoryIndex(i))
 		if err != nil {
@@ -280,6 +429,327 @@ func TestModuleVsSystem(t *testing.T) {
 		if !reflect.DeepEqual(dde, *ddeSys) {
 			t.Errorf("DeepEqual failed on DataDirectory[%d]", i)
 		}
+
+		ddeRaw, err := pem.RawDataDirectoryEntry(DataDirectoryIndex(i))
+		if err != nil {
+			t.Errorf("RawDataDirectoryEntry(%d) error: %v", i, err)
+		} else if ddeRaw != dde {
+			t.Errorf("RawDataDirectoryEntry(%d) got %#v, want %#v", i, ddeRaw, dde)
+		}
+	}
+}
+
+func TestNewPEFromDLLInvalid(t *testing.T) {
+	for _, dll := range []*windows.DLL{nil, {Name: "unloaded.dll"}} {
+		if _, err := NewPEFromDLL(dll); err != os.ErrInvalid {
+			t.Errorf("NewPEFromDLL(%v) got error %v, want %v", dll, err, os.ErrInvalid)
+		}
+	}
+}
+
+func TestExports(t *testing.T) {
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	for _, name := range []string{"GetProcAddress", "LoadLibraryW", "HeapAlloc"} {
+		ordinal, rva, err := pem.ExportByName(name)
+		if err != nil {
+			t.Errorf("ExportByName(%q) from module error: %v", name, err)
+			continue
+		}
+
+		fordinal, frva, err := pef.ExportByName(name)
+		if err != nil {
+			t.Errorf("ExportByName(%q) from file error: %v", name, err)
+			continue
+		}
+		if ordinal != fordinal || rva != frva {
+			t.Errorf("ExportByName(%q) mismatch: module (%d, 0x%08X), file (%d, 0x%08X)", name, ordinal, rva, fordinal, frva)
+		}
+
+		oname, orva, err := pem.ExportByOrdinal(ordinal)
+		if err != nil {
+			t.Errorf("ExportByOrdinal(%d) error: %v", ordinal, err)
+			continue
+		}
+		if oname != name || orva != rva {
+			t.Errorf("ExportByOrdinal(%d) got (%q, 0x%08X), want (%q, 0x%08X)", ordinal, oname, orva, name, rva)
+		}
+	}
+
+	// kernel32!HeapAlloc has been forwarded to ntdll since Windows 7.
+	targetDLL, targetSymbol, err := pem.ResolveForwarder("HeapAlloc")
+	if err != nil {
+		t.Errorf("ResolveForwarder error: %v", err)
+	} else if !strings.EqualFold(targetDLL, "ntdll") || targetSymbol != "RtlAllocateHeap" {
+		t.Errorf("ResolveForwarder got (%q, %q), want (%q, %q)", targetDLL, targetSymbol, "NTDLL", "RtlAllocateHeap")
+	}
+
+	if _, _, err := pem.ExportByName("ThisFunctionDoesNotExist"); err != ErrNotPresent {
+		t.Errorf("ExportByName for missing name got error %v, want %v", err, ErrNotPresent)
+	}
+	if _, _, err := pem.ExportByOrdinal(0xFFFF); err != ErrNotPresent {
+		t.Errorf("ExportByOrdinal for missing ordinal got error %v, want %v", err, ErrNotPresent)
+	}
+}
+
+func TestIATThunks(t *testing.T) {
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	iatAny, err := pem.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT)
+	if err != nil {
+		t.Fatalf("DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT) error: %v", err)
+	}
+
+	iat, ok := iatAny.(*IATInfo)
+	if !ok {
+		t.Fatalf("did not get *IATInfo")
+	}
+
+	if iat.ThunkSize() != uint32(unsafe.Sizeof(uintptr(0))) {
+		t.Errorf("unexpected thunk size %d", iat.ThunkSize())
+	}
+
+	thunks, err := iat.Thunks()
+	if err != nil {
+		t.Fatalf("Thunks error: %v", err)
+	}
+	if got, want := uint32(len(thunks)), iat.Size/iat.ThunkSize(); got != want {
+		t.Errorf("unexpected thunk count, got %d, want %d", got, want)
+	}
+
+	// The loader resolves each slot in a loaded module's IAT to the address of
+	// the imported function, so the IAT must contain the address of a function
+	// that kernel32 imports from ntdll.
+	syms, err := pem.ImportsFrom("ntdll.dll")
+	if err != nil {
+		t.Fatalf("ImportsFrom error: %v", err)
+	}
+	i := slices.IndexFunc(syms, func(s ImportedSymbol) bool { return !s.ByOrdinal && !s.Delayed })
+	if i < 0 {
+		t.Fatalf("kernel32 does not import any functions from ntdll by name")
+	}
+
+	ntdll := windows.MustLoadDLL("ntdll.dll")
+	proc, err := ntdll.FindProc(syms[i].Name)
+	if err != nil {
+		t.Fatalf("FindProc(%q) error: %v", syms[i].Name, err)
+	}
+	if !slices.Contains(thunks, uint64(proc.Addr())) {
+		t.Errorf("IAT does not contain the address of ntdll!%s (0x%X)", syms[i].Name, proc.Addr())
+	}
+}
+
+func TestImportsFrom(t *testing.T) {
+	// Go binaries import from kernel32 by name.
+	peg, err := NewPEFromFileName(os.Args[0])
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer peg.Close()
+
+	syms, err := peg.ImportsFrom("KERNEL32.DLL")
+	if err != nil {
+		t.Fatalf("ImportsFrom error: %v", err)
+	}
+	if !slices.ContainsFunc(syms, func(s ImportedSymbol) bool { return s.Name == "GetProcAddress" }) {
+		t.Errorf("ImportsFrom did not include GetProcAddress")
+	}
+
+	if _, err := peg.ImportsFrom("ThisDLLDoesNotExist.dll"); err != ErrNotPresent {
+		t.Errorf("ImportsFrom for missing DLL got error %v, want %v", err, ErrNotPresent)
+	}
+
+	// The import name table is not modified by the loader, so a module must
+	// produce the same results as its file.
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	msyms, err := pem.ImportsFrom("ntdll.dll")
+	if err != nil {
+		t.Fatalf("ImportsFrom from module error: %v", err)
+	}
+	fsyms, err := pef.ImportsFrom("ntdll.dll")
+	if err != nil {
+		t.Fatalf("ImportsFrom from file error: %v", err)
+	}
+	if !reflect.DeepEqual(msyms, fsyms) {
+		t.Errorf("ImportsFrom mismatch between module and file")
+	}
+}
+
+func TestResource(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	mbuf, err := pem.Resource(RT_VERSION, IntResourceID(1), 0)
+	if err != nil {
+		t.Fatalf("Resource from module error: %v", err)
+	}
+	fbuf, err := pef.Resource(RT_VERSION, IntResourceID(1), 0)
+	if err != nil {
+		t.Fatalf("Resource from file error: %v", err)
+	}
+	if !bytes.Equal(mbuf, fbuf) {
+		t.Errorf("Resource mismatch between module and file")
+	}
+
+	// The raw resource must parse identically to the system's copy.
+	viRes, err := NewVersionInfoFromBytes(fbuf)
+	if err != nil {
+		t.Fatalf("NewVersionInfoFromBytes error: %v", err)
+	}
+	viFile, err := NewVersionInfo(fname)
+	if err != nil {
+		t.Fatalf("NewVersionInfo error: %v", err)
+	}
+	if got, want := viRes.VersionNumber(), viFile.VersionNumber(); got != want {
+		t.Errorf("VersionNumber got %v, want %v", got, want)
+	}
+
+	for _, tc := range []struct {
+		resType ResourceType
+		id      ResourceID
+		lang    uint16
+	}{
+		{RT_VERSION, IntResourceID(0xFFFF), 0},
+		{RT_VERSION, NamedResourceID("NoSuchResource"), 0},
+		{RT_VERSION, IntResourceID(1), 0x7FFF},
+		{RT_VXD, IntResourceID(1), 0},
+	} {
+		if _, err := pef.Resource(tc.resType, tc.id, tc.lang); err != ErrNotPresent {
+			t.Errorf("Resource(%d, %v, 0x%04X) got error %v, want %v", tc.resType, tc.id, tc.lang, err, ErrNotPresent)
+		}
+	}
+}
+
+func TestResourceID(t *testing.T) {
+	for _, tc := range []struct {
+		id      ResourceID
+		isNamed bool
+		str     string
+	}{
+		{IntResourceID(1), false, "#1"},
+		{NamedResourceID("MUI"), true, "MUI"},
+		{NamedResourceID(""), false, "#0"},
+	} {
+		if got := tc.id.IsNamed(); got != tc.isNamed {
+			t.Errorf("%v.IsNamed() got %v, want %v", tc.id, got, tc.isNamed)
+		}
+		if got := tc.id.String(); got != tc.str {
+			t.Errorf("String() got %q, want %q", got, tc.str)
+		}
+	}
+
+	if got, want := NamedResourceID(""), IntResourceID(0); got != want {
+		t.Errorf("NamedResourceID(\"\") got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseStringTableBlock(t *testing.T) {
+	// Block 2 covers string IDs 16 through 31. Populate the first and last
+	// entries, leaving the rest empty.
+	var block []byte
+	appendString := func(s string) {
+		u16 := windows.StringToUTF16(s)
+		u16 = u16[:len(u16)-1]
+		block = binary.LittleEndian.AppendUint16(block, uint16(len(u16)))
+		for _, c := range u16 {
+			block = binary.LittleEndian.AppendUint16(block, c)
+		}
+	}
+
+	appendString("first")
+	for i := 0; i < 14; i++ {
+		appendString("")
+	}
+	appendString("last")
+
+	got := make(map[uint16]string)
+	if err := parseStringTableBlock(got, 2, block); err != nil {
+		t.Fatalf("parseStringTableBlock error: %v", err)
+	}
+	if want := map[uint16]string{16: "first", 31: "last"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStringTableBlock got %v, want %v", got, want)
+	}
+
+	if err := parseStringTableBlock(got, 2, block[:len(block)-1]); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("parseStringTableBlock on truncated block got error %v, want %v", err, ErrInvalidBinary)
+	}
+	for _, blockID := range []uint32{0, maxStringTableBlockID + 1, 0x10000} {
+		if err := parseStringTableBlock(got, blockID, block); !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("parseStringTableBlock with block ID %d got error %v, want %v", blockID, err, ErrInvalidBinary)
+		}
+	}
+
+	// The final block covers string IDs 65520 through 65535.
+	got = make(map[uint16]string)
+	if err := parseStringTableBlock(got, maxStringTableBlockID, block); err != nil {
+		t.Fatalf("parseStringTableBlock with final block ID error: %v", err)
+	}
+	if want := map[uint16]string{65520: "first", 65535: "last"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStringTableBlock with final block ID got %v, want %v", got, want)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	// Localized strings live in resource-only MUI files.
+	const fname = `C:\Windows\System32\en-US\shell32.dll.mui`
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			t.Skipf("%q not found", fname)
+		}
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	strs, err := pef.Strings(0)
+	if err != nil {
+		t.Fatalf("Strings error: %v", err)
+	}
+	if len(strs) == 0 {
+		t.Errorf("Strings returned no strings")
+	}
+
+	if _, err := pef.Strings(0x7FFF); err != ErrNotPresent {
+		t.Errorf("Strings for missing language got error %v, want %v", err, ErrNotPresent)
 	}
 }
 
@@ -304,3 +774,915 @@ func getDataDirectoryEntryViaSystem(hmodule uintptr, ddIndex DataDirectoryIndex)
 		Size:           size,
 	}, nil
 }
+
+func TestTruncatedHeaders(t *testing.T) {
+	full, err := os.ReadFile(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	e_lfanew := int(binary.LittleEndian.Uint32(full[offsetIMAGE_DOS_HEADERe_lfanew:]))
+	optionalHeaderOffset := e_lfanew + 4 + int(unsafe.Sizeof(FileHeader{}))
+	sizeOfOptionalHeader := int(binary.LittleEndian.Uint16(full[optionalHeaderOffset-4:]))
+	sectionTableOffset := optionalHeaderOffset + sizeOfOptionalHeader
+
+	testCases := []struct {
+		name string
+		size int
+	}{
+		{"Signature", e_lfanew + 2},
+		{"FileHeader", e_lfanew + 4 + 10},
+		{"OptionalHeader", optionalHeaderOffset + 16},
+		{"SectionTable", sectionTableOffset + int(unsafe.Sizeof(SectionHeader{})) + 10},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range testCases {
+		fname := filepath.Join(dir, tc.name+".dll")
+		if err := os.WriteFile(fname, full[:tc.size], 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		peh, err := NewPEFromFileName(fname)
+		if err == nil {
+			peh.Close()
+		}
+		if !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("%s: NewPEFromFileName got error %v, want %v", tc.name, err, ErrInvalidBinary)
+		}
+	}
+}
+
+func TestInconsistentMagicAndMachine(t *testing.T) {
+	full, err := os.ReadFile(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	e_lfanew := int(binary.LittleEndian.Uint32(full[offsetIMAGE_DOS_HEADERe_lfanew:]))
+	machineOffset := e_lfanew + 4
+	optionalHeaderOffset := machineOffset + int(unsafe.Sizeof(FileHeader{}))
+
+	testCases := []struct {
+		name    string
+		machine uint16
+		magic   uint16
+	}{
+		{"PE32_ARM64", dpe.IMAGE_FILE_MACHINE_ARM64, 0x010B},
+		{"PE32_AMD64", dpe.IMAGE_FILE_MACHINE_AMD64, 0x010B},
+		{"PE32Plus_I386", dpe.IMAGE_FILE_MACHINE_I386, 0x020B},
+		{"ROM_AMD64", dpe.IMAGE_FILE_MACHINE_AMD64, 0x0107},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range testCases {
+		tampered := bytes.Clone(full)
+		binary.LittleEndian.PutUint16(tampered[machineOffset:], tc.machine)
+		binary.LittleEndian.PutUint16(tampered[optionalHeaderOffset:], tc.magic)
+
+		fname := filepath.Join(dir, tc.name+".dll")
+		if err := os.WriteFile(fname, tampered, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		peh, err := NewPEFromFileName(fname)
+		if err == nil {
+			peh.Close()
+		}
+		if !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("%s: NewPEFromFileName got error %v, want %v", tc.name, err, ErrInvalidBinary)
+		}
+	}
+}
+
+func TestValidateSectionsTampered(t *testing.T) {
+	full, err := os.ReadFile(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	e_lfanew := int(binary.LittleEndian.Uint32(full[offsetIMAGE_DOS_HEADERe_lfanew:]))
+	fileHeaderOffset := e_lfanew + 4
+	var fh FileHeader
+	if err := binary.Read(bytes.NewReader(full[fileHeaderOffset:]), binary.LittleEndian, &fh); err != nil {
+		t.Fatalf("reading FileHeader: %v", err)
+	}
+	if fh.NumberOfSections < 2 {
+		t.Fatalf("test binary has fewer than two sections")
+	}
+	sectionTableOffset := fileHeaderOffset + int(unsafe.Sizeof(fh)) + int(fh.SizeOfOptionalHeader)
+	sectionOffset := func(i int) int {
+		return sectionTableOffset + i*int(unsafe.Sizeof(SectionHeader{}))
+	}
+	const (
+		virtualSizeOffset      = 8
+		pointerToRawDataOffset = 20
+	)
+
+	testCases := []struct {
+		name   string
+		tamper func(b []byte)
+	}{
+		{
+			"VirtualRangeExceedsImage",
+			func(b []byte) {
+				binary.LittleEndian.PutUint32(b[sectionOffset(0)+virtualSizeOffset:], 0x7FFFFFFF)
+			},
+		},
+		{
+			"OverlappingRawData",
+			func(b []byte) {
+				ptr := binary.LittleEndian.Uint32(b[sectionOffset(0)+pointerToRawDataOffset:])
+				binary.LittleEndian.PutUint32(b[sectionOffset(1)+pointerToRawDataOffset:], ptr)
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range testCases {
+		tampered := bytes.Clone(full)
+		tc.tamper(tampered)
+
+		fname := filepath.Join(dir, tc.name+".dll")
+		if err := os.WriteFile(fname, tampered, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		peh, err := NewPEFromFileName(fname)
+		if err != nil {
+			t.Errorf("%s: NewPEFromFileName error: %v", tc.name, err)
+			continue
+		}
+		err = peh.ValidateSections()
+		peh.Close()
+		if !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("%s: ValidateSections got error %v, want %v", tc.name, err, ErrInvalidBinary)
+		}
+	}
+}
+
+func TestReadStructArrayCopy(t *testing.T) {
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	dde, err := pem.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		t.Fatalf("RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG) error: %v", err)
+	}
+
+	count := int(dde.Size / uint32(unsafe.Sizeof(IMAGE_DEBUG_DIRECTORY{})))
+	inPlace, err := readStructArray[IMAGE_DEBUG_DIRECTORY](pem.r, dde.VirtualAddress, count)
+	if err != nil {
+		t.Fatalf("readStructArray error: %v", err)
+	}
+	copied, err := readStructArrayCopy[IMAGE_DEBUG_DIRECTORY](pem.r, dde.VirtualAddress, count)
+	if err != nil {
+		t.Fatalf("readStructArrayCopy error: %v", err)
+	}
+
+	if !reflect.DeepEqual(inPlace, copied) {
+		t.Errorf("readStructArrayCopy result does not match readStructArray")
+	}
+
+	if addr := uintptr(unsafe.Pointer(unsafe.SliceData(copied))); addr >= pem.r.Base() && addr < pem.r.Limit() {
+		t.Errorf("readStructArrayCopy result references module memory at 0x%X", addr)
+	}
+}
+
+// managedTestAssembly is a .NET assembly that ships with every supported
+// version of Windows.
+const managedTestAssembly = `C:\Windows\Microsoft.NET\Framework\v4.0.30319\System.dll`
+
+func openManagedTestAssembly(t *testing.T) *PEHeaders {
+	t.Helper()
+	if _, err := os.Stat(managedTestAssembly); err != nil {
+		t.Skipf("managed test assembly unavailable: %v", err)
+	}
+
+	pef, err := NewPEFromFileName(managedTestAssembly)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	return pef
+}
+
+func TestDotNetRuntimeVersion(t *testing.T) {
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	if _, err := pef.DotNetRuntimeVersion(); err != ErrNotPresent {
+		t.Errorf("DotNetRuntimeVersion for native binary got error %v, want %v", err, ErrNotPresent)
+	}
+
+	pem := openManagedTestAssembly(t)
+	defer pem.Close()
+
+	ver, err := pem.DotNetRuntimeVersion()
+	if err != nil {
+		t.Fatalf("DotNetRuntimeVersion error: %v", err)
+	}
+	if ver != "v4.0.30319" {
+		t.Errorf("DotNetRuntimeVersion got %q, want %q", ver, "v4.0.30319")
+	}
+}
+
+func TestAssemblyReferences(t *testing.T) {
+	pem := openManagedTestAssembly(t)
+	defer pem.Close()
+
+	refs, err := pem.AssemblyReferences()
+	if err != nil {
+		t.Fatalf("AssemblyReferences error: %v", err)
+	}
+
+	var found bool
+	for _, ref := range refs {
+		t.Logf("%s, Version=%v, Culture=%q, PublicKeyToken=%x", ref.Name, ref.Version, ref.Culture, ref.PublicKeyToken)
+		if ref.Name != "mscorlib" {
+			continue
+		}
+		found = true
+		if want := "b77a5c561934e089"; hex.EncodeToString(ref.PublicKeyToken) != want {
+			t.Errorf("mscorlib PublicKeyToken got %x, want %s", ref.PublicKeyToken, want)
+		}
+		if ref.Version.Major != 4 {
+			t.Errorf("mscorlib Version got %v, want 4.x", ref.Version)
+		}
+	}
+	if !found {
+		t.Errorf("AssemblyReferences did not include mscorlib")
+	}
+}
+
+func TestStrongNameSignature(t *testing.T) {
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	if _, err := pef.StrongNameSignature(); err != ErrNotPresent {
+		t.Errorf("StrongNameSignature for native binary got error %v, want %v", err, ErrNotPresent)
+	}
+
+	pem := openManagedTestAssembly(t)
+	defer pem.Close()
+
+	sig, err := pem.StrongNameSignature()
+	if err != nil {
+		t.Fatalf("StrongNameSignature error: %v", err)
+	}
+	// The signature's length is that of the RSA key that produced it.
+	if len(sig) < 128 || len(sig)%8 != 0 {
+		t.Errorf("StrongNameSignature returned signature of unexpected length %d", len(sig))
+	}
+	if bytes.Count(sig, []byte{0}) == len(sig) {
+		t.Errorf("StrongNameSignature returned a signature consisting entirely of zeros")
+	}
+}
+
+func TestPublicKeyToken(t *testing.T) {
+	// The ECMA standard public key, whose token is well-known.
+	ecmaKey := []byte{0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0}
+	if got, want := hex.EncodeToString(publicKeyToken(ecmaKey)), "b77a5c561934e089"; got != want {
+		t.Errorf("publicKeyToken got %s, want %s", got, want)
+	}
+}
+
+func TestSafeSEHHandlers(t *testing.T) {
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	handlersFromModule, err := pem.SafeSEHHandlers()
+	if expectedMachineForGOARCH != dpe.IMAGE_FILE_MACHINE_I386 {
+		if err != ErrUnsupportedMachine {
+			t.Errorf("SafeSEHHandlers got error %v, want %v", err, ErrUnsupportedMachine)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("SafeSEHHandlers from module error: %v", err)
+	}
+	if len(handlersFromModule) == 0 {
+		t.Errorf("SafeSEHHandlers from module returned no handlers")
+	}
+
+	handlersFromFile, err := pef.SafeSEHHandlers()
+	if err != nil {
+		t.Fatalf("SafeSEHHandlers from file error: %v", err)
+	}
+	if !reflect.DeepEqual(handlersFromModule, handlersFromFile) {
+		t.Errorf("SafeSEHHandlers from module does not match file")
+	}
+}
+
+func TestCFGFunctions(t *testing.T) {
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	defer pem.Close()
+
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	// kernel32 is built with /guard:cf on every supported version of Windows.
+	funcsFromModule, err := pem.CFGFunctions()
+	if err != nil {
+		t.Fatalf("CFGFunctions from module error: %v", err)
+	}
+	if len(funcsFromModule) == 0 {
+		t.Fatalf("CFGFunctions from module returned no functions")
+	}
+
+	funcsFromFile, err := pef.CFGFunctions()
+	if err != nil {
+		t.Fatalf("CFGFunctions from file error: %v", err)
+	}
+	if !reflect.DeepEqual(funcsFromModule, funcsFromFile) {
+		t.Errorf("CFGFunctions from module does not match file")
+	}
+
+	// The table is sorted by RVA, and every entry must lie within the image.
+	sizeOfImage := pem.OptionalHeader().GetSizeOfImage()
+	for i, rva := range funcsFromModule {
+		if rva >= sizeOfImage {
+			t.Errorf("CFG function %d has out-of-range RVA 0x%08X", i, rva)
+		}
+		if i > 0 && rva <= funcsFromModule[i-1] {
+			t.Errorf("CFG function %d is out of order: 0x%08X <= 0x%08X", i, rva, funcsFromModule[i-1])
+		}
+	}
+
+	// Our Go test binary does not use CFG.
+	pet, err := NewPEFromFileName(os.Args[0])
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pet.Close()
+
+	if _, err := pet.CFGFunctions(); err != ErrNotPresent {
+		t.Errorf("CFGFunctions for Go binary got error %v, want %v", err, ErrNotPresent)
+	}
+}
+
+func TestLoadConfigTableOverflow(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	testLoadConfigTableOverflow(t, fname)
+}
+
+func testLoadConfigTableOverflow(t *testing.T, fname string) {
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	dde, err := pef.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_LOAD_CONFIG)
+	if err != nil {
+		t.Fatalf("RawDataDirectoryEntry error: %v", err)
+	}
+	lcOffset, ok := resolveRVA(pef, dde.VirtualAddress)
+	if !ok {
+		t.Fatalf("resolveRVA(0x%08X) failed", dde.VirtualAddress)
+	}
+
+	full, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// Claim a CFG function count that, when multiplied by the 4-byte stride that
+	// we select via GuardFlags, wraps around to a small table size.
+	tampered := bytes.Clone(full)
+	switch pef.optionalHeader.(type) {
+	case *optionalHeader32:
+		var lc _IMAGE_LOAD_CONFIG_DIRECTORY32
+		countOffset := int(lcOffset) + int(unsafe.Offsetof(lc.GuardCFFunctionCount))
+		flagsOffset := int(lcOffset) + int(unsafe.Offsetof(lc.GuardFlags))
+		binary.LittleEndian.PutUint32(tampered[countOffset:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(tampered[flagsOffset:], binary.LittleEndian.Uint32(tampered[flagsOffset:])&^imageGuardCFFunctionTableSizeMask)
+	case *optionalHeader64:
+		var lc _IMAGE_LOAD_CONFIG_DIRECTORY64
+		countOffset := int(lcOffset) + int(unsafe.Offsetof(lc.GuardCFFunctionCount))
+		flagsOffset := int(lcOffset) + int(unsafe.Offsetof(lc.GuardFlags))
+		binary.LittleEndian.PutUint64(tampered[countOffset:], 0x4000000000000001)
+		binary.LittleEndian.PutUint32(tampered[flagsOffset:], binary.LittleEndian.Uint32(tampered[flagsOffset:])&^imageGuardCFFunctionTableSizeMask)
+	}
+
+	tamperedName := filepath.Join(t.TempDir(), "tampered.dll")
+	if err := os.WriteFile(tamperedName, tampered, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	pet, err := NewPEFromFileName(tamperedName)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName(tampered) error: %v", err)
+	}
+	defer pet.Close()
+
+	if _, err := pet.CFGFunctions(); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("CFGFunctions with overflowing count got error %v, want %v", err, ErrInvalidBinary)
+	}
+
+	// SafeSEHHandlers is only available for x86 binaries, so we exercise its
+	// table (which has 4-byte entries) directly.
+	va := pef.optionalHeader.GetImageBase() + uint64(dde.VirtualAddress)
+	if _, err := pef.readLoadConfigTable(va, 0x4000000000000001, 4); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("readLoadConfigTable with overflowing count got error %v, want %v", err, ErrInvalidBinary)
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	var nilPEH *PEHeaders
+	if err := nilPEH.Close(); err != nil {
+		t.Errorf("Close on nil *PEHeaders got error %v, want nil", err)
+	}
+
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	if err := pef.Close(); err != nil {
+		t.Errorf("first Close on file got error %v", err)
+	}
+	if err := pef.Close(); err != nil {
+		t.Errorf("second Close on file got error %v, want nil", err)
+	}
+
+	k32 := windows.MustLoadDLL("kernel32.dll")
+	pem, err := NewPEFromDLL(k32)
+	if err != nil {
+		t.Fatalf("NewPEFromDLL error: %v", err)
+	}
+	if err := pem.Close(); err != nil {
+		t.Errorf("first Close on module got error %v", err)
+	}
+	// A second Close must not release another reference to the module.
+	if err := pem.Close(); err != nil {
+		t.Errorf("second Close on module got error %v, want nil", err)
+	}
+}
+
+func TestNewPEFromFileNameMapped(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	pem, err := NewPEFromFileNameMapped(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileNameMapped error: %v", err)
+	}
+
+	if pem.r.Limit() != pef.r.Limit() {
+		t.Errorf("Limit got %d, want %d", pem.r.Limit(), pef.r.Limit())
+	}
+	if pem.IsMappedAsImage() {
+		t.Errorf("IsMappedAsImage got true for mapped file, want false")
+	}
+	if *pem.FileHeader() != *pef.FileHeader() {
+		t.Errorf("FileHeader mismatch")
+	}
+	if !reflect.DeepEqual(pem.OptionalHeader(), pef.OptionalHeader()) {
+		t.Errorf("OptionalHeader mismatch")
+	}
+	if !reflect.DeepEqual(pem.Sections(), pef.Sections()) {
+		t.Errorf("Sections mismatch")
+	}
+
+	// The mapped file uses file offsets, not RVAs, so these must match too.
+	for _, idx := range []DataDirectoryIndex{IMAGE_DIRECTORY_ENTRY_DEBUG, IMAGE_DIRECTORY_ENTRY_SECURITY, IMAGE_DIRECTORY_ENTRY_IAT} {
+		fromFile, errFile := pef.DataDirectoryEntry(idx)
+		fromMapping, errMapping := pem.DataDirectoryEntry(idx)
+		if errFile != errMapping {
+			t.Errorf("DataDirectoryEntry(%d) error mismatch: got %v, want %v", idx, errMapping, errFile)
+			continue
+		}
+		if iat, ok := fromFile.(*IATInfo); ok {
+			fromFile, _ = iat.Thunks()
+			fromMapping, _ = fromMapping.(*IATInfo).Thunks()
+		}
+		if !reflect.DeepEqual(fromFile, fromMapping) {
+			t.Errorf("DataDirectoryEntry(%d) mismatch", idx)
+		}
+	}
+
+	_, wantRVA, err := pef.ExportByName("GetProcAddress")
+	if err != nil {
+		t.Fatalf("ExportByName from file error: %v", err)
+	}
+	if _, gotRVA, err := pem.ExportByName("GetProcAddress"); err != nil || gotRVA != wantRVA {
+		t.Errorf("ExportByName from mapping got (0x%08X, %v), want 0x%08X", gotRVA, err, wantRVA)
+	}
+
+	if err := pem.Close(); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+}
+
+func TestConcurrentQueries(t *testing.T) {
+	pef, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	query := func() (any, error) {
+		_, rva, err := pef.ExportByName("GetProcAddress")
+		if err != nil {
+			return nil, err
+		}
+		dbg, err := pef.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+		if err != nil {
+			return nil, err
+		}
+		return []any{rva, dbg}, nil
+	}
+
+	want, err := query()
+	if err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+
+	const numGoroutines = 16
+	errs := make(chan error, numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				got, err := query()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !reflect.DeepEqual(got, want) {
+					errs <- errors.New("concurrent query returned inconsistent results")
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+
+	for i := 0; i < numGoroutines; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSectionName(t *testing.T) {
+	pef, err := NewPEFromFileName(os.Args[0])
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	var hmod windows.Handle
+	if err := windows.GetModuleHandleEx(windows.GET_MODULE_HANDLE_EX_FLAG_UNCHANGED_REFCOUNT, nil, &hmod); err != nil {
+		t.Fatalf("GetModuleHandleEx error: %v", err)
+	}
+
+	pem, err := NewPEFromHMODULE(hmod)
+	if err != nil {
+		t.Fatalf("NewPEFromHMODULE error: %v", err)
+	}
+	defer pem.Close()
+
+	var numLong int
+	for i, s := range pef.Sections() {
+		raw := s.NameString()
+		name, err := pef.SectionName(i)
+		if err != nil {
+			t.Errorf("SectionName(%d) (%q) error: %v", i, raw, err)
+			continue
+		}
+
+		isLong := strings.HasPrefix(raw, "/")
+		if !isLong {
+			if name != raw {
+				t.Errorf("SectionName(%d) got %q, want %q", i, name, raw)
+			}
+		} else {
+			numLong++
+			if name == "" || strings.HasPrefix(name, "/") {
+				t.Errorf("SectionName(%d) did not resolve %q, got %q", i, raw, name)
+			}
+		}
+
+		// Modules cannot resolve long names, but must still report short ones.
+		mname, err := pem.SectionName(i)
+		if isLong {
+			if err != ErrUnavailableInModule {
+				t.Errorf("module SectionName(%d) got error %v, want %v", i, err, ErrUnavailableInModule)
+			}
+		} else if err != nil || mname != raw {
+			t.Errorf("module SectionName(%d) got (%q, %v), want (%q, nil)", i, mname, err, raw)
+		}
+	}
+
+	if _, err := pef.SectionName(len(pef.Sections())); err != ErrIndexOutOfRange {
+		t.Errorf("SectionName(out of range) got error %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	// Go's linker stores DWARF section names in the COFF string table, unless
+	// DWARF has been omitted from the test binary.
+	t.Logf("%d long section names", numLong)
+}
+
+func TestParseFiles(t *testing.T) {
+	paths := []string{
+		`C:\Windows\System32\kernel32.dll`,
+		filepath.Join(t.TempDir(), "doesnotexist.dll"),
+		os.Args[0],
+		`C:\Windows\System32\ntdll.dll`,
+	}
+
+	opts := ParseOptions{
+		Concurrency: 2,
+		Directories: []DataDirectoryIndex{IMAGE_DIRECTORY_ENTRY_DEBUG, IMAGE_DIRECTORY_ENTRY_SECURITY},
+	}
+	results, errs := ParseFiles(paths, opts)
+	if len(results) != len(paths) || len(errs) != len(paths) {
+		t.Fatalf("ParseFiles returned %d results and %d errors, want %d of each", len(results), len(errs), len(paths))
+	}
+
+	for i, path := range paths {
+		peh, err := results[i], errs[i]
+		if i == 1 {
+			if !errors.Is(err, os.ErrNotExist) || peh != nil {
+				t.Errorf("ParseFiles(%q) got (%v, %v), want (nil, %v)", path, peh, err, os.ErrNotExist)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseFiles(%q) error: %v", path, err)
+			continue
+		}
+		defer peh.Close()
+
+		want, err := NewPEFromFileName(path)
+		if err != nil {
+			t.Fatalf("NewPEFromFileName(%q) error: %v", path, err)
+		}
+		defer want.Close()
+
+		if !reflect.DeepEqual(peh.sections, want.sections) {
+			t.Errorf("ParseFiles(%q) sections do not match NewPEFromFileName", path)
+		}
+
+		for _, idx := range opts.Directories {
+			if _, ok := peh.dirCache[idx]; !ok {
+				t.Errorf("ParseFiles(%q) did not eagerly parse directory %d", path, idx)
+			}
+			got, gotErr := peh.DataDirectoryEntry(idx)
+			wantVal, wantErr := want.DataDirectoryEntry(idx)
+			if gotErr != wantErr || !reflect.DeepEqual(got, wantVal) {
+				t.Errorf("ParseFiles(%q) DataDirectoryEntry(%d) does not match NewPEFromFileName", path, idx)
+			}
+		}
+	}
+}
+
+func TestParseFilesCachedCopies(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	opts := ParseOptions{Directories: []DataDirectoryIndex{IMAGE_DIRECTORY_ENTRY_DEBUG, IMAGE_DIRECTORY_ENTRY_IAT}}
+	results, errs := ParseFiles([]string{fname}, opts)
+	if errs[0] != nil {
+		t.Fatalf("ParseFiles(%q) error: %v", fname, errs[0])
+	}
+	peh := results[0]
+	defer peh.Close()
+
+	dbgAny, err := peh.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		t.Fatalf("DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG) error: %v", err)
+	}
+	dbg := dbgAny.([]IMAGE_DEBUG_DIRECTORY)
+	if len(dbg) == 0 {
+		t.Fatalf("%q has no debug directory entries", fname)
+	}
+	want := dbg[0]
+	dbg[0] = IMAGE_DEBUG_DIRECTORY{}
+
+	dbgAny, err = peh.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		t.Fatalf("DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG) error: %v", err)
+	}
+	if got := dbgAny.([]IMAGE_DEBUG_DIRECTORY)[0]; got != want {
+		t.Errorf("mutating a cached debug directory result affected a subsequent call: got %+v, want %+v", got, want)
+	}
+
+	iatAny, err := peh.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT)
+	if err != nil {
+		t.Fatalf("DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT) error: %v", err)
+	}
+	iat := iatAny.(*IATInfo)
+	wantIAT := iat.DataDirectoryEntry
+	iat.DataDirectoryEntry = DataDirectoryEntry{}
+
+	iatAny, err = peh.DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT)
+	if err != nil {
+		t.Fatalf("DataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_IAT) error: %v", err)
+	}
+	if got := iatAny.(*IATInfo).DataDirectoryEntry; got != wantIAT {
+		t.Errorf("mutating a cached IAT result affected a subsequent call: got %+v, want %+v", got, wantIAT)
+	}
+}
+
+func TestSignatureCoverage(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	// System DLLs are catalog-signed, so they lack embedded signatures.
+	if _, _, err := pef.SignatureCoverage(); err != ErrNotPresent {
+		t.Fatalf("SignatureCoverage got error %v, want %v", err, ErrNotPresent)
+	}
+
+	full, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// Append a certificate table to a copy of the file and point the security
+	// directory entry at it.
+	e_lfanew := int(binary.LittleEndian.Uint32(full[offsetIMAGE_DOS_HEADERe_lfanew:]))
+	optionalHeaderOffset := e_lfanew + 4 + int(unsafe.Sizeof(FileHeader{}))
+	ddOffset := optionalHeaderOffset + int(unsafe.Offsetof(optionalHeader32{}.DataDirectory))
+	if pef.optionalHeader.GetMagic() == 0x020B {
+		ddOffset = optionalHeaderOffset + int(unsafe.Offsetof(optionalHeader64{}.DataDirectory))
+	}
+	securityOffset := ddOffset + int(IMAGE_DIRECTORY_ENTRY_SECURITY)*int(unsafe.Sizeof(DataDirectoryEntry{}))
+
+	const certTableSize = 16
+	signed := bytes.Clone(full)
+	signed = append(signed, make([]byte, alignUp(len(signed), 8)-len(signed))...)
+	certStart := len(signed)
+	binary.LittleEndian.PutUint32(signed[securityOffset:], uint32(certStart))
+	binary.LittleEndian.PutUint32(signed[securityOffset+4:], certTableSize)
+	signed = append(signed, make([]byte, certTableSize)...)
+
+	const overlaySize = 100
+	testCases := []struct {
+		name     string
+		contents []byte
+	}{
+		{"Signed", signed},
+		{"Overlay", append(bytes.Clone(signed), make([]byte, overlaySize)...)},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range testCases {
+		fname := filepath.Join(dir, tc.name+".dll")
+		if err := os.WriteFile(fname, tc.contents, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		pet, err := NewPEFromFileName(fname)
+		if err != nil {
+			t.Fatalf("%s: NewPEFromFileName error: %v", tc.name, err)
+		}
+		defer pet.Close()
+
+		signedBytes, totalBytes, err := pet.SignatureCoverage()
+		if err != nil {
+			t.Errorf("%s: SignatureCoverage error: %v", tc.name, err)
+			continue
+		}
+		if want := int64(certStart - 12); signedBytes != want {
+			t.Errorf("%s: signedBytes got %d, want %d", tc.name, signedBytes, want)
+		}
+		if want := int64(len(tc.contents)); totalBytes != want {
+			t.Errorf("%s: totalBytes got %d, want %d", tc.name, totalBytes, want)
+		}
+	}
+}
+
+func TestGoBuildInfo(t *testing.T) {
+	want, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("build info unavailable for test binary")
+	}
+
+	pef, err := NewPEFromFileName(os.Args[0])
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	got, err := pef.GoBuildInfo()
+	if err != nil {
+		t.Fatalf("GoBuildInfo error: %v", err)
+	}
+	if got.GoVersion != want.GoVersion || got.Path != want.Path {
+		t.Errorf("GoBuildInfo got (%q, %q), want (%q, %q)", got.GoVersion, got.Path, want.GoVersion, want.Path)
+	}
+
+	k32, err := NewPEFromFileName(`C:\Windows\System32\kernel32.dll`)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer k32.Close()
+
+	if _, err := k32.GoBuildInfo(); err != ErrNotPresent {
+		t.Errorf("GoBuildInfo on non-Go binary got error %v, want %v", err, ErrNotPresent)
+	}
+
+	var hmod windows.Handle
+	if err := windows.GetModuleHandleEx(windows.GET_MODULE_HANDLE_EX_FLAG_UNCHANGED_REFCOUNT, nil, &hmod); err != nil {
+		t.Fatalf("GetModuleHandleEx error: %v", err)
+	}
+
+	pem, err := NewPEFromHMODULE(hmod)
+	if err != nil {
+		t.Fatalf("NewPEFromHMODULE error: %v", err)
+	}
+	defer pem.Close()
+
+	if _, err := pem.GoBuildInfo(); err != ErrUnavailableInModule {
+		t.Errorf("GoBuildInfo on module got error %v, want %v", err, ErrUnavailableInModule)
+	}
+}
+
+func TestDataDirectoryEntryStrict(t *testing.T) {
+	const fname = `C:\Windows\System32\kernel32.dll`
+	pef, err := NewPEFromFileName(fname)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName error: %v", err)
+	}
+	defer pef.Close()
+
+	for i := 0; i < pef.NumDataDirectories(); i++ {
+		idx := DataDirectoryIndex(i)
+		want, wantErr := pef.DataDirectoryEntry(idx)
+		got, err := pef.DataDirectoryEntryStrict(idx)
+		if err != wantErr {
+			t.Errorf("DataDirectoryEntryStrict(%d) got error %v, want %v", idx, err, wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("DataDirectoryEntryStrict(%d) result does not match DataDirectoryEntry", idx)
+		}
+	}
+
+	// Now inflate the size of the debug directory entry in a copy of the file.
+	dde, err := pef.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_DEBUG)
+	if err != nil {
+		t.Fatalf("RawDataDirectoryEntry error: %v", err)
+	}
+
+	full, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	optionalHeaderOffset := int(pef.e_lfanew) + 4 + int(unsafe.Sizeof(FileHeader{}))
+	sizeOffset := optionalHeaderOffset + len(pef.OptionalHeaderBytes()) + int(IMAGE_DIRECTORY_ENTRY_DEBUG)*8 + 4
+	if got := binary.LittleEndian.Uint32(full[sizeOffset:]); got != dde.Size {
+		t.Fatalf("located wrong data directory entry: size got %d, want %d", got, dde.Size)
+	}
+	binary.LittleEndian.PutUint32(full[sizeOffset:], 0x10000000)
+
+	tampered := filepath.Join(t.TempDir(), "tampered.dll")
+	if err := os.WriteFile(tampered, full, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	pet, err := NewPEFromFileName(tampered)
+	if err != nil {
+		t.Fatalf("NewPEFromFileName(tampered) error: %v", err)
+	}
+	defer pet.Close()
+
+	if _, err := pet.DataDirectoryEntryStrict(IMAGE_DIRECTORY_ENTRY_DEBUG); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("DataDirectoryEntryStrict on tampered binary got error %v, want %v", err, ErrInvalidBinary)
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// ResourceType identifies one of the predefined types of Win32 resources.
+type ResourceType uint16
+
+const (
+	RT_CURSOR       ResourceType = 1
+	RT_BITMAP       ResourceType = 2
+	RT_ICON         ResourceType = 3
+	RT_MENU         ResourceType = 4
+	RT_DIALOG       ResourceType = 5
+	RT_STRING       ResourceType = 6
+	RT_FONTDIR      ResourceType = 7
+	RT_FONT         ResourceType = 8
+	RT_ACCELERATOR  ResourceType = 9
+	RT_RCDATA       ResourceType = 10
+	RT_MESSAGETABLE ResourceType = 11
+	RT_GROUP_CURSOR ResourceType = 12
+	RT_GROUP_ICON   ResourceType = 14
+	RT_VERSION      ResourceType = 16
+	RT_DLGINCLUDE   ResourceType = 17
+	RT_PLUGPLAY     ResourceType = 19
+	RT_VXD          ResourceType = 20
+	RT_ANICURSOR    ResourceType = 21
+	RT_ANIICON      ResourceType = 22
+	RT_HTML         ResourceType = 23
+	RT_MANIFEST     ResourceType = 24
+)
+
+// ResourceID identifies a resource either by number or by name. Use
+// IntResourceID or NamedResourceID to create one.
+type ResourceID struct {
+	name string
+	num  uint16
+}
+
+// IntResourceID returns a ResourceID that identifies a resource by number.
+func IntResourceID(num uint16) ResourceID {
+	return ResourceID{num: num}
+}
+
+// NamedResourceID returns a ResourceID that identifies a resource by name.
+// Names are matched case-insensitively. Since resource names cannot be empty,
+// NamedResourceID("") is equivalent to IntResourceID(0).
+func NamedResourceID(name string) ResourceID {
+	return ResourceID{name: name}
+}
+
+// IsNamed returns true when id identifies a resource by name.
+func (id ResourceID) IsNamed() bool {
+	return id.name != ""
+}
+
+func (id ResourceID) String() string {
+	if id.IsNamed() {
+		return id.name
+	}
+	return "#" + strconv.FormatUint(uint64(id.num), 10)
+}
+
+// The following constants are from the PE spec
+const (
+	imageResourceNameIsString    = 0x80000000
+	imageResourceDataIsDirectory = 0x80000000
+	maxResourceNameLen           = 0x1000
+)
+
+const (
+	langEnUS    = 0x0409
+	langNeutral = 0
+)
+
+type _IMAGE_RESOURCE_DIRECTORY struct {
+	Characteristics      uint32
+	TimeDateStamp        uint32
+	MajorVersion         uint16
+	MinorVersion         uint16
+	NumberOfNamedEntries uint16
+	NumberOfIdEntries    uint16
+}
+
+type _IMAGE_RESOURCE_DIRECTORY_ENTRY struct {
+	NameOrID     uint32
+	OffsetToData uint32
+}
+
+type _IMAGE_RESOURCE_DATA_ENTRY struct {
+	OffsetToData uint32
+	Size         uint32
+	CodePage     uint32
+	Reserved     uint32
+}
+
+// resourceDirectory provides access to nfo's resource tree. All offsets within
+// the tree are relative to the beginning of the resource directory.
+type resourceDirectory struct {
+	nfo *PEHeaders
+	dde DataDirectoryEntry
+}
+
+func (nfo *PEHeaders) resourceDirectory() (*resourceDirectory, error) {
+	dde, err := nfo.RawDataDirectoryEntry(IMAGE_DIRECTORY_ENTRY_RESOURCE)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourceDirectory{nfo: nfo, dde: dde}, nil
+}
+
+// entries returns the entries of the resource directory table located at
+// offset.
+func (rd *resourceDirectory) entries(offset uint32) ([]_IMAGE_RESOURCE_DIRECTORY_ENTRY, error) {
+	if offset >= rd.dde.Size {
+		return nil, fmt.Errorf("%w: resource directory offset 0x%X is out of bounds", ErrInvalidBinary, offset)
+	}
+
+	rva, ok := resolveRVA(rd.nfo, rd.dde.VirtualAddress+offset)
+	if !ok {
+		return nil, ErrResolvingFileRVA
+	}
+
+	dir, err := readStructCopy[_IMAGE_RESOURCE_DIRECTORY](rd.nfo.r, rva)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(dir.NumberOfNamedEntries) + int(dir.NumberOfIdEntries)
+	return readStructArrayCopy[_IMAGE_RESOURCE_DIRECTORY_ENTRY](rd.nfo.r, rva+uint32(unsafe.Sizeof(dir)), count)
+}
+
+// name reads the name of a named directory entry, which is stored as a
+// length-prefixed UTF-16 string at offset.
+func (rd *resourceDirectory) name(offset uint32) (string, error) {
+	if offset >= rd.dde.Size {
+		return "", fmt.Errorf("%w: resource name offset 0x%X is out of bounds", ErrInvalidBinary, offset)
+	}
+
+	rva := rd.dde.VirtualAddress + offset
+	length, err := readArrayElement[uint16](rd.nfo, rva, 0)
+	if err != nil {
+		return "", err
+	}
+	if length > maxResourceNameLen {
+		return "", fmt.Errorf("%w: resource name is too long", ErrInvalidBinary)
+	}
+
+	off, ok := resolveRVA(rd.nfo, rva+uint32(unsafe.Sizeof(length)))
+	if !ok {
+		return "", ErrResolvingFileRVA
+	}
+
+	u16, err := readStructArrayCopy[uint16](rd.nfo.r, off, int(length))
+	if err != nil {
+		return "", err
+	}
+
+	return string(utf16.Decode(u16)), nil
+}
+
+// matches returns true when e is identified by id.
+func (rd *resourceDirectory) matches(e _IMAGE_RESOURCE_DIRECTORY_ENTRY, id ResourceID) (bool, error) {
+	isNamed := e.NameOrID&imageResourceNameIsString != 0
+	if isNamed != id.IsNamed() {
+		return false, nil
+	}
+	if !isNamed {
+		return uint16(e.NameOrID) == id.num, nil
+	}
+
+	name, err := rd.name(e.NameOrID &^ imageResourceNameIsString)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(name, id.name), nil
+}
+
+// subdirectory locates the entry identified by id within the directory table at
+// offset, and returns the offset of the subdirectory that it references. It
+// returns ErrNotPresent if no such entry exists.
+func (rd *resourceDirectory) subdirectory(offset uint32, id ResourceID) (uint32, error) {
+	entries, err := rd.entries(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range entries {
+		ok, err := rd.matches(e, id)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		if e.OffsetToData&imageResourceDataIsDirectory == 0 {
+			return 0, fmt.Errorf("%w: resource directory entry %v is not a subdirectory", ErrInvalidBinary, id)
+		}
+
+		return e.OffsetToData &^ imageResourceDataIsDirectory, nil
+	}
+
+	return 0, ErrNotPresent
+}
+
+// languageEntry selects the entry for lang from the language directory table at
+// offset. When lang is zero, it prefers en-US, then language-neutral, then the
+// first language available. It returns the offset of the selected entry's
+// data entry, or ErrNotPresent if no suitable entry exists.
+func (rd *resourceDirectory) languageEntry(offset uint32, lang uint16) (uint32, error) {
+	entries, err := rd.entries(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	var candidates []uint16
+	if lang == 0 {
+		candidates = []uint16{langEnUS, langNeutral}
+	} else {
+		candidates = []uint16{lang}
+	}
+
+	selected := -1
+	for _, c := range candidates {
+		for i, e := range entries {
+			if e.NameOrID&imageResourceNameIsString == 0 && uint16(e.NameOrID) == c {
+				selected = i
+				break
+			}
+		}
+		if selected >= 0 {
+			break
+		}
+	}
+	if selected < 0 && lang == 0 && len(entries) > 0 {
+		selected = 0
+	}
+	if selected < 0 {
+		return 0, ErrNotPresent
+	}
+
+	e := entries[selected]
+	if e.OffsetToData&imageResourceDataIsDirectory != 0 {
+		return 0, fmt.Errorf("%w: resource language entry is a subdirectory", ErrInvalidBinary)
+	}
+
+	return e.OffsetToData, nil
+}
+
+// data reads the contents of the resource described by the data entry located
+// at offset.
+func (rd *resourceDirectory) data(offset uint32) ([]byte, error) {
+	if offset >= rd.dde.Size {
+		return nil, fmt.Errorf("%w: resource data entry offset 0x%X is out of bounds", ErrInvalidBinary, offset)
+	}
+
+	rva, ok := resolveRVA(rd.nfo, rd.dde.VirtualAddress+offset)
+	if !ok {
+		return nil, ErrResolvingFileRVA
+	}
+
+	de, err := readStructCopy[_IMAGE_RESOURCE_DATA_ENTRY](rd.nfo.r, rva)
+	if err != nil {
+		return nil, err
+	}
+	if de.Size > rd.nfo.optionalHeader.GetSizeOfImage() {
+		return nil, fmt.Errorf("%w: resource size 0x%X is too large", ErrInvalidBinary, de.Size)
+	}
+
+	// Unlike the other offsets in the resource tree, OffsetToData is an RVA.
+	sr, err := rd.nfo.rvaReader(de.OffsetToData, de.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, de.Size)
+	if _, err := readFull(sr, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Resource returns a copy of the raw contents of the resource of type resType
+// that is identified by id, in the language lang. When lang is zero, Resource
+// prefers en-US, followed by language-neutral, followed by the first language
+// available. It returns ErrNotPresent if no such resource exists.
+func (nfo *PEHeaders) Resource(resType ResourceType, id ResourceID, lang uint16) ([]byte, error) {
+	rd, err := nfo.resourceDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	typeDir, err := rd.subdirectory(0, IntResourceID(uint16(resType)))
+	if err != nil {
+		return nil, err
+	}
+
+	nameDir, err := rd.subdirectory(typeDir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dataEntry, err := rd.languageEntry(nameDir, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	return rd.data(dataEntry)
+}
+
+// The following constants are from the Windows SDK
+const (
+	stringsPerStringTableBlock = 16
+	// String IDs are 16 bits wide, so 4096 blocks of 16 strings cover them all.
+	maxStringTableBlockID = 0x10000 / stringsPerStringTableBlock
+)
+
+// parseStringTableBlock decodes block, the contents of an RT_STRING resource
+// whose ID is blockID, into result. Each block consists of 16 length-prefixed
+// UTF-16 strings; empty strings are omitted from result.
+func parseStringTableBlock(result map[uint16]string, blockID uint32, block []byte) error {
+	if blockID == 0 || blockID > maxStringTableBlockID {
+		return fmt.Errorf("%w: invalid string table block ID %d", ErrInvalidBinary, blockID)
+	}
+
+	firstID := (blockID - 1) * stringsPerStringTableBlock
+	for i := uint32(0); i < stringsPerStringTableBlock; i++ {
+		if len(block) < 2 {
+			return fmt.Errorf("%w: string table block %d is truncated", ErrInvalidBinary, blockID)
+		}
+		length := int(binary.LittleEndian.Uint16(block))
+		block = block[2:]
+
+		if len(block) < length*2 {
+			return fmt.Errorf("%w: string table block %d is truncated", ErrInvalidBinary, blockID)
+		}
+		if length == 0 {
+			continue
+		}
+
+		u16 := make([]uint16, length)
+		for j := range u16 {
+			u16[j] = binary.LittleEndian.Uint16(block[j*2:])
+		}
+		block = block[length*2:]
+
+		result[uint16(firstID+i)] = string(utf16.Decode(u16))
+	}
+
+	return nil
+}
+
+// Strings returns the contents of all of nfo's RT_STRING resources in the
+// language lang, as a map of string IDs to their values. When lang is zero,
+// each string table block is selected using the same language preferences as
+// Resource. Empty strings are omitted. It returns ErrNotPresent if nfo contains
+// no strings in lang.
+func (nfo *PEHeaders) Strings(lang uint16) (map[uint16]string, error) {
+	rd, err := nfo.resourceDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	typeDir, err := rd.subdirectory(0, IntResourceID(uint16(RT_STRING)))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := rd.entries(typeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint16]string)
+	for _, b := range blocks {
+		if b.NameOrID&imageResourceNameIsString != 0 {
+			// String table blocks are always identified by number.
+			continue
+		}
+		if b.OffsetToData&imageResourceDataIsDirectory == 0 {
+			return nil, fmt.Errorf("%w: string table block entry is not a subdirectory", ErrInvalidBinary)
+		}
+
+		dataEntry, err := rd.languageEntry(b.OffsetToData&^imageResourceDataIsDirectory, lang)
+		if err != nil {
+			if err == ErrNotPresent {
+				// This block has not been translated into lang.
+				continue
+			}
+			return nil, err
+		}
+
+		block, err := rd.data(dataEntry)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := parseStringTableBlock(result, b.NameOrID, block); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotPresent
+	}
+
+	return result, nil
+}
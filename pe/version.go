@@ -6,6 +6,8 @@
 package pe
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"unsafe"
@@ -16,21 +18,9 @@ import (
 var (
 	errFixedFileInfoBadSig   = errors.New("bad VS_FIXEDFILEINFO signature")
 	errFixedFileInfoTooShort = errors.New("buffer smaller than VS_FIXEDFILEINFO")
+	errVersionInfoNotUnicode = errors.New("resource is not a 32-bit Unicode VS_VERSIONINFO")
 )
 
-// VersionNumber encapsulates a four-component version number that is stored
-// in Windows VERSIONINFO resources.
-type VersionNumber struct {
-	Major uint16
-	Minor uint16
-	Patch uint16
-	Build uint16
-}
-
-func (vn VersionNumber) String() string {
-	return fmt.Sprintf("%d.%d.%d.%d", vn.Major, vn.Minor, vn.Patch, vn.Build)
-}
-
 type langAndCodePage struct {
 	language uint16
 	codePage uint16
@@ -44,11 +34,7 @@ type VersionInfo struct {
 	translationIDs []langAndCodePage
 }
 
-const (
-	langEnUS        = 0x0409
-	codePageUTF16LE = 0x04B0
-	langNeutral     = 0
-)
+const codePageUTF16LE = 0x04B0
 
 // NewVersionInfo extracts any VERSIONINFO resource from filepath, parses its
 // fixed-size information, and returns a *VersionInfo for further querying.
@@ -67,6 +53,53 @@ func NewVersionInfo(filepath string) (*VersionInfo, error) {
 		return nil, err
 	}
 
+	return parseVersionInfo(buf)
+}
+
+// NewVersionInfoFromBytes parses the fixed-size information of the VERSIONINFO
+// resource contained in buf and returns a *VersionInfo for further querying.
+// buf must contain the raw contents of a 32-bit (Unicode) RT_VERSION resource,
+// such as those produced by GetFileVersionInfo. NewVersionInfoFromBytes makes
+// its own copy of buf.
+func NewVersionInfoFromBytes(buf []byte) (*VersionInfo, error) {
+	// The resource begins with its own length in bytes, followed by two more
+	// uint16 header fields and the resource's key. Ensure that all of this is
+	// consistent with buf before letting VerQueryValue loose on it.
+	const minHeaderLen = 6
+	if len(buf) < minHeaderLen {
+		return nil, ErrBadLength
+	}
+	length := int(binary.LittleEndian.Uint16(buf))
+	if length < minHeaderLen || length > len(buf) {
+		return nil, ErrBadLength
+	}
+
+	// 16-bit resources use an ANSI key and must be converted by VerQueryValue,
+	// which requires even more scratch space than we reserve below.
+	if !bytes.HasPrefix(buf[minHeaderLen:length], versionInfoKeyUTF16) {
+		return nil, errVersionInfoNotUnicode
+	}
+
+	// VerQueryValue assumes that it may use the scratch space that
+	// GetFileVersionInfoSize reserves beyond the end of the resource, so we
+	// must provide the same amount of slack: twice the resource's length, plus
+	// a trailing signature.
+	cloned := make([]byte, 2*length+4)
+	copy(cloned, buf[:length])
+	return parseVersionInfo(cloned)
+}
+
+// versionInfoKeyUTF16 is the null-terminated, UTF-16LE encoding of the key
+// that begins every 32-bit VS_VERSIONINFO.
+var versionInfoKeyUTF16 = func() []byte {
+	u16 := windows.StringToUTF16("VS_VERSION_INFO")
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(u16))), len(u16)*2)
+}()
+
+// parseVersionInfo validates the fixed-size information in buf, which must
+// contain a VERSIONINFO resource, and wraps buf in a *VersionInfo. The
+// returned VersionInfo retains pointers into buf.
+func parseVersionInfo(buf []byte) (*VersionInfo, error) {
 	var fixed *windows.VS_FIXEDFILEINFO
 	var fixedLen uint32
 	if err := windows.VerQueryValue(unsafe.Pointer(unsafe.SliceData(buf)), `\`, unsafe.Pointer(&fixed), &fixedLen); err != nil {
@@ -165,3 +198,51 @@ func (vi *VersionInfo) Field(key string) (string, error) {
 
 	return "", ErrNotPresent
 }
+
+// The following methods query the standard string fields of the version
+// information. Each one returns ErrNotPresent if its field is unavailable.
+
+// CompanyName returns the name of the company that produced the binary.
+func (vi *VersionInfo) CompanyName() (string, error) {
+	return vi.Field("CompanyName")
+}
+
+// ProductName returns the name of the product with which the binary is
+// distributed.
+func (vi *VersionInfo) ProductName() (string, error) {
+	return vi.Field("ProductName")
+}
+
+// FileDescription returns a description of the binary that is suitable for
+// presentation to users.
+func (vi *VersionInfo) FileDescription() (string, error) {
+	return vi.Field("FileDescription")
+}
+
+// OriginalFilename returns the name of the binary as originally produced,
+// before any renaming.
+func (vi *VersionInfo) OriginalFilename() (string, error) {
+	return vi.Field("OriginalFilename")
+}
+
+// InternalName returns the binary's internal name.
+func (vi *VersionInfo) InternalName() (string, error) {
+	return vi.Field("InternalName")
+}
+
+// LegalCopyright returns the copyright notices that apply to the binary.
+func (vi *VersionInfo) LegalCopyright() (string, error) {
+	return vi.Field("LegalCopyright")
+}
+
+// FileVersionString returns the binary's version as a free-form string. Unlike
+// VersionNumber, its contents need not be numeric.
+func (vi *VersionInfo) FileVersionString() (string, error) {
+	return vi.Field("FileVersion")
+}
+
+// ProductVersionString returns the version of the product with which the
+// binary is distributed, as a free-form string.
+func (vi *VersionInfo) ProductVersionString() (string, error) {
+	return vi.Field("ProductVersion")
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !windows
+
+package pe
+
+// VersionInfo encapsulates a buffer containing the VERSIONINFO resources that
+// have been successfully extracted from a PE binary. Parsing VERSIONINFO
+// resources requires Windows APIs, so on other platforms a VersionInfo can
+// never be obtained; it is declared only so that code using it may be compiled
+// for every platform.
+type VersionInfo struct{}
+
+// NewVersionInfo always returns ErrRequiresWindows on this platform.
+func NewVersionInfo(filepath string) (*VersionInfo, error) {
+	return nil, ErrRequiresWindows
+}
+
+// NewVersionInfoFromBytes always returns ErrRequiresWindows on this platform.
+func NewVersionInfoFromBytes(buf []byte) (*VersionInfo, error) {
+	return nil, ErrRequiresWindows
+}
+
+// VersionNumber returns the binary's numeric file version. VersionInfo is
+// unsupported on non-Windows platforms, so it always returns the zero value.
+func (vi *VersionInfo) VersionNumber() VersionNumber {
+	return VersionNumber{}
+}
+
+// Field queries the version information for a field named key. VersionInfo
+// is unsupported on non-Windows platforms, so it always returns
+// ErrRequiresWindows.
+func (vi *VersionInfo) Field(key string) (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// CompanyName returns the name of the company that produced the binary. It is
+// unsupported on non-Windows platforms and always returns ErrRequiresWindows.
+func (vi *VersionInfo) CompanyName() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// ProductName returns the name of the product with which the binary is
+// distributed. It is unsupported on non-Windows platforms and always returns
+// ErrRequiresWindows.
+func (vi *VersionInfo) ProductName() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// FileDescription returns a description of the binary that is suitable for
+// presentation to users. It is unsupported on non-Windows platforms and always
+// returns ErrRequiresWindows.
+func (vi *VersionInfo) FileDescription() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// OriginalFilename returns the name of the binary as originally produced,
+// before any renaming. It is unsupported on non-Windows platforms and always
+// returns ErrRequiresWindows.
+func (vi *VersionInfo) OriginalFilename() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// InternalName returns the binary's internal name. It is unsupported on
+// non-Windows platforms and always returns ErrRequiresWindows.
+func (vi *VersionInfo) InternalName() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// LegalCopyright returns the copyright notices that apply to the binary. It is
+// unsupported on non-Windows platforms and always returns ErrRequiresWindows.
+func (vi *VersionInfo) LegalCopyright() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// FileVersionString returns the binary's version as a free-form string. It is
+// unsupported on non-Windows platforms and always returns ErrRequiresWindows.
+func (vi *VersionInfo) FileVersionString() (string, error) {
+	return "", ErrRequiresWindows
+}
+
+// ProductVersionString returns the version of the product with which the
+// binary is distributed, as a free-form string. It is unsupported on
+// non-Windows platforms and always returns ErrRequiresWindows.
+func (vi *VersionInfo) ProductVersionString() (string, error) {
+	return "", ErrRequiresWindows
+}
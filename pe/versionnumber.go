@@ -0,0 +1,21 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package pe
+
+import (
+	"fmt"
+)
+
+// VersionNumber encapsulates a four-component version number that is stored
+// in Windows VERSIONINFO resources.
+type VersionNumber struct {
+	Major uint16
+	Minor uint16
+	Patch uint16
+	Build uint16
+}
+
+func (vn VersionNumber) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", vn.Major, vn.Minor, vn.Patch, vn.Build)
+}
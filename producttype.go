@@ -0,0 +1,121 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package wingoes
+
+import (
+	"fmt"
+)
+
+// ProductType is an enumeration of Windows editions, as returned by the
+// GetProductInfo API.
+type ProductType uint32
+
+const (
+	PRODUCT_UNDEFINED                    = ProductType(0x00000000)
+	PRODUCT_ULTIMATE                     = ProductType(0x00000001)
+	PRODUCT_HOME_BASIC                   = ProductType(0x00000002)
+	PRODUCT_HOME_PREMIUM                 = ProductType(0x00000003)
+	PRODUCT_ENTERPRISE                   = ProductType(0x00000004)
+	PRODUCT_HOME_BASIC_N                 = ProductType(0x00000005)
+	PRODUCT_BUSINESS                     = ProductType(0x00000006)
+	PRODUCT_STANDARD_SERVER              = ProductType(0x00000007)
+	PRODUCT_DATACENTER_SERVER            = ProductType(0x00000008)
+	PRODUCT_SMALLBUSINESS_SERVER         = ProductType(0x00000009)
+	PRODUCT_ENTERPRISE_SERVER            = ProductType(0x0000000A)
+	PRODUCT_STARTER                      = ProductType(0x0000000B)
+	PRODUCT_DATACENTER_SERVER_CORE       = ProductType(0x0000000C)
+	PRODUCT_STANDARD_SERVER_CORE         = ProductType(0x0000000D)
+	PRODUCT_ENTERPRISE_SERVER_CORE       = ProductType(0x0000000E)
+	PRODUCT_WEB_SERVER                   = ProductType(0x00000011)
+	PRODUCT_ENTERPRISE_N                 = ProductType(0x0000001B)
+	PRODUCT_PROFESSIONAL                 = ProductType(0x00000030)
+	PRODUCT_PROFESSIONAL_N               = ProductType(0x00000031)
+	PRODUCT_ENTERPRISE_EVALUATION        = ProductType(0x00000048)
+	PRODUCT_STANDARD_EVALUATION_SERVER   = ProductType(0x0000004F)
+	PRODUCT_DATACENTER_EVALUATION_SERVER = ProductType(0x00000050)
+	PRODUCT_CORE_N                       = ProductType(0x00000062)
+	PRODUCT_CORE_COUNTRYSPECIFIC         = ProductType(0x00000063)
+	PRODUCT_CORE_SINGLELANGUAGE          = ProductType(0x00000064)
+	PRODUCT_CORE                         = ProductType(0x00000065)
+	PRODUCT_EDUCATION                    = ProductType(0x00000079)
+	PRODUCT_EDUCATION_N                  = ProductType(0x0000007A)
+	PRODUCT_ENTERPRISE_S                 = ProductType(0x0000007D)
+	PRODUCT_ENTERPRISE_S_N               = ProductType(0x0000007E)
+	PRODUCT_PRO_WORKSTATION              = ProductType(0x000000A1)
+	PRODUCT_PRO_WORKSTATION_N            = ProductType(0x000000A2)
+	PRODUCT_PRO_FOR_EDUCATION            = ProductType(0x000000A4)
+	PRODUCT_SERVERRDSH                   = ProductType(0x000000AF)
+	PRODUCT_IOTENTERPRISE                = ProductType(0x000000BC)
+	PRODUCT_UNLICENSED                   = ProductType(0xABCDABCD)
+)
+
+var productTypeNames = map[ProductType]string{
+	PRODUCT_UNDEFINED:                    "Unknown",
+	PRODUCT_ULTIMATE:                     "Ultimate",
+	PRODUCT_HOME_BASIC:                   "Home Basic",
+	PRODUCT_HOME_PREMIUM:                 "Home Premium",
+	PRODUCT_ENTERPRISE:                   "Enterprise",
+	PRODUCT_HOME_BASIC_N:                 "Home Basic N",
+	PRODUCT_BUSINESS:                     "Business",
+	PRODUCT_STANDARD_SERVER:              "Server Standard",
+	PRODUCT_DATACENTER_SERVER:            "Server Datacenter",
+	PRODUCT_SMALLBUSINESS_SERVER:         "Small Business Server",
+	PRODUCT_ENTERPRISE_SERVER:            "Server Enterprise",
+	PRODUCT_STARTER:                      "Starter",
+	PRODUCT_DATACENTER_SERVER_CORE:       "Server Datacenter (Core)",
+	PRODUCT_STANDARD_SERVER_CORE:         "Server Standard (Core)",
+	PRODUCT_ENTERPRISE_SERVER_CORE:       "Server Enterprise (Core)",
+	PRODUCT_WEB_SERVER:                   "Web Server",
+	PRODUCT_ENTERPRISE_N:                 "Enterprise N",
+	PRODUCT_PROFESSIONAL:                 "Pro",
+	PRODUCT_PROFESSIONAL_N:               "Pro N",
+	PRODUCT_ENTERPRISE_EVALUATION:        "Enterprise Evaluation",
+	PRODUCT_STANDARD_EVALUATION_SERVER:   "Server Standard Evaluation",
+	PRODUCT_DATACENTER_EVALUATION_SERVER: "Server Datacenter Evaluation",
+	PRODUCT_CORE_N:                       "Home N",
+	PRODUCT_CORE_COUNTRYSPECIFIC:         "Home China",
+	PRODUCT_CORE_SINGLELANGUAGE:          "Home Single Language",
+	PRODUCT_CORE:                         "Home",
+	PRODUCT_EDUCATION:                    "Education",
+	PRODUCT_EDUCATION_N:                  "Education N",
+	PRODUCT_ENTERPRISE_S:                 "Enterprise LTSC",
+	PRODUCT_ENTERPRISE_S_N:               "Enterprise N LTSC",
+	PRODUCT_PRO_WORKSTATION:              "Pro for Workstations",
+	PRODUCT_PRO_WORKSTATION_N:            "Pro N for Workstations",
+	PRODUCT_PRO_FOR_EDUCATION:            "Pro Education",
+	PRODUCT_SERVERRDSH:                   "Enterprise multi-session",
+	PRODUCT_IOTENTERPRISE:                "IoT Enterprise",
+	PRODUCT_UNLICENSED:                   "Unlicensed",
+}
+
+func (pt ProductType) String() string {
+	if name, ok := productTypeNames[pt]; ok {
+		return name
+	}
+	return fmt.Sprintf("ProductType(0x%08X)", uint32(pt))
+}
+
+// IsKnown returns true when pt is one of the ProductType constants known to
+// this package.
+func (pt ProductType) IsKnown() bool {
+	_, ok := productTypeNames[pt]
+	return ok
+}
+
+// GetProductType returns the edition of Windows running on the current machine.
+// When the edition is not one of the ProductType constants known to this
+// package, it still returns the raw value reported by the OS instead of
+// failing; use IsKnown to detect this case.
+func GetProductType() (ProductType, error) {
+	vi := getVersionInfo()
+
+	var pt ProductType
+	if err := getProductInfo(vi.major, vi.minor, uint32(vi.servicePack), 0, &pt); err != nil {
+		return PRODUCT_UNDEFINED, err
+	}
+
+	return pt, nil
+}
@@ -27,3 +27,26 @@ func DurationToTimeoutMilliseconds(d time.Duration) (uint32, error) {
 	}
 	return uint32(millis), nil
 }
+
+// The FILETIME epoch (January 1, 1601 UTC) precedes the Unix epoch by this many
+// seconds.
+const filetimeToUnixEpochSeconds = 11644473600
+
+// TimeFromFiletime converts ft into a time.Time. A zero ft yields the zero
+// time.Time.
+//
+// Unlike (windows.Filetime).Nanoseconds, TimeFromFiletime does not overflow
+// when ft is outside of the range representable by int64 nanoseconds since the
+// Unix epoch.
+func TimeFromFiletime(ft windows.Filetime) time.Time {
+	ticks := uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+	if ticks == 0 {
+		return time.Time{}
+	}
+
+	// FILETIME ticks are in 100ns units.
+	const ticksPerSecond = 10000000
+	secs := int64(ticks/ticksPerSecond) - filetimeToUnixEpochSeconds
+	nsecs := int64(ticks%ticksPerSecond) * 100
+	return time.Unix(secs, nsecs)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package wingoes
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestTimeFromFiletime(t *testing.T) {
+	testCases := []struct {
+		ft   windows.Filetime
+		want time.Time
+	}{
+		{windows.Filetime{}, time.Time{}},
+		// The Unix epoch.
+		{windows.Filetime{HighDateTime: 0x019DB1DE, LowDateTime: 0xD53E8000}, time.Unix(0, 0)},
+		// One tick past the FILETIME epoch, which overflows Filetime.Nanoseconds.
+		{windows.Filetime{LowDateTime: 1}, time.Date(1601, time.January, 1, 0, 0, 0, 100, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		if got := TimeFromFiletime(tc.ft); !got.Equal(tc.want) {
+			t.Errorf("TimeFromFiletime(%+v) got %v, want %v", tc.ft, got, tc.want)
+		}
+	}
+
+	now := time.Now()
+	ft := windows.NsecToFiletime(now.UnixNano())
+	if got := TimeFromFiletime(ft); !got.Equal(now.Truncate(100 * time.Nanosecond)) {
+		t.Errorf("TimeFromFiletime round trip got %v, want %v", got, now)
+	}
+}
+
+func TestTimeFromUnixStamp(t *testing.T) {
+	if got := TimeFromUnixStamp(0); !got.IsZero() {
+		t.Errorf("TimeFromUnixStamp(0) got %v, want zero time", got)
+	}
+
+	want := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	if got := TimeFromUnixStamp(uint32(want.Unix())); !got.Equal(want) {
+		t.Errorf("TimeFromUnixStamp got %v, want %v", got, want)
+	}
+}
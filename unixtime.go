@@ -0,0 +1,19 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package wingoes
+
+import (
+	"time"
+)
+
+// TimeFromUnixStamp converts u, a 32-bit count of seconds since the Unix epoch,
+// into a time.Time. Such timestamps are found throughout the PE format. A zero
+// u yields the zero time.Time, since binaries use zero to indicate that no
+// timestamp is present.
+func TimeFromUnixStamp(u uint32) time.Time {
+	if u == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(u), 0)
+}
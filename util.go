@@ -7,6 +7,7 @@
 package wingoes
 
 import (
+	"fmt"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -51,6 +52,137 @@ func CurrentProcessUserSIDs() (*UserSIDs, error) {
 	return &UserSIDs{User: userSid, PrimaryGroup: primaryGroupSid}, nil
 }
 
+// GroupSID contains a pointer to the SID of a group in which a token is a
+// member, along with the SE_GROUP_* attributes of that membership.
+type GroupSID struct {
+	SID        *windows.SID
+	Attributes uint32
+}
+
+// Enabled returns true when the group is enabled for access checks.
+func (g *GroupSID) Enabled() bool {
+	return g.Attributes&windows.SE_GROUP_ENABLED != 0
+}
+
+// DenyOnly returns true when the group is only used for access checks
+// against access-denied ACEs.
+func (g *GroupSID) DenyOnly() bool {
+	return g.Attributes&windows.SE_GROUP_USE_FOR_DENY_ONLY != 0
+}
+
+// IsLogonSID returns true when g's SID is the logon SID that identifies the
+// logon session associated with the token.
+func (g *GroupSID) IsLogonSID() bool {
+	return g.Attributes&windows.SE_GROUP_LOGON_ID == windows.SE_GROUP_LOGON_ID
+}
+
+// CurrentProcessGroupSIDs returns the SIDs of all groups (including the logon
+// SID) in which the current process's token is a member.
+func CurrentProcessGroupSIDs() ([]GroupSID, error) {
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return nil, err
+	}
+	defer token.Close()
+
+	groups, err := token.GetTokenGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	all := groups.AllGroups()
+	result := make([]GroupSID, 0, len(all))
+	for _, g := range all {
+		// We just want the SIDs, not the rest of the buffer that was output.
+		sid, err := g.Sid.Copy()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, GroupSID{SID: sid, Attributes: g.Attributes})
+	}
+
+	return result, nil
+}
+
+// IntegrityLevel is the mandatory integrity level of a security token. Its
+// value is the RID of the token's integrity level SID, so IntegrityLevels
+// may be compared using the usual relational operators.
+type IntegrityLevel uint32
+
+const (
+	IntegrityLevelUntrusted        = IntegrityLevel(0x0000)
+	IntegrityLevelLow              = IntegrityLevel(0x1000)
+	IntegrityLevelMedium           = IntegrityLevel(0x2000)
+	IntegrityLevelMediumPlus       = IntegrityLevel(0x2100)
+	IntegrityLevelHigh             = IntegrityLevel(0x3000)
+	IntegrityLevelSystem           = IntegrityLevel(0x4000)
+	IntegrityLevelProtectedProcess = IntegrityLevel(0x5000)
+)
+
+func (il IntegrityLevel) String() string {
+	switch il {
+	case IntegrityLevelUntrusted:
+		return "Untrusted"
+	case IntegrityLevelLow:
+		return "Low"
+	case IntegrityLevelMedium:
+		return "Medium"
+	case IntegrityLevelMediumPlus:
+		return "MediumPlus"
+	case IntegrityLevelHigh:
+		return "High"
+	case IntegrityLevelSystem:
+		return "System"
+	case IntegrityLevelProtectedProcess:
+		return "ProtectedProcess"
+	default:
+		return fmt.Sprintf("IntegrityLevel(0x%04X)", uint32(il))
+	}
+}
+
+// CurrentProcessIntegrityLevel returns the integrity level of the current
+// process's token.
+func CurrentProcessIntegrityLevel() (IntegrityLevel, error) {
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return 0, err
+	}
+	defer token.Close()
+
+	label, err := getTokenInfoVariableLen[windows.Tokenmandatorylabel](token, windows.TokenIntegrityLevel)
+	if err != nil {
+		return 0, err
+	}
+
+	// The integrity level is encoded in the final subauthority of the label's SID.
+	sid := label.Label.Sid
+	n := sid.SubAuthorityCount()
+	if n == 0 {
+		return 0, windows.ERROR_INVALID_SID
+	}
+
+	return IntegrityLevel(sid.SubAuthority(uint32(n - 1))), nil
+}
+
+// IsCurrentProcessElevated returns true when the current process's token is
+// elevated. Unlike checking for membership in the Administrators group, this
+// produces the correct result when UAC has issued the process a filtered token.
+func IsCurrentProcessElevated() (bool, error) {
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return false, err
+	}
+	defer token.Close()
+
+	// TOKEN_ELEVATION consists of a single DWORD.
+	elevation, err := getTokenInfoFixedLen[uint32](token, windows.TokenElevation)
+	if err != nil {
+		return false, err
+	}
+
+	return elevation != 0, nil
+}
+
 // getTokenInfoVariableLen obtains variable-length token information. Use
 // this function for information classes that output variable-length data.
 func getTokenInfoVariableLen[T any](token windows.Token, infoClass uint32) (*T, error) {
@@ -0,0 +1,61 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build windows
+
+package wingoes
+
+import (
+	"testing"
+)
+
+func TestCurrentProcessIntegrityLevel(t *testing.T) {
+	il, err := CurrentProcessIntegrityLevel()
+	if err != nil {
+		t.Fatalf("CurrentProcessIntegrityLevel error: %v", err)
+	}
+	if il < IntegrityLevelLow || il > IntegrityLevelSystem {
+		t.Errorf("Unexpected integrity level %v for test process", il)
+	}
+	t.Logf("Integrity level: %v", il)
+}
+
+func TestIsCurrentProcessElevated(t *testing.T) {
+	elevated, err := IsCurrentProcessElevated()
+	if err != nil {
+		t.Fatalf("IsCurrentProcessElevated error: %v", err)
+	}
+
+	il, err := CurrentProcessIntegrityLevel()
+	if err != nil {
+		t.Fatalf("CurrentProcessIntegrityLevel error: %v", err)
+	}
+
+	if elevated && il < IntegrityLevelHigh {
+		t.Errorf("Elevated process has unexpected integrity level %v", il)
+	}
+}
+
+func TestCurrentProcessGroupSIDs(t *testing.T) {
+	groups, err := CurrentProcessGroupSIDs()
+	if err != nil {
+		t.Fatalf("CurrentProcessGroupSIDs error: %v", err)
+	}
+	if len(groups) == 0 {
+		t.Fatalf("CurrentProcessGroupSIDs returned no groups")
+	}
+
+	var foundLogon bool
+	for _, g := range groups {
+		if !g.SID.IsValid() {
+			t.Errorf("Invalid group SID")
+		}
+		if g.IsLogonSID() {
+			foundLogon = true
+		}
+		t.Logf("%v: enabled %v, deny-only %v, logon %v", g.SID, g.Enabled(), g.DenyOnly(), g.IsLogonSID())
+	}
+	if !foundLogon {
+		t.Logf("No logon SID present in token groups")
+	}
+}